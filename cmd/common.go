@@ -8,6 +8,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/bootstrap"
 	"github.com/alist-org/alist/v3/internal/bootstrap/data"
 	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	log "github.com/sirupsen/logrus"
 )
@@ -22,6 +23,7 @@ func Init() {
 
 func Release() {
 	db.Close()
+	op.ClosePersistCache()
 }
 
 var pid = -1
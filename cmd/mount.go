@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/alist-org/alist/v3/internal/bootstrap"
+	"github.com/alist-org/alist/v3/internal/fuse"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mountReadAhead int
+	mountOptions   []string
+)
+
+// MountCmd represents the mount command
+var MountCmd = &cobra.Command{
+	Use:   "mount <alist path> <local dir>",
+	Short: "Mount a path of alist at a local directory via FUSE",
+	Long: `Mount a path of the alist virtual file system at a local directory,
+so that all storages below it can be used by ordinary tools.
+AList must be built with -tags fuse to use this command.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		Init()
+		defer Release()
+		bootstrap.InitListCache()
+		bootstrap.LoadStorages()
+		bootstrap.InitTaskManager()
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		utils.Log.Infof("mount %s at %s", args[0], args[1])
+		if err := fuse.Mount(ctx, args[0], args[1], mountReadAhead, mountOptions); err != nil {
+			utils.Log.Errorf("failed to mount: %+v", err)
+			Release()
+			os.Exit(1)
+		}
+		utils.Log.Infof("unmounted %s", args[1])
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(MountCmd)
+	MountCmd.Flags().IntVar(&mountReadAhead, "read-ahead", fuse.DefaultReadAhead, "read-ahead buffer size in bytes of each opened file")
+	MountCmd.Flags().StringSliceVarP(&mountOptions, "option", "o", nil, "extra options passed to fuse, e.g. -o allow_other")
+}
@@ -18,6 +18,8 @@ import (
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server"
+	"github.com/alist-org/alist/v3/server/ftp"
+	"github.com/alist-org/alist/v3/server/sftp"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -36,8 +38,14 @@ the address is defined in config file`,
 			time.Sleep(time.Duration(conf.Conf.DelayedStart) * time.Second)
 		}
 		bootstrap.InitOfflineDownloadTools()
+		bootstrap.InitListCache()
 		bootstrap.LoadStorages()
 		bootstrap.InitTaskManager()
+		bootstrap.InitMetrics()
+		bootstrap.InitSyncJobs()
+		bootstrap.InitTrashCleaner()
+		bootstrap.InitUsageReconciler()
+		bootstrap.InitAuditCleaner()
 		if !flags.Debug && !flags.Dev {
 			gin.SetMode(gin.ReleaseMode)
 		}
@@ -112,6 +120,36 @@ the address is defined in config file`,
 				}
 			}()
 		}
+		var ftpSrv *ftp.Server
+		if conf.Conf.FTP.Enable {
+			var err error
+			ftpSrv, err = ftp.NewServer(conf.Conf.FTP)
+			if err != nil {
+				utils.Log.Fatalf("failed to start ftp server: %s", err.Error())
+			}
+			utils.Log.Infof("start FTP server @ %s", conf.Conf.FTP.Listen)
+			go func() {
+				err := ftpSrv.Serve()
+				if err != nil && !errors.Is(err, ftp.ErrServerClosed) {
+					utils.Log.Fatalf("failed to start ftp server: %s", err.Error())
+				}
+			}()
+		}
+		var sftpSrv *sftp.Server
+		if conf.Conf.SFTP.Enable {
+			var err error
+			sftpSrv, err = sftp.NewServer(conf.Conf.SFTP)
+			if err != nil {
+				utils.Log.Fatalf("failed to start sftp server: %s", err.Error())
+			}
+			utils.Log.Infof("start SFTP server @ %s", conf.Conf.SFTP.Listen)
+			go func() {
+				err := sftpSrv.Serve()
+				if err != nil && !errors.Is(err, sftp.ErrServerClosed) {
+					utils.Log.Fatalf("failed to start sftp server: %s", err.Error())
+				}
+			}()
+		}
 		// Wait for interrupt signal to gracefully shutdown the server with
 		// a timeout of 1 second.
 		quit := make(chan os.Signal, 1)
@@ -152,6 +190,16 @@ the address is defined in config file`,
 				}
 			}()
 		}
+		if ftpSrv != nil {
+			if err := ftpSrv.Close(); err != nil {
+				utils.Log.Errorf("FTP server shutdown err: %s", err.Error())
+			}
+		}
+		if sftpSrv != nil {
+			if err := sftpSrv.Close(); err != nil {
+				utils.Log.Errorf("SFTP server shutdown err: %s", err.Error())
+			}
+		}
 		wg.Wait()
 		utils.Log.Println("Server exit")
 	},
@@ -0,0 +1,320 @@
+package chunker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// Chunker splits the files bigger than the chunk size into chunks in the
+// remote storage. A chunked file is made up of a small meta object with the
+// name of the file and the chunks named by the name format, like rclone chunker.
+type Chunker struct {
+	model.Storage
+	Addition
+	remoteStorage    driver.Driver
+	remoteActualPath string
+	chunkSize        int64
+	nameFormat       *nameFormat
+}
+
+func (d *Chunker) Config() driver.Config {
+	return config
+}
+
+func (d *Chunker) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Chunker) Init(ctx context.Context) error {
+	if d.ChunkSize <= 0 {
+		return fmt.Errorf("chunk size must be positive")
+	}
+	d.chunkSize = d.ChunkSize * utils.MB
+	d.NameFormat = utils.GetNoneEmpty(d.NameFormat, "*.rclone_chunk.###")
+	d.MetaFormat = utils.GetNoneEmpty(d.MetaFormat, "simplejson")
+	var err error
+	d.nameFormat, err = parseNameFormat(d.NameFormat)
+	if err != nil {
+		return fmt.Errorf("invalid name format: %w", err)
+	}
+	//need remote storage exist
+	storage, actualPath, err := op.GetStorageAndActualPath(d.RemotePath)
+	if err != nil {
+		return fmt.Errorf("can't find remote storage: %w", err)
+	}
+	d.remoteStorage, d.remoteActualPath = storage, actualPath
+	return nil
+}
+
+func (d *Chunker) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *Chunker) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	dirs, files, err := d.listRemote(ctx, stdpath.Join(d.remoteActualPath, dir.GetPath()))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.Obj, 0, len(dirs)+len(files))
+	for _, obj := range dirs {
+		result = append(result, &model.Object{
+			Path:     stdpath.Join(dir.GetPath(), obj.GetName()),
+			Name:     obj.GetName(),
+			Modified: obj.ModTime(),
+			Ctime:    obj.CreateTime(),
+			IsFolder: true,
+		})
+	}
+	for _, f := range files {
+		result = append(result, d.toObj(f, stdpath.Join(dir.GetPath(), f.name)))
+	}
+	return result, nil
+}
+
+func (d *Chunker) Get(ctx context.Context, path string) (model.Obj, error) {
+	if utils.PathEqual(path, "/") {
+		return &model.Object{
+			Name:     "Root",
+			IsFolder: true,
+			Path:     "/",
+		}, nil
+	}
+	f, dir, err := d.findRemote(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if dir != nil {
+		return &model.Object{
+			Path:     path,
+			Name:     dir.GetName(),
+			Modified: dir.ModTime(),
+			Ctime:    dir.CreateTime(),
+			IsFolder: true,
+		}, nil
+	}
+	obj := d.toObj(f, path)
+	if len(f.chunks) > 0 && f.meta != nil {
+		// the hashes of the whole file are kept in the meta object
+		if meta, err := d.readMeta(ctx, stdpath.Join(d.remoteActualPath, stdpath.Dir(path)), f.meta); err == nil {
+			hashes := map[*utils.HashType]string{}
+			if meta.MD5 != "" {
+				hashes[utils.MD5] = meta.MD5
+			}
+			if meta.SHA1 != "" {
+				hashes[utils.SHA1] = meta.SHA1
+			}
+			obj.(*model.Object).HashInfo = utils.NewHashInfoByMap(hashes)
+		} else {
+			log.Warnf("failed read meta of %s: %s", path, err)
+		}
+	}
+	return obj, nil
+}
+
+func (d *Chunker) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	f, _, err := d.findRemote(ctx, file.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	dirActualPath := stdpath.Join(d.remoteActualPath, stdpath.Dir(file.GetPath()))
+	if len(f.chunks) == 0 {
+		link, _, err := op.Link(ctx, d.remoteStorage, stdpath.Join(dirActualPath, f.name), args)
+		return link, err
+	}
+	size := f.size()
+	rrc := &model.RangeReadCloser{}
+	rrc.RangeReader = func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+		length := httpRange.Length
+		if length < 0 || httpRange.Start+length > size {
+			length = size - httpRange.Start
+		}
+		return d.newChunksReader(ctx, dirActualPath, f.chunks, httpRange.Start, length, &rrc.Closers), nil
+	}
+	return &model.Link{
+		RangeReadCloser: rrc,
+	}, nil
+}
+
+func (d *Chunker) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	return op.MakeDir(ctx, d.remoteStorage, stdpath.Join(d.remoteActualPath, parentDir.GetPath(), dirName))
+}
+
+func (d *Chunker) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	dstDirActualPath := stdpath.Join(d.remoteActualPath, dstDir.GetPath())
+	return d.forEachPart(ctx, srcObj.GetPath(), func(actualPath string, n int) error {
+		return op.Move(ctx, d.remoteStorage, actualPath, dstDirActualPath)
+	})
+}
+
+func (d *Chunker) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	return d.forEachPart(ctx, srcObj.GetPath(), func(actualPath string, n int) error {
+		name := newName
+		if n >= 0 {
+			name = d.chunkName(newName, n)
+		}
+		return op.Rename(ctx, d.remoteStorage, actualPath, name)
+	})
+}
+
+func (d *Chunker) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	dstDirActualPath := stdpath.Join(d.remoteActualPath, dstDir.GetPath())
+	return d.forEachPart(ctx, srcObj.GetPath(), func(actualPath string, n int) error {
+		return op.Copy(ctx, d.remoteStorage, actualPath, dstDirActualPath)
+	})
+}
+
+func (d *Chunker) Remove(ctx context.Context, obj model.Obj) error {
+	return d.forEachPart(ctx, obj.GetPath(), func(actualPath string, n int) error {
+		return op.Remove(ctx, d.remoteStorage, actualPath)
+	})
+}
+
+func (d *Chunker) Put(ctx context.Context, dstDir model.Obj, streamer model.FileStreamer, up driver.UpdateProgress) error {
+	dirActualPath := stdpath.Join(d.remoteActualPath, dstDir.GetPath())
+	name, size := streamer.GetName(), streamer.GetSize()
+	if size <= d.chunkSize {
+		// small files are stored as is
+		if err := op.Put(ctx, d.remoteStorage, dirActualPath, streamer, up, false); err != nil {
+			return err
+		}
+		return d.removeChunks(ctx, dirActualPath, name, 0)
+	}
+	hasher := utils.NewMultiHasher([]*utils.HashType{utils.MD5, utils.SHA1})
+	reader := io.TeeReader(streamer, hasher)
+	nChunks := int((size + d.chunkSize - 1) / d.chunkSize)
+	for i := 0; i < nChunks; i++ {
+		if utils.IsCanceled(ctx) {
+			return ctx.Err()
+		}
+		offset := int64(i) * d.chunkSize
+		partSize := d.chunkSize
+		if size-offset < partSize {
+			partSize = size - offset
+		}
+		part := &stream.FileStream{
+			Obj: &model.Object{
+				Name:     d.chunkName(name, i),
+				Size:     partSize,
+				Modified: streamer.ModTime(),
+			},
+			Reader:            io.LimitReader(reader, partSize),
+			Mimetype:          "application/octet-stream",
+			WebPutAsTask:      streamer.NeedStore(),
+			ForceStreamUpload: true,
+		}
+		err := op.Put(ctx, d.remoteStorage, dirActualPath, part, func(p float64) {
+			up((float64(offset) + float64(partSize)*p/100) / float64(size) * 100)
+		}, false)
+		if err != nil {
+			return fmt.Errorf("failed to put chunk %d: %w", i, err)
+		}
+	}
+	if d.MetaFormat == "simplejson" {
+		hashes := hasher.GetHashInfo()
+		meta := metadata{
+			Version: 1,
+			Size:    size,
+			NChunks: nChunks,
+			MD5:     hashes.GetHash(utils.MD5),
+			SHA1:    hashes.GetHash(utils.SHA1),
+		}
+		if err := d.putMeta(ctx, dirActualPath, name, streamer, meta); err != nil {
+			return err
+		}
+	} else if _, err := op.Get(ctx, d.remoteStorage, stdpath.Join(dirActualPath, name)); err == nil {
+		// an old file with the same name is left
+		if err = op.Remove(ctx, d.remoteStorage, stdpath.Join(dirActualPath, name)); err != nil {
+			return err
+		}
+	}
+	return d.removeChunks(ctx, dirActualPath, name, nChunks)
+}
+
+func (d *Chunker) putMeta(ctx context.Context, dirActualPath, name string, streamer model.FileStreamer, meta metadata) error {
+	data, err := utils.Json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     int64(len(data)),
+			Modified: streamer.ModTime(),
+		},
+		Reader:   bytes.NewReader(data),
+		Mimetype: "application/json",
+	}
+	return op.Put(ctx, d.remoteStorage, dirActualPath, s, nil, false)
+}
+
+func (d *Chunker) readMeta(ctx context.Context, dirActualPath string, obj model.Obj) (*metadata, error) {
+	if obj.GetSize() > maxMetaSize {
+		return nil, errs.NotSupport
+	}
+	closers := utils.EmptyClosers()
+	defer closers.Close()
+	rc, err := d.openChunk(ctx, dirActualPath, obj, 0, obj.GetSize(), &closers)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	var meta metadata
+	if err = utils.Json.NewDecoder(rc).Decode(&meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// forEachPart calls f with the actual path of each part of the object, n is the chunk number,
+// or -1 for the meta object and dirs
+func (d *Chunker) forEachPart(ctx context.Context, path string, f func(actualPath string, n int) error) error {
+	file, dir, err := d.findRemote(ctx, path)
+	if err != nil {
+		return err
+	}
+	dirActualPath := stdpath.Join(d.remoteActualPath, stdpath.Dir(path))
+	if dir != nil {
+		return f(stdpath.Join(dirActualPath, dir.GetName()), -1)
+	}
+	if file.meta != nil {
+		if err = f(stdpath.Join(dirActualPath, file.meta.GetName()), -1); err != nil {
+			return err
+		}
+	}
+	for i, chunk := range file.chunks {
+		if err = f(stdpath.Join(dirActualPath, chunk.GetName()), i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeChunks removes the chunks of name numbered from, which are left by an old version
+func (d *Chunker) removeChunks(ctx context.Context, dirActualPath, name string, from int) error {
+	objs, err := op.List(ctx, d.remoteStorage, dirActualPath, model.ListArgs{})
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		if file, n, ok := d.parseChunkName(obj.GetName()); ok && file == name && n >= from {
+			if err = op.Remove(ctx, d.remoteStorage, stdpath.Join(dirActualPath, obj.GetName())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var _ driver.Driver = (*Chunker)(nil)
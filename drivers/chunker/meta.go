@@ -0,0 +1,28 @@
+package chunker
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+type Addition struct {
+	RemotePath string `json:"remote_path" required:"true" help:"This is where the chunks store"`
+	ChunkSize  int64  `json:"chunk_size" type:"number" required:"true" default:"2048" help:"max size of each chunk in MB"`
+	NameFormat string `json:"name_format" required:"true" default:"*.rclone_chunk.###" help:"for advanced user only! * is the file name, ### is the zero padded chunk number"`
+	StartFrom  int    `json:"start_from" type:"number" default:"1" help:"for advanced user only! the number of the first chunk"`
+	MetaFormat string `json:"meta_format" type:"select" options:"simplejson,none" default:"simplejson" help:"simplejson is compatible with rclone chunker"`
+}
+
+var config = driver.Config{
+	Name:        "Chunker",
+	LocalSort:   true,
+	OnlyProxy:   true,
+	NoCache:     true,
+	DefaultRoot: "/",
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Chunker{}
+	})
+}
@@ -0,0 +1,39 @@
+package chunker
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// metadata is the content of the meta object of a chunked file,
+// the same as the simplejson format of rclone chunker
+type metadata struct {
+	Version int    `json:"ver"`
+	Size    int64  `json:"size"`
+	NChunks int    `json:"nchunks"`
+	MD5     string `json:"md5,omitempty"`
+	SHA1    string `json:"sha1,omitempty"`
+	TxnID   string `json:"txn,omitempty"`
+}
+
+// maxMetaSize is the max size of a meta object, a bigger object
+// with the name of a chunked file is treated as a normal file
+const maxMetaSize = 1023
+
+// chunkedObj is a logical file in the remote dir, it's a normal
+// file if it has no chunks
+type chunkedObj struct {
+	name   string
+	meta   model.Obj   // the meta object, or the file itself if it's not chunked
+	chunks []model.Obj // ordered by chunk number
+}
+
+func (c *chunkedObj) size() int64 {
+	if len(c.chunks) == 0 {
+		return c.meta.GetSize()
+	}
+	var size int64
+	for _, chunk := range c.chunks {
+		size += chunk.GetSize()
+	}
+	return size
+}
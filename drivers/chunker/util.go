@@ -0,0 +1,264 @@
+package chunker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	stdpath "path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// nameFormat is a parsed name format like "*.rclone_chunk.###",
+// the sequence of # is in between prefix and suffix
+type nameFormat struct {
+	prefix, suffix string
+	width          int
+	re             *regexp.Regexp
+}
+
+func parseNameFormat(format string) (*nameFormat, error) {
+	if strings.Count(format, "*") != 1 {
+		return nil, fmt.Errorf("name format must contain one *")
+	}
+	locs := regexp.MustCompile(`#+`).FindAllStringIndex(format, -1)
+	if len(locs) != 1 {
+		return nil, fmt.Errorf("name format must contain one sequence of #")
+	}
+	f := &nameFormat{
+		prefix: format[:locs[0][0]],
+		suffix: format[locs[0][1]:],
+		width:  locs[0][1] - locs[0][0],
+	}
+	expr := regexp.QuoteMeta(f.prefix) + `([0-9]{` + strconv.Itoa(f.width) + `,})` + regexp.QuoteMeta(f.suffix)
+	expr = strings.Replace(expr, regexp.QuoteMeta("*"), `(.+)`, 1)
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, err
+	}
+	f.re = re
+	return f, nil
+}
+
+func (d *Chunker) chunkName(name string, n int) string {
+	f := d.nameFormat
+	return strings.Replace(f.prefix, "*", name, 1) + fmt.Sprintf("%0*d", f.width, n+d.StartFrom) + strings.Replace(f.suffix, "*", name, 1)
+}
+
+// parseChunkName returns the file name and the index of a chunk,
+// ok is false if name is not a chunk
+func (d *Chunker) parseChunkName(name string) (string, int, bool) {
+	m := d.nameFormat.re.FindStringSubmatch(name)
+	if m == nil {
+		return "", 0, false
+	}
+	// the number may be before the name in the format
+	file, num := m[1], m[2]
+	if strings.Contains(d.nameFormat.suffix, "*") {
+		file, num = m[2], m[1]
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil || n < d.StartFrom {
+		return "", 0, false
+	}
+	return file, n - d.StartFrom, true
+}
+
+// listRemote groups the objects in the remote dir into logical files
+func (d *Chunker) listRemote(ctx context.Context, dirActualPath string) ([]model.Obj, []*chunkedObj, error) {
+	objs, err := op.List(ctx, d.remoteStorage, dirActualPath, model.ListArgs{})
+	if err != nil {
+		return nil, nil, err
+	}
+	var dirs []model.Obj
+	files := make(map[string]*chunkedObj)
+	indexes := make(map[model.Obj]int)
+	get := func(name string) *chunkedObj {
+		if f, ok := files[name]; ok {
+			return f
+		}
+		f := &chunkedObj{name: name}
+		files[name] = f
+		return f
+	}
+	for _, obj := range objs {
+		if obj.IsDir() {
+			dirs = append(dirs, obj)
+			continue
+		}
+		if name, n, ok := d.parseChunkName(obj.GetName()); ok {
+			f := get(name)
+			f.chunks = append(f.chunks, obj)
+			indexes[obj] = n
+			continue
+		}
+		get(obj.GetName()).meta = obj
+	}
+	res := make([]*chunkedObj, 0, len(files))
+	for _, f := range files {
+		sort.Slice(f.chunks, func(i, j int) bool {
+			return indexes[f.chunks[i]] < indexes[f.chunks[j]]
+		})
+		if len(f.chunks) > 0 && f.meta != nil && f.meta.GetSize() > maxMetaSize {
+			// a normal file happens to have the name, hide the chunks
+			f.chunks = nil
+		}
+		if len(f.chunks) > 0 && !d.chunksComplete(f, indexes) {
+			// an incomplete upload, don't show it
+			if f.meta == nil {
+				continue
+			}
+			f.chunks = nil
+		}
+		res = append(res, f)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].name < res[j].name
+	})
+	return dirs, res, nil
+}
+
+// chunksComplete checks that the chunk numbers are continuous from 0
+func (d *Chunker) chunksComplete(f *chunkedObj, indexes map[model.Obj]int) bool {
+	for i, chunk := range f.chunks {
+		if indexes[chunk] != i {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *Chunker) findRemote(ctx context.Context, path string) (*chunkedObj, model.Obj, error) {
+	dirActualPath := stdpath.Join(d.remoteActualPath, stdpath.Dir(path))
+	name := stdpath.Base(path)
+	dirs, files, err := d.listRemote(ctx, dirActualPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, dir := range dirs {
+		if dir.GetName() == name {
+			return nil, dir, nil
+		}
+	}
+	for _, f := range files {
+		if f.name == name {
+			return f, nil, nil
+		}
+	}
+	return nil, nil, errs.ObjectNotFound
+}
+
+func (d *Chunker) toObj(f *chunkedObj, path string) model.Obj {
+	obj := &model.Object{
+		Path:     path,
+		Name:     f.name,
+		Size:     f.size(),
+		IsFolder: false,
+	}
+	src := f.meta
+	if src == nil {
+		src = f.chunks[len(f.chunks)-1]
+	}
+	obj.Modified, obj.Ctime = src.ModTime(), src.CreateTime()
+	if len(f.chunks) == 0 {
+		obj.HashInfo = src.GetHash()
+	}
+	return obj
+}
+
+// openChunk reads length bytes of the chunk from offset
+func (d *Chunker) openChunk(ctx context.Context, dirActualPath string, chunk model.Obj, offset, length int64, closers *utils.Closers) (io.ReadCloser, error) {
+	link, file, err := op.Link(ctx, d.remoteStorage, stdpath.Join(dirActualPath, chunk.GetName()), model.LinkArgs{})
+	if err != nil {
+		return nil, err
+	}
+	if link.MFile != nil {
+		closers.Add(link.MFile)
+		return io.NopCloser(io.NewSectionReader(link.MFile, offset, length)), nil
+	}
+	rrc := link.RangeReadCloser
+	if rrc == nil {
+		if rrc, err = stream.GetRangeReadCloserFromLink(file.GetSize(), link); err != nil {
+			return nil, err
+		}
+	}
+	closers.AddClosers(rrc.GetClosers())
+	return rrc.RangeRead(ctx, http_range.Range{Start: offset, Length: length})
+}
+
+// chunksReader reads a range across the chunks, the chunks are opened one by one
+type chunksReader struct {
+	ctx       context.Context
+	d         *Chunker
+	dir       string
+	chunks    []model.Obj
+	idx       int
+	offset    int64 // offset in the current chunk
+	remaining int64
+	cur       io.ReadCloser
+	closers   *utils.Closers
+}
+
+// newChunksReader reads length bytes from start of the file made up of the chunks
+func (d *Chunker) newChunksReader(ctx context.Context, dirActualPath string, chunks []model.Obj, start, length int64, closers *utils.Closers) *chunksReader {
+	r := &chunksReader{ctx: ctx, d: d, dir: dirActualPath, chunks: chunks, remaining: length, closers: closers}
+	for r.idx < len(chunks) && start >= chunks[r.idx].GetSize() {
+		start -= chunks[r.idx].GetSize()
+		r.idx++
+	}
+	r.offset = start
+	return r
+}
+
+func (r *chunksReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if r.cur == nil {
+		if r.idx >= len(r.chunks) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		chunk := r.chunks[r.idx]
+		length := chunk.GetSize() - r.offset
+		if length > r.remaining {
+			length = r.remaining
+		}
+		rc, err := r.d.openChunk(r.ctx, r.dir, chunk, r.offset, length, r.closers)
+		if err != nil {
+			return 0, err
+		}
+		r.cur = rc
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.cur.Read(p)
+	r.remaining -= int64(n)
+	if errors.Is(err, io.EOF) {
+		// go on with the next chunk
+		err = r.cur.Close()
+		r.cur = nil
+		r.idx++
+		r.offset = 0
+	}
+	return n, err
+}
+
+func (r *chunksReader) Close() error {
+	if r.cur == nil {
+		return nil
+	}
+	err := r.cur.Close()
+	r.cur = nil
+	return err
+}
@@ -0,0 +1,34 @@
+package chunker
+
+import "testing"
+
+func TestChunkName(t *testing.T) {
+	tests := []struct {
+		format string
+		name   string
+		n      int
+		want   string
+	}{
+		{"*.rclone_chunk.###", "movie.mkv", 0, "movie.mkv.rclone_chunk.001"},
+		{"*.rclone_chunk.###", "movie.mkv", 1233, "movie.mkv.rclone_chunk.1234"},
+		{"##_*", "a.b", 9, "10_a.b"},
+	}
+	for _, tt := range tests {
+		f, err := parseNameFormat(tt.format)
+		if err != nil {
+			t.Fatalf("failed parse %s: %+v", tt.format, err)
+		}
+		d := &Chunker{Addition: Addition{NameFormat: tt.format, StartFrom: 1}, nameFormat: f}
+		got := d.chunkName(tt.name, tt.n)
+		if got != tt.want {
+			t.Errorf("chunkName(%s, %d) = %s, want %s", tt.name, tt.n, got, tt.want)
+		}
+		name, n, ok := d.parseChunkName(got)
+		if !ok || name != tt.name || n != tt.n {
+			t.Errorf("parseChunkName(%s) = %s, %d, %v", got, name, n, ok)
+		}
+	}
+	if _, err := parseNameFormat("*.chunk"); err == nil {
+		t.Errorf("expect error for format without #")
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/djherbis/times"
+	"github.com/shirou/gopsutil/v3/disk"
 	log "github.com/sirupsen/logrus"
 	_ "golang.org/x/image/webp"
 )
@@ -299,4 +300,13 @@ func (d *Local) Put(ctx context.Context, dstDir model.Obj, stream model.FileStre
 	return nil
 }
 
+func (d *Local) FreeSpace(ctx context.Context) (uint64, error) {
+	usage, err := disk.UsageWithContext(ctx, d.GetRootPath())
+	if err != nil {
+		return 0, err
+	}
+	return usage.Free, nil
+}
+
 var _ driver.Driver = (*Local)(nil)
+var _ driver.FreeSpace = (*Local)(nil)
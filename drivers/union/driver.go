@@ -0,0 +1,187 @@
+package union
+
+import (
+	"context"
+	"errors"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+type Union struct {
+	model.Storage
+	Addition
+	branches []string
+	rrIndex  uint32
+}
+
+func (d *Union) Config() driver.Config {
+	return config
+}
+
+func (d *Union) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Union) Init(ctx context.Context) error {
+	d.branches = nil
+	for _, path := range strings.Split(d.Paths, "\n") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		path = utils.FixAndCleanPath(path)
+		if utils.IsSubPath(d.MountPath, path) || utils.IsSubPath(path, d.MountPath) {
+			return errors.New("a branch can't contain or be inside the union itself")
+		}
+		d.branches = append(d.branches, path)
+	}
+	if len(d.branches) == 0 {
+		return errors.New("paths is required")
+	}
+	if d.CreatePolicy == "" {
+		d.CreatePolicy = PolicyFirstFound
+	}
+	if d.ActionPolicy == "" {
+		d.ActionPolicy = PolicyAll
+	}
+	return nil
+}
+
+func (d *Union) Drop(ctx context.Context) error {
+	d.branches = nil
+	return nil
+}
+
+func (d *Union) Get(ctx context.Context, path string) (model.Obj, error) {
+	if utils.PathEqual(path, "/") {
+		return &model.Object{
+			Name:     "Root",
+			IsFolder: true,
+			Path:     "/",
+		}, nil
+	}
+	for _, branch := range d.branches {
+		obj, err := fs.Get(ctx, stdpath.Join(branch, path), &fs.GetArgs{NoLog: true})
+		if err == nil {
+			return toObj(obj, path), nil
+		}
+	}
+	return nil, errs.ObjectNotFound
+}
+
+func (d *Union) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	var (
+		objs  []model.Obj
+		names = make(map[string]struct{})
+		found bool
+	)
+	for _, branch := range d.branches {
+		tmp, err := fs.List(ctx, stdpath.Join(branch, dir.GetPath()), &fs.ListArgs{NoLog: true})
+		if err != nil {
+			continue
+		}
+		found = true
+		for _, obj := range tmp {
+			if _, ok := names[obj.GetName()]; ok {
+				continue
+			}
+			names[obj.GetName()] = struct{}{}
+			objs = append(objs, toObj(obj, stdpath.Join(dir.GetPath(), obj.GetName())))
+		}
+	}
+	if !found {
+		return nil, errs.ObjectNotFound
+	}
+	return objs, nil
+}
+
+func (d *Union) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	for _, branch := range d.branches {
+		link, err := d.link(ctx, stdpath.Join(branch, file.GetPath()), args)
+		if err == nil {
+			return link, nil
+		}
+	}
+	return nil, errs.ObjectNotFound
+}
+
+func (d *Union) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	branch, err := d.createBranch(ctx, parentDir.GetPath())
+	if err != nil {
+		return err
+	}
+	return fs.MakeDir(ctx, stdpath.Join(branch, parentDir.GetPath(), dirName))
+}
+
+func (d *Union) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	ctx = context.WithValue(ctx, conf.NoTaskKey, struct{}{})
+	return d.action(ctx, srcObj.GetPath(), func(branch string) error {
+		dstDirPath := stdpath.Join(branch, dstDir.GetPath())
+		if err := fs.MakeDir(ctx, dstDirPath); err != nil {
+			return err
+		}
+		_, err := fs.Move(ctx, stdpath.Join(branch, srcObj.GetPath()), dstDirPath)
+		return err
+	})
+}
+
+func (d *Union) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	return d.action(ctx, srcObj.GetPath(), func(branch string) error {
+		return fs.Rename(ctx, stdpath.Join(branch, srcObj.GetPath()), newName)
+	})
+}
+
+// Copy copies the object in the first branch containing it, the copy stays in that branch
+func (d *Union) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	ctx = context.WithValue(ctx, conf.NoTaskKey, struct{}{})
+	for _, branch := range d.branches {
+		srcPath := stdpath.Join(branch, srcObj.GetPath())
+		if _, err := fs.Get(ctx, srcPath, &fs.GetArgs{NoLog: true}); err != nil {
+			continue
+		}
+		dstDirPath := stdpath.Join(branch, dstDir.GetPath())
+		if err := fs.MakeDir(ctx, dstDirPath); err != nil {
+			return err
+		}
+		_, err := fs.Copy(ctx, srcPath, dstDirPath)
+		return err
+	}
+	return errs.ObjectNotFound
+}
+
+func (d *Union) Remove(ctx context.Context, obj model.Obj) error {
+	return d.action(ctx, obj.GetPath(), func(branch string) error {
+		return fs.Remove(ctx, stdpath.Join(branch, obj.GetPath()))
+	})
+}
+
+func (d *Union) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	branch, err := d.createBranch(ctx, dstDir.GetPath())
+	if err != nil {
+		return err
+	}
+	storage, dstDirActualPath, err := op.GetStorageAndActualPath(stdpath.Join(branch, dstDir.GetPath()))
+	if err != nil {
+		return err
+	}
+	return op.Put(ctx, storage, dstDirActualPath, stream, up)
+}
+
+func (d *Union) FreeSpace(ctx context.Context) (uint64, error) {
+	var total uint64
+	for _, branch := range d.branches {
+		total += branchFreeSpace(ctx, branch)
+	}
+	return total, nil
+}
+
+var _ driver.Driver = (*Union)(nil)
+var _ driver.FreeSpace = (*Union)(nil)
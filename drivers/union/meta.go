@@ -0,0 +1,27 @@
+package union
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+type Addition struct {
+	// the branches are in order of priority, a name existing in several
+	// branches is shown as the one of the first branch
+	Paths        string `json:"paths" required:"true" type:"text" help:"alist paths to merge, one per line, in order of priority"`
+	CreatePolicy string `json:"create_policy" type:"select" options:"first-found,most-free-space,round-robin" default:"first-found" help:"how to choose the branch of new files and dirs"`
+	ActionPolicy string `json:"action_policy" type:"select" options:"all,first-found" default:"all" help:"apply remove and rename to all branches or only the first one"`
+}
+
+var config = driver.Config{
+	Name:        "Union",
+	LocalSort:   true,
+	NoCache:     true,
+	DefaultRoot: "/",
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Union{}
+	})
+}
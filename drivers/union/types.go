@@ -0,0 +1,8 @@
+package union
+
+const (
+	PolicyFirstFound    = "first-found"
+	PolicyMostFreeSpace = "most-free-space"
+	PolicyRoundRobin    = "round-robin"
+	PolicyAll           = "all"
+)
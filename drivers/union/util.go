@@ -0,0 +1,130 @@
+package union
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"sync/atomic"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+)
+
+func toObj(obj model.Obj, path string) model.Obj {
+	objRes := model.Object{
+		Path:     path,
+		Name:     obj.GetName(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		Ctime:    obj.CreateTime(),
+		IsFolder: obj.IsDir(),
+		HashInfo: obj.GetHash(),
+	}
+	thumb, ok := model.GetThumb(obj)
+	if !ok {
+		return &objRes
+	}
+	return &model.ObjThumb{
+		Object: objRes,
+		Thumbnail: model.Thumbnail{
+			Thumbnail: thumb,
+		},
+	}
+}
+
+func (d *Union) link(ctx context.Context, reqPath string, args model.LinkArgs) (*model.Link, error) {
+	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
+	if err != nil {
+		return nil, err
+	}
+	_, err = fs.Get(ctx, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return nil, err
+	}
+	if common.ShouldProxy(storage, stdpath.Base(reqPath)) {
+		return &model.Link{
+			URL: fmt.Sprintf("%s/p%s?sign=%s",
+				common.GetApiUrl(args.HttpReq),
+				utils.EncodePath(reqPath, true),
+				sign.Sign(reqPath)),
+		}, nil
+	}
+	link, _, err := fs.Link(ctx, reqPath, args)
+	return link, err
+}
+
+// createBranch chooses the branch to create a new object in dirPath by the create policy,
+// only the branches in which dirPath exists are considered, unless there is none
+func (d *Union) createBranch(ctx context.Context, dirPath string) (string, error) {
+	var candidates []string
+	for _, branch := range d.branches {
+		if _, err := fs.Get(ctx, stdpath.Join(branch, dirPath), &fs.GetArgs{NoLog: true}); err == nil {
+			candidates = append(candidates, branch)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = d.branches
+	}
+	switch d.CreatePolicy {
+	case PolicyMostFreeSpace:
+		var (
+			res  = candidates[0]
+			most uint64
+		)
+		for _, branch := range candidates {
+			if free := branchFreeSpace(ctx, branch); free > most {
+				res, most = branch, free
+			}
+		}
+		return res, nil
+	case PolicyRoundRobin:
+		i := atomic.AddUint32(&d.rrIndex, 1) - 1
+		return candidates[int(i)%len(candidates)], nil
+	default:
+		return candidates[0], nil
+	}
+}
+
+// branchFreeSpace returns the free space of the storage of branch, 0 if unknown
+func branchFreeSpace(ctx context.Context, branch string) uint64 {
+	storage, err := fs.GetStorage(branch, &fs.GetStoragesArgs{})
+	if err != nil {
+		return 0
+	}
+	fsp, ok := storage.(driver.FreeSpace)
+	if !ok {
+		return 0
+	}
+	free, err := fsp.FreeSpace(ctx)
+	if err != nil {
+		return 0
+	}
+	return free
+}
+
+// action applies f to the branches in which path exists by the action policy
+func (d *Union) action(ctx context.Context, path string, f func(branch string) error) error {
+	found := false
+	for _, branch := range d.branches {
+		if _, err := fs.Get(ctx, stdpath.Join(branch, path), &fs.GetArgs{NoLog: true}); err != nil {
+			continue
+		}
+		found = true
+		if err := f(branch); err != nil {
+			return errors.WithMessagef(err, "failed in branch [%s]", branch)
+		}
+		if d.ActionPolicy == PolicyFirstFound {
+			break
+		}
+	}
+	if !found {
+		return errs.ObjectNotFound
+	}
+	return nil
+}
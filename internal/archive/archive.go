@@ -0,0 +1,219 @@
+package archive
+
+import (
+	"context"
+	"io"
+	stdpath "path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	TypeZip   = "zip"
+	TypeTar   = "tar"
+	TypeTarGz = "tar.gz"
+	Type7z    = "7z"
+)
+
+// GetType returns the type of the archive by its name, empty if it's not supported
+func GetType(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return TypeZip
+	case strings.HasSuffix(name, ".tar"):
+		return TypeTar
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return TypeTarGz
+	case strings.HasSuffix(name, ".7z"):
+		return Type7z
+	default:
+		return ""
+	}
+}
+
+func IsArchive(name string) bool {
+	return GetType(name) != ""
+}
+
+type Entry struct {
+	Path     string // the path in the archive, without leading "/"
+	Size     int64
+	Modified time.Time
+	IsDir    bool
+}
+
+// Archive is the index of the entries of an archive file
+type Archive struct {
+	entries  map[string]*Entry
+	children map[string][]*Entry
+}
+
+// Source is the content of an archive file which can be read by range
+type Source struct {
+	Name     string
+	Size     int64
+	Password string // for encrypted zip and 7z
+	model.RangeReadCloserIF
+}
+
+// Open reads the index of the archive, only the needed bytes are fetched
+// for zip, tar and 7z, but a compressed tar has to be read through
+func Open(ctx context.Context, src *Source) (*Archive, error) {
+	var (
+		entries []*Entry
+		err     error
+	)
+	switch GetType(src.Name) {
+	case TypeZip:
+		entries, err = zipEntries(ctx, src)
+	case TypeTar:
+		entries, err = tarEntries(ctx, src, false)
+	case TypeTarGz:
+		entries, err = tarEntries(ctx, src, true)
+	case Type7z:
+		entries, err = sevenZipEntries(ctx, src)
+	default:
+		return nil, errs.NotSupport
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed read archive [%s]", src.Name)
+	}
+	return newArchive(entries), nil
+}
+
+func newArchive(entries []*Entry) *Archive {
+	a := &Archive{
+		entries:  map[string]*Entry{"": {IsDir: true}},
+		children: map[string][]*Entry{},
+	}
+	var add func(e *Entry)
+	add = func(e *Entry) {
+		if old, ok := a.entries[e.Path]; ok {
+			// a dir may be added before its own entry
+			if old.IsDir && e.IsDir && !e.Modified.IsZero() {
+				old.Modified = e.Modified
+			}
+			return
+		}
+		a.entries[e.Path] = e
+		dir := stdpath.Dir(e.Path)
+		if dir == "." {
+			dir = ""
+		}
+		// some archives don't have entries of dirs
+		add(&Entry{Path: dir, IsDir: true})
+		a.children[dir] = append(a.children[dir], e)
+	}
+	for _, e := range entries {
+		e.Path = cleanPath(e.Path)
+		if e.Path == "" {
+			continue
+		}
+		add(e)
+	}
+	for _, children := range a.children {
+		sort.Slice(children, func(i, j int) bool {
+			return children[i].Path < children[j].Path
+		})
+	}
+	return a
+}
+
+func cleanPath(path string) string {
+	path = strings.ReplaceAll(path, "\\", "/")
+	return strings.TrimPrefix(stdpath.Clean("/"+path), "/")
+}
+
+func (a *Archive) Get(path string) (*Entry, error) {
+	e, ok := a.entries[cleanPath(path)]
+	if !ok {
+		return nil, errs.ObjectNotFound
+	}
+	return e, nil
+}
+
+func (a *Archive) List(dir string) ([]*Entry, error) {
+	e, err := a.Get(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsDir {
+		return nil, errs.NotFolder
+	}
+	return a.children[e.Path], nil
+}
+
+// OpenEntry reads the range of the file in the archive
+func OpenEntry(ctx context.Context, src *Source, path string, httpRange http_range.Range) (io.ReadCloser, error) {
+	path = cleanPath(path)
+	switch GetType(src.Name) {
+	case TypeZip:
+		return openZipEntry(ctx, src, path, httpRange)
+	case TypeTar:
+		return openTarEntry(ctx, src, path, httpRange, false)
+	case TypeTarGz:
+		return openTarEntry(ctx, src, path, httpRange, true)
+	case Type7z:
+		return openSevenZipEntry(ctx, src, path, httpRange)
+	default:
+		return nil, errs.NotSupport
+	}
+}
+
+// WalkFunc is called for each entry when walking an archive, r is the content
+// of the entry which is nil for dirs, and it's only valid in the call
+type WalkFunc func(e *Entry, r io.Reader) error
+
+// Walk reads all the entries of the archive in the order they are stored
+func Walk(ctx context.Context, src *Source, fn WalkFunc) error {
+	walk := func(e *Entry, r io.Reader) error {
+		if utils.IsCanceled(ctx) {
+			return ctx.Err()
+		}
+		e.Path = cleanPath(e.Path)
+		if e.Path == "" {
+			return nil
+		}
+		return fn(e, r)
+	}
+	switch GetType(src.Name) {
+	case TypeZip:
+		return walkZip(ctx, src, walk)
+	case TypeTar:
+		return walkTar(ctx, src, false, walk)
+	case TypeTarGz:
+		return walkTar(ctx, src, true, walk)
+	case Type7z:
+		return walkSevenZip(ctx, src, walk)
+	default:
+		return errs.NotSupport
+	}
+}
+
+// limitRange skips the bytes before the range of rc, which can't seek
+func limitRange(rc io.ReadCloser, size int64, httpRange http_range.Range) (io.ReadCloser, error) {
+	if httpRange.Start > 0 {
+		if _, err := io.CopyN(io.Discard, rc, httpRange.Start); err != nil {
+			_ = rc.Close()
+			return nil, err
+		}
+	}
+	length := httpRange.Length
+	if length < 0 || httpRange.Start+length > size {
+		length = size - httpRange.Start
+	}
+	return readCloser{Reader: io.LimitReader(rc, length), Closer: rc}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
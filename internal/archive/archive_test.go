@@ -0,0 +1,250 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+)
+
+var files = map[string]string{
+	"a.txt":         "hello archive",
+	"dir/b.txt":     "content of b",
+	"dir/sub/c.txt": "ccc",
+}
+
+func newSource(name string, data []byte) *Source {
+	return &Source{
+		Name: name,
+		Size: int64(len(data)),
+		RangeReadCloserIF: &model.RangeReadCloser{
+			RangeReader: func(ctx context.Context, r http_range.Range) (io.ReadCloser, error) {
+				return io.NopCloser(io.NewSectionReader(bytes.NewReader(data), r.Start, r.Length)), nil
+			},
+		},
+	}
+}
+
+func makeZip(t *testing.T) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	method := zip.Store
+	for name, content := range files {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = f.Write([]byte(content))
+		method = zip.Deflate
+	}
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+func makeTar(t *testing.T, compressed bool) []byte {
+	var buf bytes.Buffer
+	var out io.Writer = &buf
+	var gw *gzip.Writer
+	if compressed {
+		gw = gzip.NewWriter(&buf)
+		out = gw
+	}
+	w := tar.NewWriter(out)
+	for name, content := range files {
+		if err := w.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(content))
+	}
+	_ = w.Close()
+	if gw != nil {
+		_ = gw.Close()
+	}
+	return buf.Bytes()
+}
+
+func TestArchive(t *testing.T) {
+	ctx := context.Background()
+	sources := []*Source{
+		newSource("test.zip", makeZip(t)),
+		newSource("test.tar", makeTar(t, false)),
+		newSource("test.tar.gz", makeTar(t, true)),
+	}
+	for _, src := range sources {
+		a, err := Open(ctx, src)
+		if err != nil {
+			t.Fatalf("failed open %s: %+v", src.Name, err)
+		}
+		root, err := a.List("/")
+		if err != nil || len(root) != 2 {
+			t.Fatalf("%s: expect 2 entries in root, got %d: %v", src.Name, len(root), err)
+		}
+		if e, err := a.Get("dir/sub"); err != nil || !e.IsDir {
+			t.Errorf("%s: expect implied dir dir/sub: %v", src.Name, err)
+		}
+		for name, content := range files {
+			e, err := a.Get("/" + name)
+			if err != nil || e.Size != int64(len(content)) {
+				t.Errorf("%s: wrong entry %s: %v", src.Name, name, err)
+				continue
+			}
+			rc, err := OpenEntry(ctx, src, name, http_range.Range{Start: 1, Length: -1})
+			if err != nil {
+				t.Errorf("%s: failed open %s: %+v", src.Name, name, err)
+				continue
+			}
+			data, _ := io.ReadAll(rc)
+			_ = rc.Close()
+			if string(data) != content[1:] {
+				t.Errorf("%s: content of %s is %q, want %q", src.Name, name, data, content[1:])
+			}
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	ctx := context.Background()
+	for _, src := range []*Source{
+		newSource("test.zip", makeZip(t)),
+		newSource("test.tar.gz", makeTar(t, true)),
+	} {
+		got := map[string]string{}
+		err := Walk(ctx, src, func(e *Entry, r io.Reader) error {
+			data, err := io.ReadAll(r)
+			got[e.Path] = string(data)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("failed walk %s: %+v", src.Name, err)
+		}
+		for name, content := range files {
+			if got[name] != content {
+				t.Errorf("%s: content of %s is %q, want %q", src.Name, name, got[name], content)
+			}
+		}
+	}
+}
+
+// makeZipCrypto makes a zip with an entry stored and encrypted by the traditional encryption
+func makeZipCrypto(t *testing.T, name, content, password string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	crc := crc32.ChecksumIEEE([]byte(content))
+	f, err := w.CreateRaw(&zip.FileHeader{
+		Name:               name,
+		Method:             zip.Store,
+		Flags:              zipFlagEncrypted,
+		CRC32:              crc,
+		CompressedSize64:   uint64(12 + len(content)),
+		UncompressedSize64: uint64(len(content)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := newZipCrypto([]byte(password))
+	plain := append(make([]byte, 11), byte(crc>>24))
+	plain = append(plain, content...)
+	for i, c := range plain {
+		k := z.keys[2] | 2
+		plain[i] = c ^ byte((k*(k^1))>>8)
+		z.update(c)
+	}
+	_, _ = f.Write(plain)
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+func TestZipCrypto(t *testing.T) {
+	ctx := context.Background()
+	src := newSource("secret.zip", makeZipCrypto(t, "a.txt", "secret content", "pass"))
+	if _, err := OpenEntry(ctx, src, "a.txt", http_range.Range{Length: -1}); !errors.Is(err, ErrPassword) {
+		t.Errorf("expect password error without password, got %v", err)
+	}
+	src.Password = "wrong"
+	if _, err := OpenEntry(ctx, src, "a.txt", http_range.Range{Length: -1}); !errors.Is(err, ErrPassword) {
+		t.Errorf("expect password error with wrong password, got %v", err)
+	}
+	src.Password = "pass"
+	rc, err := OpenEntry(ctx, src, "a.txt", http_range.Range{Start: 7, Length: -1})
+	if err != nil {
+		t.Fatalf("failed open encrypted entry: %+v", err)
+	}
+	data, err := io.ReadAll(rc)
+	_ = rc.Close()
+	if err != nil || string(data) != "content" {
+		t.Errorf("content is %q, want %q: %v", data, "content", err)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	ctx := context.Background()
+	for _, format := range []string{TypeZip, TypeTar} {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = w.AddDir("dir", time.Now())
+		for name, content := range files {
+			if err = w.AddFile(name, int64(len(content)), time.Now(), strings.NewReader(content)); err != nil {
+				t.Fatalf("%s: failed add %s: %+v", format, name, err)
+			}
+		}
+		_ = w.Close()
+		src := newSource("test."+format, buf.Bytes())
+		a, err := Open(ctx, src)
+		if err != nil {
+			t.Fatalf("%s: failed open: %+v", format, err)
+		}
+		for name, content := range files {
+			if e, err := a.Get(name); err != nil || e.Size != int64(len(content)) {
+				t.Errorf("%s: wrong entry %s: %v", format, name, err)
+			}
+		}
+	}
+}
+
+// sevenZip is a 7z with the lzma compressed files "foo" and "bar"
+const sevenZip = "N3q8ryccAASgR6WICAAAAAAAAABmAAAAAAAAAN2R8/FiYXIKZm9vCgEEBgACCQQEAAcLAgABAQABAQAMBAQACAoB6bOiBKhlMn4AAAUCGQUAAAAAABERAGIAYQByAAAAZgBvAG8AAAAZAgAAFBIBAACFM3PyY9YBAFgCcvJj1gEVCgEAIICkgSCApIEAAA=="
+
+func TestSevenZip(t *testing.T) {
+	ctx := context.Background()
+	data, _ := base64.StdEncoding.DecodeString(sevenZip)
+	src := newSource("test.7z", data)
+	a, err := Open(ctx, src)
+	if err != nil {
+		t.Fatalf("failed open: %+v", err)
+	}
+	if root, err := a.List("/"); err != nil || len(root) != 2 {
+		t.Fatalf("expect 2 entries in root, got %d: %v", len(root), err)
+	}
+	rc, err := OpenEntry(ctx, src, "foo", http_range.Range{Start: 1, Length: 2})
+	if err != nil {
+		t.Fatalf("failed open foo: %+v", err)
+	}
+	content, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(content) != "oo" {
+		t.Errorf("content of foo is %q, want %q", content, "oo")
+	}
+	got := map[string]string{}
+	err = Walk(ctx, src, func(e *Entry, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		got[e.Path] = string(data)
+		return err
+	})
+	if err != nil || got["foo"] != "foo\n" || got["bar"] != "bar\n" {
+		t.Errorf("walk got %v: %v", got, err)
+	}
+}
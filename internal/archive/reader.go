@@ -0,0 +1,74 @@
+package archive
+
+import (
+	"context"
+	"io"
+
+	"github.com/alist-org/alist/v3/pkg/http_range"
+)
+
+const (
+	blockSize = 64 * 1024
+	maxBlocks = 32
+)
+
+// readerAt turns the range reads of a source into an io.ReaderAt, the read
+// blocks are kept for a while since the index is usually read by small pieces
+type readerAt struct {
+	ctx    context.Context
+	src    *Source
+	blocks map[int64][]byte
+	order  []int64
+}
+
+func newReaderAt(ctx context.Context, src *Source) *readerAt {
+	return &readerAt{ctx: ctx, src: src, blocks: map[int64][]byte{}}
+}
+
+func (r *readerAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.src.Size {
+		return 0, io.EOF
+	}
+	n := 0
+	for n < len(p) && off < r.src.Size {
+		index := off / blockSize
+		block, err := r.block(index)
+		if err != nil {
+			return n, err
+		}
+		c := copy(p[n:], block[off-index*blockSize:])
+		n += c
+		off += int64(c)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *readerAt) block(index int64) ([]byte, error) {
+	if b, ok := r.blocks[index]; ok {
+		return b, nil
+	}
+	start := index * blockSize
+	length := int64(blockSize)
+	if start+length > r.src.Size {
+		length = r.src.Size - start
+	}
+	rc, err := r.src.RangeRead(r.ctx, http_range.Range{Start: start, Length: length})
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	b := make([]byte, length)
+	if _, err = io.ReadFull(rc, b); err != nil {
+		return nil, err
+	}
+	if len(r.order) >= maxBlocks {
+		delete(r.blocks, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.blocks[index] = b
+	r.order = append(r.order, index)
+	return b, nil
+}
@@ -0,0 +1,80 @@
+package archive
+
+import (
+	"context"
+	"io"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/bodgit/sevenzip"
+	"github.com/pkg/errors"
+)
+
+// sevenZipEntries reads the header of a 7z, which is at the end of the file,
+// so only the start and the end of the archive are fetched
+func sevenZipEntries(ctx context.Context, src *Source) ([]*Entry, error) {
+	r, err := sevenzip.NewReaderWithPassword(newReaderAt(ctx, src), src.Size, src.Password)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*Entry, 0, len(r.File))
+	for _, f := range r.File {
+		entries = append(entries, sevenZipEntry(f))
+	}
+	return entries, nil
+}
+
+func sevenZipEntry(f *sevenzip.File) *Entry {
+	return &Entry{
+		Path:     f.Name,
+		Size:     int64(f.UncompressedSize),
+		Modified: f.Modified,
+		IsDir:    f.FileInfo().IsDir(),
+	}
+}
+
+// openSevenZipEntry decompresses the entry, files in a solid block have to be
+// decompressed from the start of the block
+func openSevenZipEntry(ctx context.Context, src *Source, path string, httpRange http_range.Range) (io.ReadCloser, error) {
+	r, err := sevenzip.NewReaderWithPassword(newReaderAt(ctx, src), src.Size, src.Password)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || cleanPath(f.Name) != path {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		return limitRange(rc, int64(f.UncompressedSize), httpRange)
+	}
+	return nil, errs.ObjectNotFound
+}
+
+func walkSevenZip(ctx context.Context, src *Source, fn WalkFunc) error {
+	r, err := sevenzip.NewReaderWithPassword(newReaderAt(ctx, src), src.Size, src.Password)
+	if err != nil {
+		return err
+	}
+	for _, f := range r.File {
+		e := sevenZipEntry(f)
+		if e.IsDir {
+			if err = fn(e, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return errors.WithMessagef(err, "failed open [%s]", e.Path)
+		}
+		err = fn(e, rc)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
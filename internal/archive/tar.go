@@ -0,0 +1,145 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+)
+
+// tarEntries reads the headers of a tar, the content of entries is skipped by
+// seeking if the tar is not compressed, so that only the headers are fetched
+func tarEntries(ctx context.Context, src *Source, compressed bool) ([]*Entry, error) {
+	tr, closer, err := newTarReader(ctx, src, compressed)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	var entries []*Entry
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch h.Typeflag {
+		case tar.TypeDir, tar.TypeReg, tar.TypeRegA:
+			entries = append(entries, &Entry{
+				Path:     h.Name,
+				Size:     h.Size,
+				Modified: h.ModTime,
+				IsDir:    h.Typeflag == tar.TypeDir,
+			})
+		}
+	}
+}
+
+func openTarEntry(ctx context.Context, src *Source, path string, httpRange http_range.Range, compressed bool) (io.ReadCloser, error) {
+	tr, closer, err := newTarReader(ctx, src, compressed)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		h, err := tr.Next()
+		if err != nil {
+			_ = closer.Close()
+			if errors.Is(err, io.EOF) {
+				return nil, errs.ObjectNotFound
+			}
+			return nil, err
+		}
+		if h.Typeflag == tar.TypeDir || cleanPath(h.Name) != path {
+			continue
+		}
+		if sr, ok := closer.(*sectionCloser); ok {
+			// the tar is read by the reader at, read the range directly instead
+			offset, err := sr.Seek(0, io.SeekCurrent)
+			_ = closer.Close()
+			if err != nil {
+				return nil, err
+			}
+			length := httpRange.Length
+			if length < 0 || httpRange.Start+length > h.Size {
+				length = h.Size - httpRange.Start
+			}
+			return src.RangeRead(ctx, http_range.Range{Start: offset + httpRange.Start, Length: length})
+		}
+		return limitRange(readCloser{Reader: tr, Closer: closer}, h.Size, httpRange)
+	}
+}
+
+// walkTar reads the whole tar in one request since all the content is needed
+func walkTar(ctx context.Context, src *Source, compressed bool, fn WalkFunc) error {
+	rc, err := src.RangeRead(ctx, http_range.Range{Start: 0, Length: src.Size})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	r := io.Reader(rc)
+	if compressed {
+		gr, err := gzip.NewReader(rc)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	}
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		e := &Entry{
+			Path:     h.Name,
+			Size:     h.Size,
+			Modified: h.ModTime,
+			IsDir:    h.Typeflag == tar.TypeDir,
+		}
+		switch h.Typeflag {
+		case tar.TypeDir:
+			err = fn(e, nil)
+		case tar.TypeReg, tar.TypeRegA:
+			err = fn(e, tr)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+type sectionCloser struct {
+	*io.SectionReader
+}
+
+func (s *sectionCloser) Close() error {
+	return nil
+}
+
+func newTarReader(ctx context.Context, src *Source, compressed bool) (*tar.Reader, io.Closer, error) {
+	if !compressed {
+		sr := &sectionCloser{io.NewSectionReader(newReaderAt(ctx, src), 0, src.Size)}
+		return tar.NewReader(sr), sr, nil
+	}
+	rc, err := src.RangeRead(ctx, http_range.Range{Start: 0, Length: src.Size})
+	if err != nil {
+		return nil, nil, err
+	}
+	gr, err := gzip.NewReader(rc)
+	if err != nil {
+		_ = rc.Close()
+		return nil, nil, err
+	}
+	return tar.NewReader(gr), multiCloser{gr, rc}, nil
+}
@@ -0,0 +1,98 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+)
+
+// Writer streams files into a zip or tar, nothing is buffered so that
+// the total size doesn't need to be known
+type Writer struct {
+	zw *zip.Writer
+	tw *tar.Writer
+}
+
+// NewWriter creates a writer of zip or tar, the zip is in store mode since
+// most of the files downloaded are already compressed, and zip64 is used
+// automatically for the big files
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	switch format {
+	case TypeZip:
+		return &Writer{zw: zip.NewWriter(w)}, nil
+	case TypeTar:
+		return &Writer{tw: tar.NewWriter(w)}, nil
+	default:
+		return nil, errs.NotSupport
+	}
+}
+
+func (w *Writer) AddDir(path string, modified time.Time) error {
+	path = cleanPath(path) + "/"
+	if w.zw != nil {
+		fh := &zip.FileHeader{Name: path, Method: zip.Store, Modified: modified}
+		fh.SetMode(0755 | fs.ModeDir)
+		_, err := w.zw.CreateHeader(fh)
+		return err
+	}
+	return w.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     path,
+		Mode:     0755,
+		ModTime:  modified,
+	})
+}
+
+// AddFile writes size bytes of r as the file
+func (w *Writer) AddFile(path string, size int64, modified time.Time, r io.Reader) error {
+	path = cleanPath(path)
+	var dst io.Writer
+	if w.zw != nil {
+		fh := &zip.FileHeader{
+			Name:               path,
+			Method:             zip.Store,
+			Modified:           modified,
+			UncompressedSize64: uint64(size),
+		}
+		fh.SetMode(0644)
+		zf, err := w.zw.CreateHeader(fh)
+		if err != nil {
+			return err
+		}
+		dst = zf
+	} else {
+		err := w.tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     path,
+			Size:     size,
+			Mode:     0644,
+			ModTime:  modified,
+		})
+		if err != nil {
+			return err
+		}
+		dst = w.tw
+	}
+	_, err := io.CopyN(dst, r, size)
+	return err
+}
+
+func (w *Writer) Close() error {
+	if w.zw != nil {
+		return w.zw.Close()
+	}
+	return w.tw.Close()
+}
+
+// ContentType returns the mime type of the format
+func ContentType(format string) string {
+	if strings.EqualFold(format, TypeTar) {
+		return "application/x-tar"
+	}
+	return "application/zip"
+}
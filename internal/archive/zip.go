@@ -0,0 +1,160 @@
+package archive
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"context"
+	"hash/crc32"
+	"io"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+func zipEntries(ctx context.Context, src *Source) ([]*Entry, error) {
+	r, err := zip.NewReader(newReaderAt(ctx, src), src.Size)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*Entry, 0, len(r.File))
+	for _, f := range r.File {
+		entries = append(entries, &Entry{
+			Path:     zipName(f),
+			Size:     int64(f.UncompressedSize64),
+			Modified: f.Modified,
+			IsDir:    f.FileInfo().IsDir(),
+		})
+	}
+	return entries, nil
+}
+
+// zipName decodes the name which isn't in utf-8, it's usually in GBK
+// if the archive is created on windows in chinese
+func zipName(f *zip.File) string {
+	if !f.NonUTF8 {
+		return f.Name
+	}
+	name, err := simplifiedchinese.GBK.NewDecoder().String(f.Name)
+	if err != nil {
+		return f.Name
+	}
+	return name
+}
+
+func openZipEntry(ctx context.Context, src *Source, path string, httpRange http_range.Range) (io.ReadCloser, error) {
+	r, err := zip.NewReader(newReaderAt(ctx, src), src.Size)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || cleanPath(zipName(f)) != path {
+			continue
+		}
+		size := int64(f.UncompressedSize64)
+		if f.Method == zip.Store && !isEncrypted(f) {
+			// read the range directly
+			offset, err := f.DataOffset()
+			if err != nil {
+				return nil, err
+			}
+			length := httpRange.Length
+			if length < 0 || httpRange.Start+length > size {
+				length = size - httpRange.Start
+			}
+			return src.RangeRead(ctx, http_range.Range{Start: offset + httpRange.Start, Length: length})
+		}
+		rc, err := zipFileReader(ctx, src, f)
+		if err != nil {
+			return nil, err
+		}
+		return limitRange(rc, size, httpRange)
+	}
+	return nil, errs.ObjectNotFound
+}
+
+// zipFileReader reads the whole content of the entry, the compressed data
+// of a deflated entry is fetched in one request instead of blocks
+func zipFileReader(ctx context.Context, src *Source, f *zip.File) (io.ReadCloser, error) {
+	if f.Method != zip.Deflate || isEncrypted(f) {
+		return openZipFile(f, src.Password)
+	}
+	offset, err := f.DataOffset()
+	if err != nil {
+		return nil, err
+	}
+	rc, err := src.RangeRead(ctx, http_range.Range{Start: offset, Length: int64(f.CompressedSize64)})
+	if err != nil {
+		return nil, err
+	}
+	fr := flate.NewReader(rc)
+	return readCloser{Reader: fr, Closer: multiCloser{fr, rc}}, nil
+}
+
+func walkZip(ctx context.Context, src *Source, fn WalkFunc) error {
+	r, err := zip.NewReader(newReaderAt(ctx, src), src.Size)
+	if err != nil {
+		return err
+	}
+	for _, f := range r.File {
+		e := &Entry{
+			Path:     zipName(f),
+			Size:     int64(f.UncompressedSize64),
+			Modified: f.Modified,
+			IsDir:    f.FileInfo().IsDir(),
+		}
+		if e.IsDir {
+			if err = fn(e, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		rc, err := zipFileReader(ctx, src, f)
+		if err != nil {
+			return errors.WithMessagef(err, "failed open [%s]", e.Path)
+		}
+		err = fn(e, rc)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openZipFile opens the entry, decrypts it with the password if it's encrypted
+func openZipFile(f *zip.File, password string) (io.ReadCloser, error) {
+	if !isEncrypted(f) {
+		return f.Open()
+	}
+	r, method, err := decryptZipFile(f, password)
+	if err != nil {
+		return nil, err
+	}
+	var rc io.ReadCloser
+	switch method {
+	case zip.Store:
+		rc = io.NopCloser(r)
+	case zip.Deflate:
+		rc = flate.NewReader(r)
+	default:
+		return nil, zip.ErrAlgorithm
+	}
+	if f.CRC32 != 0 {
+		rc = &crcReader{ReadCloser: rc, hash: crc32.NewIEEE(), crc: f.CRC32}
+	}
+	return rc, nil
+}
+
+type multiCloser []io.Closer
+
+func (m multiCloser) Close() error {
+	var err error
+	for _, c := range m {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
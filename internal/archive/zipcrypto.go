@@ -0,0 +1,212 @@
+package archive
+
+import (
+	"archive/zip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// ErrPassword is returned if an encrypted entry is opened without the right password
+var ErrPassword = errors.New("password is incorrect or required")
+
+const (
+	zipFlagEncrypted      = 0x1
+	zipFlagDataDescriptor = 0x8
+	zipMethodAES          = 99
+	zipExtraAES           = 0x9901
+)
+
+func isEncrypted(f *zip.File) bool {
+	return f.Flags&zipFlagEncrypted != 0
+}
+
+// decryptZipFile returns the decrypted but still compressed data of the entry,
+// and the actual compression method
+func decryptZipFile(f *zip.File, password string) (io.Reader, uint16, error) {
+	if password == "" {
+		return nil, 0, ErrPassword
+	}
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, 0, err
+	}
+	if f.Method == zipMethodAES {
+		return newAESReader(f, raw, []byte(password))
+	}
+	r, err := newZipCryptoReader(f, raw, []byte(password))
+	return r, f.Method, err
+}
+
+// zipCrypto is the traditional PKWARE encryption
+type zipCrypto struct {
+	keys [3]uint32
+}
+
+func newZipCrypto(password []byte) *zipCrypto {
+	z := &zipCrypto{keys: [3]uint32{305419896, 591751049, 878082192}}
+	for _, b := range password {
+		z.update(b)
+	}
+	return z
+}
+
+func crc32Update(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ (crc >> 8)
+}
+
+func (z *zipCrypto) update(b byte) {
+	z.keys[0] = crc32Update(z.keys[0], b)
+	z.keys[1] = (z.keys[1]+(z.keys[0]&0xff))*134775813 + 1
+	z.keys[2] = crc32Update(z.keys[2], byte(z.keys[1]>>24))
+}
+
+func (z *zipCrypto) decrypt(p []byte) {
+	for i, c := range p {
+		t := z.keys[2] | 2
+		p[i] = c ^ byte((t*(t^1))>>8)
+		z.update(p[i])
+	}
+}
+
+type zipCryptoReader struct {
+	r io.Reader
+	z *zipCrypto
+}
+
+func newZipCryptoReader(f *zip.File, r io.Reader, password []byte) (io.Reader, error) {
+	z := newZipCrypto(password)
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	z.decrypt(header)
+	check := byte(f.CRC32 >> 24)
+	if f.Flags&zipFlagDataDescriptor != 0 {
+		check = byte(f.ModifiedTime >> 8)
+	}
+	if header[11] != check {
+		return nil, ErrPassword
+	}
+	return &zipCryptoReader{r: r, z: z}, nil
+}
+
+func (r *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.z.decrypt(p[:n])
+	return n, err
+}
+
+// aesReader decrypts the WinZip AES encryption, the data is verified by
+// the authentication code at the end
+type aesReader struct {
+	r       io.Reader
+	block   cipher.Block
+	counter uint64
+	stream  [aes.BlockSize]byte
+	used    int
+	mac     hash.Hash
+	code    io.Reader
+	checked bool
+}
+
+func newAESReader(f *zip.File, raw io.Reader, password []byte) (io.Reader, uint16, error) {
+	strength, method, ok := parseAESExtra(f.Extra)
+	if !ok {
+		return nil, 0, errors.New("invalid aes extra field")
+	}
+	keyLen := 8 * (int(strength) + 1)
+	saltLen := keyLen / 2
+	salt := make([]byte, saltLen+2)
+	if _, err := io.ReadFull(raw, salt); err != nil {
+		return nil, 0, err
+	}
+	keys := pbkdf2.Key(password, salt[:saltLen], 1000, 2*keyLen+2, sha1.New)
+	if keys[2*keyLen] != salt[saltLen] || keys[2*keyLen+1] != salt[saltLen+1] {
+		return nil, 0, ErrPassword
+	}
+	block, err := aes.NewCipher(keys[:keyLen])
+	if err != nil {
+		return nil, 0, err
+	}
+	dataLen := int64(f.CompressedSize64) - int64(saltLen) - 2 - 10
+	if dataLen < 0 {
+		return nil, 0, errors.New("invalid aes encrypted data")
+	}
+	return &aesReader{
+		r:     io.LimitReader(raw, dataLen),
+		block: block,
+		used:  aes.BlockSize,
+		mac:   hmac.New(sha1.New, keys[keyLen:2*keyLen]),
+		code:  raw,
+	}, method, nil
+}
+
+func parseAESExtra(extra []byte) (strength byte, method uint16, ok bool) {
+	for len(extra) >= 4 {
+		tag := binary.LittleEndian.Uint16(extra[:2])
+		size := int(binary.LittleEndian.Uint16(extra[2:4]))
+		extra = extra[4:]
+		if size > len(extra) {
+			return 0, 0, false
+		}
+		if tag == zipExtraAES && size >= 7 {
+			strength, method = extra[4], binary.LittleEndian.Uint16(extra[5:7])
+			return strength, method, strength >= 1 && strength <= 3
+		}
+		extra = extra[size:]
+	}
+	return 0, 0, false
+}
+
+func (r *aesReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.mac.Write(p[:n])
+	for i := 0; i < n; i++ {
+		if r.used == aes.BlockSize {
+			// the counter is little endian and starts from 1
+			r.counter++
+			var ctr [aes.BlockSize]byte
+			binary.LittleEndian.PutUint64(ctr[:8], r.counter)
+			r.block.Encrypt(r.stream[:], ctr[:])
+			r.used = 0
+		}
+		p[i] ^= r.stream[r.used]
+		r.used++
+	}
+	if errors.Is(err, io.EOF) && !r.checked {
+		r.checked = true
+		code := make([]byte, 10)
+		if _, e := io.ReadFull(r.code, code); e != nil {
+			return n, e
+		}
+		if !hmac.Equal(code, r.mac.Sum(nil)[:10]) {
+			return n, errors.New("aes authentication code mismatch")
+		}
+	}
+	return n, err
+}
+
+// crcReader verifies the crc32 of the decrypted content
+type crcReader struct {
+	io.ReadCloser
+	hash hash.Hash32
+	crc  uint32
+}
+
+func (r *crcReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	if errors.Is(err, io.EOF) && r.hash.Sum32() != r.crc {
+		return n, zip.ErrChecksum
+	}
+	return n, err
+}
@@ -0,0 +1,24 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+// InitAuditCleaner deletes the expired audit logs every hour
+func InitAuditCleaner() {
+	cron.NewCron(time.Hour).Do(func() {
+		days := setting.GetInt(conf.AuditRetentionDays, 90)
+		if days <= 0 {
+			return
+		}
+		if err := db.DeleteAuditLogsBefore(time.Now().AddDate(0, 0, -days)); err != nil {
+			log.Errorf("failed delete expired audit logs: %+v", err)
+		}
+	})
+}
@@ -0,0 +1,17 @@
+package bootstrap
+
+import (
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/op"
+	log "github.com/sirupsen/logrus"
+)
+
+func InitListCache() {
+	if !conf.Conf.ListCache.Persist {
+		return
+	}
+	if err := op.InitPersistCache(conf.Conf.ListCache.Path); err != nil {
+		// another alist process may hold the file, fall back to memory cache
+		log.Errorf("init persistent list cache error: %+v", err)
+	}
+}
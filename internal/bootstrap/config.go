@@ -9,6 +9,7 @@ import (
 	"github.com/alist-org/alist/v3/cmd/flags"
 	"github.com/alist-org/alist/v3/drivers/base"
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/tus"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/caarlos0/env/v9"
 	log "github.com/sirupsen/logrus"
@@ -68,11 +69,8 @@ func InitConfig() {
 		}
 		conf.Conf.TempDir = absPath
 	}
-	err := os.RemoveAll(filepath.Join(conf.Conf.TempDir))
-	if err != nil {
-		log.Errorln("failed delete temp file:", err)
-	}
-	err = os.MkdirAll(conf.Conf.TempDir, 0o777)
+	cleanTempDir()
+	err := os.MkdirAll(conf.Conf.TempDir, 0o777)
 	if err != nil {
 		log.Fatalf("create temp dir error: %+v", err)
 	}
@@ -81,6 +79,26 @@ func InitConfig() {
 	initURL()
 }
 
+// cleanTempDir removes the temp files of the last run,
+// except the tus uploads which can be resumed or are being put by tasks
+func cleanTempDir() {
+	entries, err := os.ReadDir(conf.Conf.TempDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorln("failed read temp dir:", err)
+		}
+		return
+	}
+	for _, e := range entries {
+		if e.Name() == tus.DirName {
+			continue
+		}
+		if err = os.RemoveAll(filepath.Join(conf.Conf.TempDir, e.Name())); err != nil {
+			log.Errorln("failed delete temp file:", err)
+		}
+	}
+}
+
 func confFromEnv() {
 	prefix := "ALIST_"
 	if flags.NoPrefix {
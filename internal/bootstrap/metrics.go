@@ -0,0 +1,23 @@
+package bootstrap
+
+import (
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/alist-org/alist/v3/internal/offline_download/tool"
+	"github.com/alist-org/alist/v3/internal/search"
+	"github.com/alist-org/alist/v3/internal/sync_job"
+)
+
+// InitMetrics registers the task managers and the index progress, which are
+// read at scrape time, so it must be called after the task managers are created
+func InitMetrics() {
+	metrics.RegisterTaskManager("upload", fs.UploadTaskManager)
+	metrics.RegisterTaskManager("copy", fs.CopyTaskManager)
+	metrics.RegisterTaskManager("move", fs.MoveTaskManager)
+	metrics.RegisterTaskManager("extract", fs.ExtractTaskManager)
+	metrics.RegisterTaskManager("hash", fs.HashTaskManager)
+	metrics.RegisterTaskManager("offline_download", tool.DownloadTaskManager)
+	metrics.RegisterTaskManager("offline_download_transfer", tool.TransferTaskManager)
+	metrics.RegisterTaskManager("sync", sync_job.TaskManager)
+	metrics.RegisterIndexProgress(search.Progress)
+}
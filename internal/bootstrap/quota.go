@@ -0,0 +1,18 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/pkg/cron"
+)
+
+// InitUsageReconciler recomputes the usage of the paths with a quota on
+// start and every 6 hours
+func InitUsageReconciler() {
+	go fs.ReconcileUsage(context.Background())
+	cron.NewCron(6 * time.Hour).Do(func() {
+		fs.ReconcileUsage(context.Background())
+	})
+}
@@ -0,0 +1,12 @@
+package bootstrap
+
+import (
+	"github.com/alist-org/alist/v3/internal/sync_job"
+	log "github.com/sirupsen/logrus"
+)
+
+func InitSyncJobs() {
+	if err := sync_job.Init(); err != nil {
+		log.Errorf("failed schedule sync jobs: %+v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/offline_download/tool"
+	"github.com/alist-org/alist/v3/internal/sync_job"
 	"github.com/alist-org/alist/v3/pkg/tache"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	log "github.com/sirupsen/logrus"
@@ -13,8 +14,12 @@ func InitTaskManager() {
 
 	uploadTaskPersistPath := conf.Conf.Tasks.Upload.PersistPath
 	copyTaskPersistPath := conf.Conf.Tasks.Copy.PersistPath
+	moveTaskPersistPath := conf.Conf.Tasks.Move.PersistPath
 	downloadTaskPersistPath := conf.Conf.Tasks.Download.PersistPath
 	transferTaskPersistPath := conf.Conf.Tasks.Transfer.PersistPath
+	syncTaskPersistPath := conf.Conf.Tasks.Sync.PersistPath
+	extractTaskPersistPath := conf.Conf.Tasks.Extract.PersistPath
+	hashTaskPersistPath := conf.Conf.Tasks.Hash.PersistPath
 	if !utils.Exists(uploadTaskPersistPath) {
 		log.Infof("传输任务持久化文件")
 		_, err := utils.CreateNestedFile(uploadTaskPersistPath)
@@ -32,6 +37,14 @@ func InitTaskManager() {
 
 	}
 
+	if !utils.Exists(moveTaskPersistPath) {
+		log.Infof("移动任务持久化文件")
+		_, err := utils.CreateNestedFile(moveTaskPersistPath)
+		if err != nil {
+			log.Fatalf("创建移动任务文件失败: %+v", err)
+		}
+	}
+
 	if !utils.Exists(downloadTaskPersistPath) {
 		log.Infof("下载任务持久化文件")
 		_, err := utils.CreateNestedFile(downloadTaskPersistPath)
@@ -48,8 +61,36 @@ func InitTaskManager() {
 		}
 	}
 
+	if !utils.Exists(syncTaskPersistPath) {
+		log.Infof("同步任务持久化文件")
+		_, err := utils.CreateNestedFile(syncTaskPersistPath)
+		if err != nil {
+			log.Fatalf("创建同步任务文件失败: %+v", err)
+		}
+	}
+
+	if !utils.Exists(extractTaskPersistPath) {
+		log.Infof("解压任务持久化文件")
+		_, err := utils.CreateNestedFile(extractTaskPersistPath)
+		if err != nil {
+			log.Fatalf("创建解压任务文件失败: %+v", err)
+		}
+	}
+
+	if !utils.Exists(hashTaskPersistPath) {
+		log.Infof("哈希任务持久化文件")
+		_, err := utils.CreateNestedFile(hashTaskPersistPath)
+		if err != nil {
+			log.Fatalf("创建哈希任务文件失败: %+v", err)
+		}
+	}
+
 	fs.UploadTaskManager = tache.NewManager[*fs.UploadTask](tache.WithWorks(conf.Conf.Tasks.Upload.Workers), tache.WithPersistPath(uploadTaskPersistPath), tache.WithMaxRetry(conf.Conf.Tasks.Upload.MaxRetry))
 	fs.CopyTaskManager = tache.NewManager[*fs.CopyTask](tache.WithWorks(conf.Conf.Tasks.Copy.Workers), tache.WithPersistPath(copyTaskPersistPath), tache.WithMaxRetry(conf.Conf.Tasks.Copy.MaxRetry))
+	fs.MoveTaskManager = tache.NewManager[*fs.MoveTask](tache.WithWorks(conf.Conf.Tasks.Move.Workers), tache.WithPersistPath(moveTaskPersistPath), tache.WithMaxRetry(conf.Conf.Tasks.Move.MaxRetry))
 	tool.DownloadTaskManager = tache.NewManager[*tool.DownloadTask](tache.WithWorks(conf.Conf.Tasks.Download.Workers), tache.WithPersistPath(downloadTaskPersistPath), tache.WithMaxRetry(conf.Conf.Tasks.Download.MaxRetry))
 	tool.TransferTaskManager = tache.NewManager[*tool.TransferTask](tache.WithWorks(conf.Conf.Tasks.Transfer.Workers), tache.WithPersistPath(transferTaskPersistPath), tache.WithMaxRetry(conf.Conf.Tasks.Transfer.MaxRetry))
+	fs.ExtractTaskManager = tache.NewManager[*fs.ExtractTask](tache.WithWorks(conf.Conf.Tasks.Extract.Workers), tache.WithPersistPath(extractTaskPersistPath), tache.WithMaxRetry(conf.Conf.Tasks.Extract.MaxRetry))
+	fs.HashTaskManager = tache.NewManager[*fs.HashTask](tache.WithWorks(conf.Conf.Tasks.Hash.Workers), tache.WithPersistPath(hashTaskPersistPath), tache.WithMaxRetry(conf.Conf.Tasks.Hash.MaxRetry))
+	sync_job.TaskManager = tache.NewManager[*sync_job.SyncTask](tache.WithWorks(conf.Conf.Tasks.Sync.Workers), tache.WithPersistPath(syncTaskPersistPath), tache.WithMaxRetry(conf.Conf.Tasks.Sync.MaxRetry))
 }
@@ -0,0 +1,22 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/cron"
+)
+
+// InitTrashCleaner purges the expired objects in the trash every hour
+func InitTrashCleaner() {
+	cron.NewCron(time.Hour).Do(func() {
+		days := setting.GetInt(conf.TrashRetentionDays, 30)
+		if days <= 0 {
+			return
+		}
+		op.CleanTrash(context.Background(), time.Duration(days)*24*time.Hour)
+	})
+}
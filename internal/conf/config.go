@@ -57,6 +57,15 @@ type TasksConfig struct {
 	Transfer TaskConfig `json:"transfer" envPrefix:"TRANSFER_"`
 	Upload   TaskConfig `json:"upload" envPrefix:"UPLOAD_"`
 	Copy     TaskConfig `json:"copy" envPrefix:"COPY_"`
+	Move     TaskConfig `json:"move" envPrefix:"MOVE_"`
+	Sync     TaskConfig `json:"sync" envPrefix:"SYNC_"`
+	Extract  TaskConfig `json:"extract" envPrefix:"EXTRACT_"`
+	Hash     TaskConfig `json:"hash" envPrefix:"HASH_"`
+}
+
+type ListCacheConfig struct {
+	Persist bool   `json:"persist" env:"PERSIST"`
+	Path    string `json:"path" env:"PATH"`
 }
 
 type Cors struct {
@@ -71,26 +80,46 @@ type S3 struct {
 	SSL    bool `json:"ssl" env:"SSL"`
 }
 
+type FTP struct {
+	Enable           bool   `json:"enable" env:"ENABLE"`
+	Listen           string `json:"listen" env:"LISTEN"`
+	PublicHost       string `json:"public_host" env:"PUBLIC_HOST"` // the ip replied to PASV, the local ip of the connection if empty
+	PassivePortStart int    `json:"passive_port_start" env:"PASSIVE_PORT_START"`
+	PassivePortEnd   int    `json:"passive_port_end" env:"PASSIVE_PORT_END"` // a random port is used if the range is empty
+	TLS              bool   `json:"tls" env:"TLS"`                           // allow AUTH TLS with the cert of scheme
+	ForceTLS         bool   `json:"force_tls" env:"FORCE_TLS"`
+	IdleTimeout      int    `json:"idle_timeout" env:"IDLE_TIMEOUT"` // seconds
+}
+
+type SFTP struct {
+	Enable  bool   `json:"enable" env:"ENABLE"`
+	Listen  string `json:"listen" env:"LISTEN"`
+	HostKey string `json:"host_key" env:"HOST_KEY"` // the private key of the host, it's generated if not exists
+}
+
 type Config struct {
-	Force                 bool        `json:"force" env:"FORCE"`
-	Notify                bool        `json:"notify" env:"NOTIFY"`
-	SiteURL               string      `json:"site_url" env:"SITE_URL"`
-	Cdn                   string      `json:"cdn" env:"CDN"`
-	JwtSecret             string      `json:"jwt_secret" env:"JWT_SECRET"`
-	TokenExpiresIn        int         `json:"token_expires_in" env:"TOKEN_EXPIRES_IN"`
-	Database              Database    `json:"database" envPrefix:"DB_"`
-	Meilisearch           Meilisearch `json:"meilisearch" envPrefix:"MEILISEARCH_"`
-	Scheme                Scheme      `json:"scheme"`
-	TempDir               string      `json:"temp_dir" env:"TEMP_DIR"`
-	BleveDir              string      `json:"bleve_dir" env:"BLEVE_DIR"`
-	DistDir               string      `json:"dist_dir"`
-	Log                   LogConfig   `json:"log"`
-	DelayedStart          int         `json:"delayed_start" env:"DELAYED_START"`
-	MaxConnections        int         `json:"max_connections" env:"MAX_CONNECTIONS"`
-	TlsInsecureSkipVerify bool        `json:"tls_insecure_skip_verify" env:"TLS_INSECURE_SKIP_VERIFY"`
-	Tasks                 TasksConfig `json:"tasks" envPrefix:"TASKS_"`
-	Cors                  Cors        `json:"cors" envPrefix:"CORS_"`
-	S3                    S3          `json:"s3" envPrefix:"S3_"`
+	Force                 bool            `json:"force" env:"FORCE"`
+	Notify                bool            `json:"notify" env:"NOTIFY"`
+	SiteURL               string          `json:"site_url" env:"SITE_URL"`
+	Cdn                   string          `json:"cdn" env:"CDN"`
+	JwtSecret             string          `json:"jwt_secret" env:"JWT_SECRET"`
+	TokenExpiresIn        int             `json:"token_expires_in" env:"TOKEN_EXPIRES_IN"`
+	Database              Database        `json:"database" envPrefix:"DB_"`
+	Meilisearch           Meilisearch     `json:"meilisearch" envPrefix:"MEILISEARCH_"`
+	Scheme                Scheme          `json:"scheme"`
+	TempDir               string          `json:"temp_dir" env:"TEMP_DIR"`
+	BleveDir              string          `json:"bleve_dir" env:"BLEVE_DIR"`
+	DistDir               string          `json:"dist_dir"`
+	Log                   LogConfig       `json:"log"`
+	DelayedStart          int             `json:"delayed_start" env:"DELAYED_START"`
+	MaxConnections        int             `json:"max_connections" env:"MAX_CONNECTIONS"`
+	TlsInsecureSkipVerify bool            `json:"tls_insecure_skip_verify" env:"TLS_INSECURE_SKIP_VERIFY"`
+	Tasks                 TasksConfig     `json:"tasks" envPrefix:"TASKS_"`
+	ListCache             ListCacheConfig `json:"list_cache" envPrefix:"LIST_CACHE_"`
+	Cors                  Cors            `json:"cors" envPrefix:"CORS_"`
+	S3                    S3              `json:"s3" envPrefix:"S3_"`
+	FTP                   FTP             `json:"ftp" envPrefix:"FTP_"`
+	SFTP                  SFTP            `json:"sftp" envPrefix:"SFTP_"`
 }
 
 func DefaultConfig() *Config {
@@ -102,6 +131,12 @@ func DefaultConfig() *Config {
 	transferPersistPath := filepath.Join(flags.DataDir, "tasks/transfer.json")
 	uploadPersistPath := filepath.Join(flags.DataDir, "tasks/upload.json")
 	copyPersistPath := filepath.Join(flags.DataDir, "tasks/copy.json")
+	movePersistPath := filepath.Join(flags.DataDir, "tasks/move.json")
+	syncPersistPath := filepath.Join(flags.DataDir, "tasks/sync.json")
+	extractPersistPath := filepath.Join(flags.DataDir, "tasks/extract.json")
+	hashPersistPath := filepath.Join(flags.DataDir, "tasks/hash.json")
+	listCachePath := filepath.Join(flags.DataDir, "list_cache.db")
+	hostKeyPath := filepath.Join(flags.DataDir, "ssh_host_key")
 	return &Config{
 		Scheme: Scheme{
 			Address:    "0.0.0.0",
@@ -155,6 +190,29 @@ func DefaultConfig() *Config {
 				MaxRetry:    2,
 				PersistPath: copyPersistPath,
 			},
+			Move: TaskConfig{
+				Workers:     5,
+				MaxRetry:    2,
+				PersistPath: movePersistPath,
+			},
+			Sync: TaskConfig{
+				Workers:     2,
+				PersistPath: syncPersistPath,
+			},
+			Extract: TaskConfig{
+				Workers:     2,
+				MaxRetry:    2,
+				PersistPath: extractPersistPath,
+			},
+			Hash: TaskConfig{
+				Workers:     2,
+				MaxRetry:    1,
+				PersistPath: hashPersistPath,
+			},
+		},
+		ListCache: ListCacheConfig{
+			Persist: false,
+			Path:    listCachePath,
 		},
 		Cors: Cors{
 			AllowOrigins: []string{"*"},
@@ -166,5 +224,17 @@ func DefaultConfig() *Config {
 			Port:   5246,
 			SSL:    false,
 		},
+		FTP: FTP{
+			Enable:           false,
+			Listen:           ":5221",
+			PassivePortStart: 5230,
+			PassivePortEnd:   5240,
+			IdleTimeout:      900,
+		},
+		SFTP: SFTP{
+			Enable:  false,
+			Listen:  ":5222",
+			HostKey: hostKeyPath,
+		},
 	}
 }
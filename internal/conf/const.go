@@ -43,6 +43,10 @@ const (
 	ForwardDirectLinkParams = "forward_direct_link_params"
 	IgnoreDirectLinkParams  = "ignore_direct_link_params"
 	WebauthnLoginEnabled    = "webauthn_login_enabled"
+	BrowseArchive           = "browse_archive"
+	TrashRetentionDays      = "trash_retention_days"
+	AuditRetentionDays      = "audit_retention_days"
+	VerifyCopy              = "verify_copy"
 
 	// index
 	SearchIndex     = "search_index"
@@ -111,5 +115,6 @@ const (
 
 // ContextKey is the type of context keys.
 const (
-	NoTaskKey = "no_task"
+	NoTaskKey         = "no_task"
+	ConflictPolicyKey = "conflict_policy" // model.ConflictPolicy of the copy or move between storages
 )
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetGroupById(id uint) (*model.Group, error) {
+	var g model.Group
+	if err := db.First(&g, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get group")
+	}
+	return &g, nil
+}
+
+func GetGroups(pageIndex, pageSize int) (groups []model.Group, count int64, err error) {
+	groupDB := db.Model(&model.Group{})
+	if err = groupDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get groups count")
+	}
+	if err = groupDB.Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&groups).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find groups")
+	}
+	return groups, count, nil
+}
+
+func CreateGroup(g *model.Group) error {
+	return errors.WithStack(db.Create(g).Error)
+}
+
+func UpdateGroup(g *model.Group) error {
+	return errors.WithStack(db.Save(g).Error)
+}
+
+// DeleteGroupById deletes the group with its members and acl entries
+func DeleteGroupById(id uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&model.UserGroup{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", id).Delete(&model.ACL{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Group{}, id).Error
+	}))
+}
+
+func GetAllUserGroups() ([]model.UserGroup, error) {
+	var ugs []model.UserGroup
+	if err := db.Find(&ugs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find user groups")
+	}
+	return ugs, nil
+}
+
+func GetGroupMembers(groupId uint) ([]uint, error) {
+	var ids []uint
+	if err := db.Model(&model.UserGroup{}).Where("group_id = ?", groupId).Pluck("user_id", &ids).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get group members")
+	}
+	return ids, nil
+}
+
+// SetGroupMembers replaces the members of the group
+func SetGroupMembers(groupId uint, userIds []uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", groupId).Delete(&model.UserGroup{}).Error; err != nil {
+			return err
+		}
+		if len(userIds) == 0 {
+			return nil
+		}
+		ugs := make([]model.UserGroup, 0, len(userIds))
+		for _, id := range userIds {
+			ugs = append(ugs, model.UserGroup{UserID: id, GroupID: groupId})
+		}
+		return tx.Create(&ugs).Error
+	}))
+}
+
+// DeleteUserAccess deletes the memberships and acl entries of the user
+func DeleteUserAccess(userId uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&model.UserGroup{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userId).Delete(&model.ACL{}).Error
+	}))
+}
+
+func GetACLById(id uint) (*model.ACL, error) {
+	var acl model.ACL
+	if err := db.First(&acl, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get acl")
+	}
+	return &acl, nil
+}
+
+func GetACLs(pageIndex, pageSize int) (acls []model.ACL, count int64, err error) {
+	aclDB := db.Model(&model.ACL{})
+	if err = aclDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get acls count")
+	}
+	if err = aclDB.Order(columnName("path")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&acls).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find acls")
+	}
+	return acls, count, nil
+}
+
+func GetAllACLs() ([]model.ACL, error) {
+	var acls []model.ACL
+	if err := db.Find(&acls).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find acls")
+	}
+	return acls, nil
+}
+
+func CreateACL(acl *model.ACL) error {
+	return errors.WithStack(db.Create(acl).Error)
+}
+
+func UpdateACL(acl *model.ACL) error {
+	return errors.WithStack(db.Save(acl).Error)
+}
+
+func DeleteACLById(id uint) error {
+	return errors.WithStack(db.Delete(&model.ACL{}, id).Error)
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetAPITokenById(id uint) (*model.APIToken, error) {
+	var t model.APIToken
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get api token")
+	}
+	return &t, nil
+}
+
+func GetAPITokenByToken(token string) (*model.APIToken, error) {
+	var t model.APIToken
+	if err := db.Where(columnName("token")+" = ?", token).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get api token")
+	}
+	return &t, nil
+}
+
+func GetAPITokenByAccessKey(accessKey string) (*model.APIToken, error) {
+	var t model.APIToken
+	if err := db.Where(columnName("access_key")+" = ?", accessKey).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get api token")
+	}
+	return &t, nil
+}
+
+// GetAPITokens returns the tokens of the user, or all tokens if userId is 0
+func GetAPITokens(userId uint, pageIndex, pageSize int) (tokens []model.APIToken, count int64, err error) {
+	tokenDB := db.Model(&model.APIToken{})
+	if userId != 0 {
+		tokenDB = tokenDB.Where(columnName("user_id")+" = ?", userId)
+	}
+	if err = tokenDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get api tokens count")
+	}
+	if err = tokenDB.Order(columnName("created_at") + " desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&tokens).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find api tokens")
+	}
+	return tokens, count, nil
+}
+
+func CreateAPIToken(t *model.APIToken) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func DeleteAPITokenById(id uint) error {
+	return errors.WithStack(db.Delete(&model.APIToken{}, id).Error)
+}
+
+func DeleteAPITokensByUserId(userId uint) error {
+	return errors.WithStack(db.Where(columnName("user_id")+" = ?", userId).Delete(&model.APIToken{}).Error)
+}
+
+func UpdateAPITokenLastUsed(id uint, t time.Time) error {
+	return errors.WithStack(db.Model(&model.APIToken{}).Where("id = ?", id).Update("last_used_at", t).Error)
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func CreateAuditLog(l *model.AuditLog) error {
+	return errors.WithStack(db.Create(l).Error)
+}
+
+func filterAuditLogs(filter model.AuditLogFilter) *gorm.DB {
+	logDB := db.Model(&model.AuditLog{})
+	if filter.Username != "" {
+		logDB = logDB.Where(columnName("username")+" = ?", filter.Username)
+	}
+	if filter.Action != "" {
+		logDB = logDB.Where(columnName("action")+" = ?", filter.Action)
+	}
+	if filter.Path != "" {
+		logDB = logDB.Where("("+columnName("src_path")+" LIKE ? OR "+columnName("dst_path")+" LIKE ?)",
+			filter.Path+"%", filter.Path+"%")
+	}
+	if filter.Storage != "" {
+		logDB = logDB.Where(columnName("storage")+" = ?", filter.Storage)
+	}
+	if filter.Success != nil {
+		logDB = logDB.Where(columnName("success")+" = ?", *filter.Success)
+	}
+	if !filter.Start.IsZero() {
+		logDB = logDB.Where(columnName("created_at")+" >= ?", filter.Start)
+	}
+	if !filter.End.IsZero() {
+		logDB = logDB.Where(columnName("created_at")+" < ?", filter.End)
+	}
+	return logDB
+}
+
+func GetAuditLogs(filter model.AuditLogFilter, pageIndex, pageSize int) (logs []model.AuditLog, count int64, err error) {
+	logDB := filterAuditLogs(filter)
+	if err = logDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get audit logs count")
+	}
+	if err = logDB.Order(columnName("id") + " desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find audit logs")
+	}
+	return logs, count, nil
+}
+
+// GetAuditLogsBeforeId returns at most limit logs with id less than the given
+// one in desc order, so that all logs can be iterated without counting
+func GetAuditLogsBeforeId(filter model.AuditLogFilter, id uint, limit int) ([]model.AuditLog, error) {
+	var logs []model.AuditLog
+	logDB := filterAuditLogs(filter)
+	if id > 0 {
+		logDB = logDB.Where(columnName("id")+" < ?", id)
+	}
+	if err := logDB.Order(columnName("id") + " desc").Limit(limit).Find(&logs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find audit logs")
+	}
+	return logs, nil
+}
+
+func DeleteAuditLogsBefore(t time.Time) error {
+	return errors.WithStack(db.Where(columnName("created_at")+" < ?", t).Delete(&model.AuditLog{}).Error)
+}
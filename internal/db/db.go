@@ -12,7 +12,10 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode),
+		new(model.SyncJob), new(model.SyncRun), new(model.SyncState), new(model.TrashItem),
+		new(model.Share), new(model.UploadLink), new(model.Usage), new(model.UserUsage), new(model.AuditLog),
+		new(model.Group), new(model.UserGroup), new(model.ACL), new(model.APIToken), new(model.SSHPublicKey), new(model.S3Key), new(model.HashCache))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
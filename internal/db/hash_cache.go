@@ -0,0 +1,38 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetHashCaches(storageId uint, dir string) (caches []model.HashCache, err error) {
+	if err = db.Where(columnName("storage_id")+" = ? AND "+columnName("dir")+" = ?", storageId, dir).Find(&caches).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find hash caches")
+	}
+	return caches, nil
+}
+
+// GetHashCacheStorageIds returns the ids of the storages having hash caches
+func GetHashCacheStorageIds() (ids []uint, err error) {
+	if err = db.Model(&model.HashCache{}).Distinct(columnName("storage_id")).Pluck(columnName("storage_id"), &ids).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get storage ids of hash caches")
+	}
+	return ids, nil
+}
+
+// SaveHashCache replaces the hash cache of the same file
+func SaveHashCache(c *model.HashCache) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where(columnName("storage_id")+" = ? AND "+columnName("dir")+" = ? AND "+columnName("name")+" = ?",
+			c.StorageID, c.Dir, c.Name).Delete(&model.HashCache{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(c).Error
+	}))
+}
+
+func DeleteHashCachesByStorageId(storageId uint) error {
+	return errors.WithStack(db.Where(columnName("storage_id")+" = ?", storageId).Delete(&model.HashCache{}).Error)
+}
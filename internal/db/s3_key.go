@@ -0,0 +1,54 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetS3KeyById(id uint) (*model.S3Key, error) {
+	var k model.S3Key
+	if err := db.First(&k, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 key")
+	}
+	return &k, nil
+}
+
+func GetS3KeyByAccessKey(accessKey string) (*model.S3Key, error) {
+	var k model.S3Key
+	if err := db.Where(columnName("access_key")+" = ?", accessKey).First(&k).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 key")
+	}
+	return &k, nil
+}
+
+func GetS3Keys(userId uint, pageIndex, pageSize int) (keys []model.S3Key, count int64, err error) {
+	keyDB := db.Model(&model.S3Key{})
+	if userId != 0 {
+		keyDB = keyDB.Where(columnName("user_id")+" = ?", userId)
+	}
+	if err = keyDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get s3 keys count")
+	}
+	if err = keyDB.Order(columnName("created_at") + " desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&keys).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find s3 keys")
+	}
+	return keys, count, nil
+}
+
+func CreateS3Key(k *model.S3Key) error {
+	return errors.WithStack(db.Create(k).Error)
+}
+
+func DeleteS3KeyById(id uint) error {
+	return errors.WithStack(db.Delete(&model.S3Key{}, id).Error)
+}
+
+func DeleteS3KeysByUserId(userId uint) error {
+	return errors.WithStack(db.Where(columnName("user_id")+" = ?", userId).Delete(&model.S3Key{}).Error)
+}
+
+func UpdateS3KeyLastUsed(id uint, t time.Time) error {
+	return errors.WithStack(db.Model(&model.S3Key{}).Where("id = ?", id).Update("last_used_at", t).Error)
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetShareById(id string) (*model.Share, error) {
+	var share model.Share
+	if err := db.Where("id = ?", id).First(&share).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get share")
+	}
+	return &share, nil
+}
+
+// GetShares returns the shares created by the user, or all shares if creatorId is 0
+func GetShares(creatorId uint, pageIndex, pageSize int) (shares []model.Share, count int64, err error) {
+	shareDB := db.Model(&model.Share{})
+	if creatorId != 0 {
+		shareDB = shareDB.Where(columnName("creator_id")+" = ?", creatorId)
+	}
+	if err = shareDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get shares count")
+	}
+	if err = shareDB.Order(columnName("created_at") + " desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&shares).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find shares")
+	}
+	return shares, count, nil
+}
+
+func CreateShare(share *model.Share) error {
+	return errors.WithStack(db.Create(share).Error)
+}
+
+func DeleteShareById(id string) error {
+	return errors.WithStack(db.Where("id = ?", id).Delete(&model.Share{}).Error)
+}
+
+// IncrShareDownloads counts a download of the share, false is returned if
+// the share has reached the max downloads
+func IncrShareDownloads(id string) (bool, error) {
+	downloads := columnName("downloads")
+	maxDownloads := columnName("max_downloads")
+	res := db.Model(&model.Share{}).
+		Where(fmt.Sprintf("id = ? AND (%s = 0 OR %s < %s)", maxDownloads, downloads, maxDownloads), id).
+		Update("downloads", gorm.Expr(downloads+" + 1"))
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetSSHPublicKeyById(id uint) (*model.SSHPublicKey, error) {
+	var k model.SSHPublicKey
+	if err := db.First(&k, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get ssh public key")
+	}
+	return &k, nil
+}
+
+func GetSSHPublicKeyByFingerprint(fingerprint string) (*model.SSHPublicKey, error) {
+	var k model.SSHPublicKey
+	if err := db.Where(columnName("fingerprint")+" = ?", fingerprint).First(&k).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get ssh public key")
+	}
+	return &k, nil
+}
+
+func GetSSHPublicKeys(userId uint, pageIndex, pageSize int) (keys []model.SSHPublicKey, count int64, err error) {
+	keyDB := db.Model(&model.SSHPublicKey{})
+	if userId != 0 {
+		keyDB = keyDB.Where(columnName("user_id")+" = ?", userId)
+	}
+	if err = keyDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get ssh public keys count")
+	}
+	if err = keyDB.Order(columnName("created_at") + " desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&keys).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find ssh public keys")
+	}
+	return keys, count, nil
+}
+
+func CreateSSHPublicKey(k *model.SSHPublicKey) error {
+	return errors.WithStack(db.Create(k).Error)
+}
+
+func DeleteSSHPublicKeyById(id uint) error {
+	return errors.WithStack(db.Delete(&model.SSHPublicKey{}, id).Error)
+}
+
+func DeleteSSHPublicKeysByUserId(userId uint) error {
+	return errors.WithStack(db.Where(columnName("user_id")+" = ?", userId).Delete(&model.SSHPublicKey{}).Error)
+}
+
+func UpdateSSHPublicKeyLastUsed(id uint, t time.Time) error {
+	return errors.WithStack(db.Model(&model.SSHPublicKey{}).Where("id = ?", id).Update("last_used_at", t).Error)
+}
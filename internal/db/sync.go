@@ -0,0 +1,92 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetSyncJobById(id uint) (*model.SyncJob, error) {
+	var job model.SyncJob
+	if err := db.First(&job, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get sync job")
+	}
+	return &job, nil
+}
+
+func GetSyncJobs(pageIndex, pageSize int) (jobs []model.SyncJob, count int64, err error) {
+	jobDB := db.Model(&model.SyncJob{})
+	if err = jobDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sync jobs count")
+	}
+	if err = jobDB.Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find sync jobs")
+	}
+	return jobs, count, nil
+}
+
+func GetEnabledSyncJobs() ([]model.SyncJob, error) {
+	var jobs []model.SyncJob
+	if err := db.Where(columnName("disabled")+" = ?", false).Find(&jobs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find enabled sync jobs")
+	}
+	return jobs, nil
+}
+
+func CreateSyncJob(job *model.SyncJob) error {
+	return errors.WithStack(db.Create(job).Error)
+}
+
+func UpdateSyncJob(job *model.SyncJob) error {
+	return errors.WithStack(db.Save(job).Error)
+}
+
+// DeleteSyncJobById deletes the job and its run history
+func DeleteSyncJobById(id uint) error {
+	if err := DeleteSyncState(id); err != nil {
+		return err
+	}
+	if err := db.Where("job_id = ?", id).Delete(&model.SyncRun{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Delete(&model.SyncJob{}, id).Error)
+}
+
+func CreateSyncRun(run *model.SyncRun) error {
+	return errors.WithStack(db.Create(run).Error)
+}
+
+func UpdateSyncRun(run *model.SyncRun) error {
+	return errors.WithStack(db.Save(run).Error)
+}
+
+func GetSyncRuns(jobId uint, pageIndex, pageSize int) (runs []model.SyncRun, count int64, err error) {
+	runDB := db.Model(&model.SyncRun{}).Where("job_id = ?", jobId)
+	if err = runDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sync runs count")
+	}
+	if err = runDB.Order("id desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&runs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find sync runs")
+	}
+	return runs, count, nil
+}
+
+// GetSyncState returns nil if the job has never finished a bidirectional run
+func GetSyncState(jobId uint) (*model.SyncState, error) {
+	var state model.SyncState
+	if err := db.Where("job_id = ?", jobId).First(&state).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed get sync state")
+	}
+	return &state, nil
+}
+
+func SaveSyncState(state *model.SyncState) error {
+	return errors.WithStack(db.Save(state).Error)
+}
+
+func DeleteSyncState(jobId uint) error {
+	return errors.WithStack(db.Where("job_id = ?", jobId).Delete(&model.SyncState{}).Error)
+}
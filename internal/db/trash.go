@@ -0,0 +1,44 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetTrashItemById(id uint) (*model.TrashItem, error) {
+	var item model.TrashItem
+	if err := db.First(&item, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get trash item")
+	}
+	return &item, nil
+}
+
+func GetTrashItems(pageIndex, pageSize int) (items []model.TrashItem, count int64, err error) {
+	itemDB := db.Model(&model.TrashItem{})
+	if err = itemDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get trash items count")
+	}
+	if err = itemDB.Order(columnName("deleted_at") + " desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find trash items")
+	}
+	return items, count, nil
+}
+
+// GetTrashItemsDeletedBefore returns the items should be purged by the retention
+func GetTrashItemsDeletedBefore(t time.Time) ([]model.TrashItem, error) {
+	var items []model.TrashItem
+	if err := db.Where(columnName("deleted_at")+" < ?", t).Find(&items).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find expired trash items")
+	}
+	return items, nil
+}
+
+func CreateTrashItem(item *model.TrashItem) error {
+	return errors.WithStack(db.Create(item).Error)
+}
+
+func DeleteTrashItemById(id uint) error {
+	return errors.WithStack(db.Delete(&model.TrashItem{}, id).Error)
+}
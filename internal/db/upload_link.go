@@ -0,0 +1,56 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetUploadLinkById(id string) (*model.UploadLink, error) {
+	var link model.UploadLink
+	if err := db.Where("id = ?", id).First(&link).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get upload link")
+	}
+	return &link, nil
+}
+
+// GetUploadLinks returns the links created by the user, or all links if creatorId is 0
+func GetUploadLinks(creatorId uint, pageIndex, pageSize int) (links []model.UploadLink, count int64, err error) {
+	linkDB := db.Model(&model.UploadLink{})
+	if creatorId != 0 {
+		linkDB = linkDB.Where(columnName("creator_id")+" = ?", creatorId)
+	}
+	if err = linkDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get upload links count")
+	}
+	if err = linkDB.Order(columnName("created_at") + " desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&links).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find upload links")
+	}
+	return links, count, nil
+}
+
+func CreateUploadLink(link *model.UploadLink) error {
+	return errors.WithStack(db.Create(link).Error)
+}
+
+func DeleteUploadLinkById(id string) error {
+	return errors.WithStack(db.Where("id = ?", id).Delete(&model.UploadLink{}).Error)
+}
+
+// AddUploadLinkUsed adds size to the used bytes of the link, false is returned
+// if the quota would be exceeded. A negative size releases the bytes.
+func AddUploadLinkUsed(id string, size int64) (bool, error) {
+	used := columnName("used")
+	quota := columnName("quota")
+	query := db.Model(&model.UploadLink{}).Where("id = ?", id)
+	if size > 0 {
+		query = query.Where(fmt.Sprintf("(%s = 0 OR %s + ? <= %s)", quota, used, quota), size)
+	}
+	res := query.Update("used", gorm.Expr(used+" + ?", size))
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
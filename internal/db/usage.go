@@ -0,0 +1,77 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetUsage(path string) (int64, error) {
+	var usage model.Usage
+	err := db.Where("path = ?", path).Limit(1).Find(&usage).Error
+	return usage.Used, errors.WithStack(err)
+}
+
+func AddUsage(path string, delta int64) error {
+	res := db.Model(&model.Usage{}).Where("path = ?", path).Updates(map[string]interface{}{
+		"used":       gorm.Expr("used + ?", delta),
+		"updated_at": time.Now(),
+	})
+	if res.Error != nil {
+		return errors.WithStack(res.Error)
+	}
+	if res.RowsAffected > 0 {
+		return nil
+	}
+	return errors.WithStack(db.Create(&model.Usage{Path: path, Used: delta, UpdatedAt: time.Now()}).Error)
+}
+
+func SetUsage(path string, used int64) error {
+	return errors.WithStack(db.Save(&model.Usage{Path: path, Used: used, UpdatedAt: time.Now()}).Error)
+}
+
+func GetUserUsage(userId uint) (int64, error) {
+	var usage model.UserUsage
+	err := db.Where("user_id = ?", userId).Limit(1).Find(&usage).Error
+	return usage.Used, errors.WithStack(err)
+}
+
+func AddUserUsage(userId uint, delta int64) error {
+	res := db.Model(&model.UserUsage{}).Where("user_id = ?", userId).Updates(map[string]interface{}{
+		"used":       gorm.Expr("used + ?", delta),
+		"updated_at": time.Now(),
+	})
+	if res.Error != nil {
+		return errors.WithStack(res.Error)
+	}
+	if res.RowsAffected > 0 {
+		return nil
+	}
+	return errors.WithStack(db.Create(&model.UserUsage{UserID: userId, Used: delta, UpdatedAt: time.Now()}).Error)
+}
+
+func SetUserUsage(userId uint, used int64) error {
+	return errors.WithStack(db.Save(&model.UserUsage{UserID: userId, Used: used, UpdatedAt: time.Now()}).Error)
+}
+
+func DeleteUserUsage(userId uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userId).Delete(&model.UserUsage{}).Error)
+}
+
+func GetUsersWithQuota() ([]model.User, error) {
+	var users []model.User
+	if err := db.Where("quota > 0").Find(&users).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find users with quota")
+	}
+	return users, nil
+}
+
+func GetMetasWithQuota() ([]model.Meta, error) {
+	var metas []model.Meta
+	if err := db.Where("quota > 0").Find(&metas).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find metas with quota")
+	}
+	return metas, nil
+}
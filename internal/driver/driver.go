@@ -46,6 +46,11 @@ type GetRooter interface {
 	GetRoot(ctx context.Context) (model.Obj, error)
 }
 
+// FreeSpace is implemented by drivers which know the free space of the storage
+type FreeSpace interface {
+	FreeSpace(ctx context.Context) (uint64, error)
+}
+
 type Getter interface {
 	// Get file by path, the path haven't been joined with root path
 	Get(ctx context.Context, path string) (model.Obj, error)
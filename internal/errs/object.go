@@ -7,9 +7,10 @@ import (
 )
 
 var (
-	ObjectNotFound = errors.New("object not found")
-	NotFolder      = errors.New("not a folder")
-	NotFile        = errors.New("not a file")
+	ObjectNotFound      = errors.New("object not found")
+	ObjectAlreadyExists = errors.New("object already exists")
+	NotFolder           = errors.New("not a folder")
+	NotFile             = errors.New("not a file")
 )
 
 func IsObjectNotFound(err error) bool {
@@ -0,0 +1,7 @@
+package errs
+
+import "errors"
+
+var (
+	QuotaExceeded = errors.New("quota exceeded")
+)
@@ -0,0 +1,182 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/archive"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// the index of an archive is kept by the path, size and modified time of the archive
+var archiveCache = cache.NewMemCache(cache.WithShards[*archive.Archive](16))
+
+const archiveCacheExpiration = 30 * time.Minute
+
+// findArchive finds the archive file which path is inside when browsing archives
+// is enabled, inner is the path in the archive, "/" for the archive itself.
+// Nested archives are not supported.
+func findArchive(ctx context.Context, path string) (archivePath, inner string, ok bool) {
+	if !setting.GetBool(conf.BrowseArchive) {
+		return "", "", false
+	}
+	path = utils.FixAndCleanPath(path)
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, part := range parts {
+		if !archive.IsArchive(part) {
+			continue
+		}
+		archivePath = "/" + strings.Join(parts[:i+1], "/")
+		obj, err := getDirectly(ctx, archivePath)
+		if err != nil || obj.IsDir() {
+			// it's a dir named like an archive, or the path doesn't exist
+			continue
+		}
+		return archivePath, "/" + strings.Join(parts[i+1:], "/"), true
+	}
+	return "", "", false
+}
+
+// IsInArchive returns whether the path is a file or dir inside an archive
+func IsInArchive(ctx context.Context, path string) bool {
+	_, inner, ok := findArchive(ctx, path)
+	return ok && inner != "/"
+}
+
+// archiveSource opens the content of the archive file by its link
+func archiveSource(ctx context.Context, archivePath string) (*archive.Source, model.Obj, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(archivePath)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed get storage")
+	}
+	link, obj, err := op.Link(ctx, storage, actualPath, model.LinkArgs{})
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed link archive")
+	}
+	// any link provided is seekable
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed get archive stream")
+	}
+	closers := utils.NewClosers(ss)
+	if link.RangeReadCloser != nil {
+		closers.Add(link.RangeReadCloser)
+	}
+	return &archive.Source{
+		Name: obj.GetName(),
+		Size: obj.GetSize(),
+		RangeReadCloserIF: &model.RangeReadCloser{
+			RangeReader: func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+				r, err := ss.RangeRead(httpRange)
+				if err != nil {
+					return nil, err
+				}
+				if rc, ok := r.(io.ReadCloser); ok {
+					return rc, nil
+				}
+				return io.NopCloser(r), nil
+			},
+			Closers: closers,
+		},
+	}, obj, nil
+}
+
+func getArchive(ctx context.Context, archivePath string) (*archive.Archive, model.Obj, error) {
+	obj, err := getDirectly(ctx, archivePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := fmt.Sprintf("%s:%d:%d", archivePath, obj.GetSize(), obj.ModTime().Unix())
+	if a, ok := archiveCache.Get(key); ok {
+		return a, obj, nil
+	}
+	src, _, err := archiveSource(ctx, archivePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer src.Close()
+	a, err := archive.Open(ctx, src)
+	if err != nil {
+		return nil, nil, err
+	}
+	archiveCache.Set(key, a, cache.WithEx[*archive.Archive](archiveCacheExpiration))
+	return a, obj, nil
+}
+
+func entryToObj(archivePath string, archiveObj model.Obj, e *archive.Entry) model.Obj {
+	modified := e.Modified
+	if modified.IsZero() {
+		modified = archiveObj.ModTime()
+	}
+	return &model.Object{
+		Path:     stdpath.Join(archivePath, e.Path),
+		Name:     stdpath.Base("/" + e.Path),
+		Size:     e.Size,
+		Modified: modified,
+		IsFolder: e.IsDir,
+	}
+}
+
+func listArchive(ctx context.Context, archivePath, inner string) ([]model.Obj, error) {
+	a, archiveObj, err := getArchive(ctx, archivePath)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := a.List(inner)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed list [%s] in archive", inner)
+	}
+	objs := make([]model.Obj, 0, len(entries))
+	for _, e := range entries {
+		objs = append(objs, entryToObj(archivePath, archiveObj, e))
+	}
+	return objs, nil
+}
+
+func getInArchive(ctx context.Context, archivePath, inner string) (model.Obj, error) {
+	a, archiveObj, err := getArchive(ctx, archivePath)
+	if err != nil {
+		return nil, err
+	}
+	e, err := a.Get(inner)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get [%s] in archive", inner)
+	}
+	return entryToObj(archivePath, archiveObj, e), nil
+}
+
+// linkInArchive streams the entry, the archive is opened again for each range
+func linkInArchive(ctx context.Context, archivePath, inner string) (*model.Link, model.Obj, error) {
+	obj, err := getInArchive(ctx, archivePath, inner)
+	if err != nil {
+		return nil, nil, err
+	}
+	if obj.IsDir() {
+		return nil, nil, errors.WithStack(errs.NotFile)
+	}
+	rrc := &model.RangeReadCloser{}
+	rrc.RangeReader = func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+		src, _, err := archiveSource(ctx, archivePath)
+		if err != nil {
+			return nil, err
+		}
+		rrc.Add(src)
+		return archive.OpenEntry(ctx, src, inner, httpRange)
+	}
+	return &model.Link{
+		RangeReadCloser: rrc,
+	}, obj, nil
+}
@@ -8,8 +8,10 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/tache"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -19,10 +21,11 @@ import (
 
 type CopyTask struct {
 	tache.Base
-	Name                   string `json:"name"`
-	Status                 string `json:"status"`
-	SrcObjPath             string `json:"src_path"`
-	DstDirPath             string `json:"dst_path"`
+	Name                   string               `json:"name"`
+	Status                 string               `json:"status"`
+	SrcObjPath             string               `json:"src_path"`
+	DstDirPath             string               `json:"dst_path"`
+	Conflict               model.ConflictPolicy `json:"conflict"`
 	srcStorage, dstStorage driver.Driver
 }
 
@@ -51,6 +54,12 @@ func (t *CopyTask) Run() error {
 	return copyBetween2Storages(t, t.srcStorage, t.dstStorage, t.SrcObjPath, t.DstDirPath)
 }
 
+// conflictPolicy returns the conflict policy set in the ctx by the caller
+func conflictPolicy(ctx context.Context) model.ConflictPolicy {
+	p, _ := ctx.Value(conf.ConflictPolicyKey).(model.ConflictPolicy)
+	return p
+}
+
 var CopyTaskManager *tache.Manager[*CopyTask]
 
 // Copy if in the same storage, call move method
@@ -66,7 +75,10 @@ func _copy(ctx context.Context, SrcObjPath, DstDirPath string, lazyCache ...bool
 	}
 	// copy if in the same storage, just call driver.Copy
 	if srcStorage.GetStorage() == dstStorage.GetStorage() {
-		return nil, op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath, lazyCache...)
+		done, err := copyInStorage(ctx, srcStorage, srcObjActualPath, dstDirActualPath, lazyCache...)
+		if done {
+			return nil, err
+		}
 	}
 	if ctx.Value(conf.NoTaskKey) != nil {
 		srcObj, err := op.Get(ctx, srcStorage, srcObjActualPath)
@@ -82,8 +94,9 @@ func _copy(ctx context.Context, SrcObjPath, DstDirPath string, lazyCache ...bool
 				return nil, errors.WithMessagef(err, "failed get [%s] link", SrcObjPath)
 			}
 			fs := stream.FileStream{
-				Obj: srcObj,
-				Ctx: ctx,
+				Obj:      srcObj,
+				Ctx:      ctx,
+				Conflict: conflictPolicy(ctx),
 			}
 			// any link provided is seekable
 			ss, err := stream.NewSeekableStream(fs, link)
@@ -100,11 +113,43 @@ func _copy(ctx context.Context, SrcObjPath, DstDirPath string, lazyCache ...bool
 		dstStorage: dstStorage,
 		SrcObjPath: srcObjActualPath,
 		DstDirPath: dstDirActualPath,
+		Conflict:   conflictPolicy(ctx),
 	}
 	CopyTaskManager.Add(t)
 	return t, nil
 }
 
+// copyInStorage applies the conflict policy before calling driver.Copy, which
+// overwrites the existing dst. It's not done if the copy has to be renamed,
+// then it's copied like between 2 storages since the driver can't rename it.
+func copyInStorage(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string, lazyCache ...bool) (bool, error) {
+	dstObj, err := op.Get(ctx, storage, stdpath.Join(dstDirPath, stdpath.Base(srcPath)))
+	if errs.IsObjectNotFound(err) {
+		return true, op.Copy(ctx, storage, srcPath, dstDirPath, lazyCache...)
+	}
+	if err != nil {
+		return true, errors.WithMessage(err, "failed get dst object")
+	}
+	switch conflictPolicy(ctx) {
+	case model.ConflictFail:
+		return true, errors.WithStack(errs.ObjectAlreadyExists)
+	case model.ConflictSkip:
+		srcObj, err := op.Get(ctx, storage, srcPath)
+		if err != nil {
+			return true, errors.WithMessage(err, "failed get src object")
+		}
+		if !srcObj.IsDir() && !dstObj.IsDir() && srcObj.GetSize() == dstObj.GetSize() {
+			// only the size is compared if they have no hash in common
+			if equal, ok := utils.CompareHashInfo(dstObj.GetHash(), srcObj.GetHash()); equal || !ok {
+				return true, nil
+			}
+		}
+	case model.ConflictRename:
+		return false, nil
+	}
+	return true, op.Copy(ctx, storage, srcPath, dstDirPath, lazyCache...)
+}
+
 func copyBetween2Storages(t *CopyTask, srcStorage, dstStorage driver.Driver, SrcObjPath, DstDirPath string) error {
 	t.Status = "getting src object"
 	srcObj, err := op.Get(t.Ctx(), srcStorage, SrcObjPath)
@@ -128,33 +173,66 @@ func copyBetween2Storages(t *CopyTask, srcStorage, dstStorage driver.Driver, Src
 				dstStorage: dstStorage,
 				SrcObjPath: SrcObjPath,
 				DstDirPath: dstObjPath,
+				Conflict:   t.Conflict,
 			})
 		}
 		t.Status = "src object is dir, added all copy tasks of objs"
 		return nil
 	}
-	return copyFileBetween2Storages(t, srcStorage, dstStorage, SrcObjPath, DstDirPath)
+	dstName, err := copyFileBetween2Storages(t.Ctx(), srcStorage, dstStorage, SrcObjPath, DstDirPath, t.Conflict, t.SetProgress)
+	if err != nil || !setting.GetBool(conf.VerifyCopy) {
+		return err
+	}
+	// the task is retried if the verification fails, the bad dst is removed
+	t.Status = "verifying dst file"
+	return verifyCopiedFile(t.Ctx(), srcStorage, SrcObjPath, srcObj, dstStorage, stdpath.Join(DstDirPath, dstName))
 }
 
-func copyFileBetween2Storages(tsk *CopyTask, srcStorage, dstStorage driver.Driver, srcFilePath, DstDirPath string) error {
-	srcFile, err := op.Get(tsk.Ctx(), srcStorage, srcFilePath)
+// CopyFile copies a file to the dst dir at once without adding a task,
+// the conflict policy and the verify_copy setting apply like in a copy task
+func CopyFile(ctx context.Context, srcFilePath, dstDirPath string, conflict model.ConflictPolicy) error {
+	srcStorage, srcFileActualPath, err := op.GetStorageAndActualPath(srcFilePath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	srcObj, err := op.Get(ctx, srcStorage, srcFileActualPath)
 	if err != nil {
 		return errors.WithMessagef(err, "failed get src [%s] file", srcFilePath)
 	}
-	link, _, err := op.Link(tsk.Ctx(), srcStorage, srcFilePath, model.LinkArgs{
+	dstName, err := copyFileBetween2Storages(ctx, srcStorage, dstStorage, srcFileActualPath, dstDirActualPath, conflict, nil)
+	if err != nil || !setting.GetBool(conf.VerifyCopy) {
+		return err
+	}
+	return verifyCopiedFile(ctx, srcStorage, srcFileActualPath, srcObj, dstStorage, stdpath.Join(dstDirActualPath, dstName))
+}
+
+// copyFileBetween2Storages returns the name of the copied file in the dst dir,
+// which differs from the src if it's renamed by the conflict policy
+func copyFileBetween2Storages(ctx context.Context, srcStorage, dstStorage driver.Driver, srcFilePath, DstDirPath string, conflict model.ConflictPolicy, up driver.UpdateProgress) (string, error) {
+	srcFile, err := op.Get(ctx, srcStorage, srcFilePath)
+	if err != nil {
+		return "", errors.WithMessagef(err, "failed get src [%s] file", srcFilePath)
+	}
+	link, _, err := op.Link(ctx, srcStorage, srcFilePath, model.LinkArgs{
 		Header: http.Header{},
 	})
 	if err != nil {
-		return errors.WithMessagef(err, "failed get [%s] link", srcFilePath)
+		return "", errors.WithMessagef(err, "failed get [%s] link", srcFilePath)
 	}
 	fs := stream.FileStream{
-		Obj: srcFile,
-		Ctx: tsk.Ctx(),
+		Obj:      srcFile,
+		Ctx:      ctx,
+		Conflict: conflict,
 	}
 	// any link provided is seekable
 	ss, err := stream.NewSeekableStream(fs, link)
 	if err != nil {
-		return errors.WithMessagef(err, "failed get [%s] stream", srcFilePath)
+		return "", errors.WithMessagef(err, "failed get [%s] stream", srcFilePath)
 	}
-	return op.Put(tsk.Ctx(), dstStorage, DstDirPath, ss, tsk.SetProgress, true)
+	err = op.Put(ctx, dstStorage, DstDirPath, ss, up, true)
+	return ss.GetName(), err
 }
@@ -0,0 +1,188 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	stdpath "path"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/archive"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/tache"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// the policies when an extracted file exists in the dst dir
+const (
+	ExtractOverwrite = "overwrite"
+	ExtractSkip      = "skip"
+	ExtractFail      = "fail"
+)
+
+type ExtractTask struct {
+	tache.Base
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	SrcPath    string `json:"src_path"`
+	DstDirPath string `json:"dst_path"`
+	// Password is not persisted with the task, so an encrypted archive
+	// fails with a password error if the task is restored after a restart
+	Password  string `json:"-"`
+	Overwrite string `json:"overwrite"`
+}
+
+func (t *ExtractTask) GetName() string {
+	return t.Name
+}
+
+func (t *ExtractTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *ExtractTask) OnFailed() {
+	result := fmt.Sprintf("%s:%s", t.GetName(), t.GetErr())
+	log.Debug(result)
+	go op.Notify("文件解压结果", result)
+}
+
+func (t *ExtractTask) OnSucceeded() {
+	result := fmt.Sprintf("解压%s到%s成功", t.SrcPath, t.DstDirPath)
+	log.Debug(result)
+	go op.Notify("文件解压结果", result)
+}
+
+func (t *ExtractTask) Run() error {
+	ctx := t.Ctx()
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(t.DstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	t.Status = "getting archive"
+	src, _, err := archiveSource(ctx, t.SrcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	src.Password = t.Password
+	// the progress is the part of the archive has been read
+	var read int64
+	src.RangeReadCloserIF = &countingSource{RangeReadCloserIF: src.RangeReadCloserIF, read: &read}
+	var extracted, skipped int
+	err = archive.Walk(ctx, src, func(e *archive.Entry, r io.Reader) error {
+		if src.Size > 0 {
+			t.SetProgress(min(float64(atomic.LoadInt64(&read))/float64(src.Size)*100, 100))
+		}
+		dstPath := stdpath.Join(dstDirActualPath, e.Path)
+		if e.IsDir {
+			return op.MakeDir(ctx, dstStorage, dstPath)
+		}
+		if t.Overwrite != ExtractOverwrite {
+			if _, err := op.Get(ctx, dstStorage, dstPath); err == nil {
+				if t.Overwrite == ExtractSkip {
+					skipped++
+					return nil
+				}
+				return errors.WithStack(errs.ObjectAlreadyExists)
+			} else if !errs.IsObjectNotFound(err) {
+				return errors.WithMessagef(err, "failed check [%s]", e.Path)
+			}
+		}
+		name := stdpath.Base(e.Path)
+		modified := e.Modified
+		if modified.IsZero() {
+			modified = time.Now()
+		}
+		file := &stream.FileStream{
+			Ctx: ctx,
+			Obj: &model.Object{
+				Name:     name,
+				Size:     e.Size,
+				Modified: modified,
+			},
+			Reader:   r,
+			Mimetype: utils.GetMimeType(name),
+		}
+		t.Status = fmt.Sprintf("extracting [%s]", e.Path)
+		err := op.Put(ctx, dstStorage, stdpath.Dir(dstPath), file, func(p float64) {
+			t.Status = fmt.Sprintf("extracting [%s] %.2f%%", e.Path, p)
+		}, true)
+		if err != nil {
+			return errors.WithMessagef(err, "failed put [%s]", e.Path)
+		}
+		extracted++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	t.Status = fmt.Sprintf("extracted %d files, skipped %d files", extracted, skipped)
+	return nil
+}
+
+// countingSource counts the bytes read from the archive
+type countingSource struct {
+	model.RangeReadCloserIF
+	read *int64
+}
+
+func (s *countingSource) RangeRead(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+	rc, err := s.RangeReadCloserIF.RangeRead(ctx, httpRange)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ReadCloser{Reader: &countingReader{Reader: rc, read: s.read}, Closer: rc}, nil
+}
+
+type countingReader struct {
+	io.Reader
+	read *int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	atomic.AddInt64(r.read, int64(n))
+	return n, err
+}
+
+var ExtractTaskManager *tache.Manager[*ExtractTask]
+
+func extract(ctx context.Context, srcPath, dstDirPath, password, overwrite string) (tache.TaskWithInfo, error) {
+	if !archive.IsArchive(srcPath) {
+		return nil, errors.WithStack(errs.NotSupport)
+	}
+	switch overwrite {
+	case "":
+		overwrite = ExtractOverwrite
+	case ExtractOverwrite, ExtractSkip, ExtractFail:
+	default:
+		return nil, errors.Errorf("invalid overwrite policy: %s", overwrite)
+	}
+	srcObj, err := getDirectly(ctx, srcPath)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get src [%s] file", srcPath)
+	}
+	if srcObj.IsDir() {
+		return nil, errors.WithStack(errs.NotFile)
+	}
+	dstStorage, _, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	t := &ExtractTask{
+		Name:       fmt.Sprintf("extract [%s] to [%s](%s)", srcPath, dstStorage.GetStorage().MountPath, dstDirPath),
+		SrcPath:    srcPath,
+		DstDirPath: dstDirPath,
+		Password:   password,
+		Overwrite:  overwrite,
+	}
+	ExtractTaskManager.Add(t)
+	return t, nil
+}
@@ -62,12 +62,12 @@ func MakeDir(ctx context.Context, path string, lazyCache ...bool) error {
 	return err
 }
 
-func Move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) error {
-	err := move(ctx, srcPath, dstDirPath, lazyCache...)
+func Move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) (tache.TaskWithInfo, error) {
+	res, err := move(ctx, srcPath, dstDirPath, lazyCache...)
 	if err != nil {
 		log.Errorf("failed move %s to %s: %+v", srcPath, dstDirPath, err)
 	}
-	return err
+	return res, err
 }
 
 func Copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool) (tache.TaskWithInfo, error) {
@@ -78,6 +78,23 @@ func Copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool)
 	return res, err
 }
 
+// ComputeHash adds a task computing the hashes of the file or the files in the dir
+func ComputeHash(ctx context.Context, path string) (tache.TaskWithInfo, error) {
+	res, err := computeHash(ctx, path)
+	if err != nil {
+		log.Errorf("failed compute hash %s: %+v", path, err)
+	}
+	return res, err
+}
+
+func Extract(ctx context.Context, srcPath, dstDirPath, password, overwrite string) (tache.TaskWithInfo, error) {
+	res, err := extract(ctx, srcPath, dstDirPath, password, overwrite)
+	if err != nil {
+		log.Errorf("failed extract %s to %s: %+v", srcPath, dstDirPath, err)
+	}
+	return res, err
+}
+
 func Rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) error {
 	err := rename(ctx, srcPath, dstName, lazyCache...)
 	if err != nil {
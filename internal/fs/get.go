@@ -12,6 +12,14 @@ import (
 )
 
 func get(ctx context.Context, path string) (model.Obj, error) {
+	if archivePath, inner, ok := findArchive(ctx, path); ok && inner != "/" {
+		return getInArchive(ctx, archivePath, inner)
+	}
+	return getDirectly(ctx, path)
+}
+
+// getDirectly gets the obj without looking into archives
+func getDirectly(ctx context.Context, path string) (model.Obj, error) {
 	path = utils.FixAndCleanPath(path)
 	// maybe a virtual file
 	if path != "/" {
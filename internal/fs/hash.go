@@ -0,0 +1,136 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/tache"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// HashTask computes the hashes that the driver doesn't provide for a file or
+// all the files in a dir, and saves them in the hash cache
+type HashTask struct {
+	tache.Base
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Path   string `json:"path"`
+}
+
+func (t *HashTask) GetName() string {
+	return t.Name
+}
+
+func (t *HashTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *HashTask) OnFailed() {
+	result := fmt.Sprintf("%s:%s", t.GetName(), t.GetErr())
+	log.Debug(result)
+	go op.Notify("文件哈希计算结果", result)
+}
+
+func (t *HashTask) OnSucceeded() {
+	result := fmt.Sprintf("计算%s的哈希成功", t.Path)
+	log.Debug(result)
+	go op.Notify("文件哈希计算结果", result)
+}
+
+func (t *HashTask) Run() error {
+	storage, actualPath, err := op.GetStorageAndActualPath(t.Path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	t.Status = "getting object"
+	obj, err := op.Get(t.Ctx(), storage, actualPath)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s]", t.Path)
+	}
+	t.Status = "collecting files"
+	type entry struct {
+		obj  model.Obj
+		path string
+	}
+	var files []entry
+	var totalSize int64
+	dirs := []entry{{obj: obj, path: actualPath}}
+	for len(dirs) > 0 {
+		if utils.IsCanceled(t.Ctx()) {
+			return t.Ctx().Err()
+		}
+		e := dirs[0]
+		dirs = dirs[1:]
+		if !e.obj.IsDir() {
+			files = append(files, e)
+			totalSize += e.obj.GetSize()
+			continue
+		}
+		objs, err := op.List(t.Ctx(), storage, e.path, model.ListArgs{})
+		if err != nil {
+			return errors.WithMessagef(err, "failed list [%s]", e.path)
+		}
+		for _, obj := range objs {
+			dirs = append(dirs, entry{obj: obj, path: stdpath.Join(e.path, obj.GetName())})
+		}
+	}
+	var hashedSize int64
+	for _, e := range files {
+		if utils.IsCanceled(t.Ctx()) {
+			return t.Ctx().Err()
+		}
+		if types := missingHashTypes(e.obj); len(types) > 0 {
+			t.Status = fmt.Sprintf("hashing [%s]", e.path)
+			hash, err := hashFile(t.Ctx(), storage, e.path, e.obj, types...)
+			if err != nil {
+				return errors.WithMessagef(err, "failed hash [%s]", e.path)
+			}
+			if err = op.SaveHashCache(storage, e.path, e.obj, hash); err != nil {
+				return err
+			}
+		}
+		hashedSize += e.obj.GetSize()
+		if totalSize > 0 {
+			t.SetProgress(float64(hashedSize) / float64(totalSize) * 100)
+		}
+	}
+	t.Status = "hashed"
+	t.SetProgress(100)
+	return nil
+}
+
+// missingHashTypes returns the hash types that can be computed but the obj
+// doesn't have, including the ones from the hash cache
+func missingHashTypes(obj model.Obj) []*utils.HashType {
+	var types []*utils.HashType
+	hash := obj.GetHash()
+	for _, ht := range verifyHashTypes {
+		if hash.GetHash(ht) == "" {
+			types = append(types, ht)
+		}
+	}
+	return types
+}
+
+var HashTaskManager *tache.Manager[*HashTask]
+
+func computeHash(ctx context.Context, path string) (tache.TaskWithInfo, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	if _, err = op.Get(ctx, storage, actualPath); err != nil {
+		return nil, errors.WithMessagef(err, "failed get [%s]", path)
+	}
+	t := &HashTask{
+		Name: fmt.Sprintf("compute hashes of [%s](%s)", storage.GetStorage().MountPath, path),
+		Path: path,
+	}
+	HashTaskManager.Add(t)
+	return t, nil
+}
@@ -12,6 +12,9 @@ import (
 )
 
 func link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, model.Obj, error) {
+	if archivePath, inner, ok := findArchive(ctx, path); ok && inner != "/" {
+		return linkInArchive(ctx, archivePath, inner)
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "failed get storage")
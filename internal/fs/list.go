@@ -12,6 +12,9 @@ import (
 
 // List files
 func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error) {
+	if archivePath, inner, ok := findArchive(ctx, path); ok {
+		return listArchive(ctx, archivePath, inner)
+	}
 	meta, _ := ctx.Value("meta").(*model.Meta)
 	user, _ := ctx.Value("user").(*model.User)
 	virtualFiles := op.GetStorageVirtualFilesByPath(path)
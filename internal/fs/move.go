@@ -0,0 +1,149 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/tache"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// MoveTask moves an object between two storages: all files are copied to
+// the destination and verified, the source is only removed after that.
+// Paths are mount paths, so the task can be resumed after a restart.
+type MoveTask struct {
+	tache.Base
+	Name       string               `json:"name"`
+	Status     string               `json:"status"`
+	SrcObjPath string               `json:"src_path"`
+	DstDirPath string               `json:"dst_path"`
+	Conflict   model.ConflictPolicy `json:"conflict"`
+}
+
+func (t *MoveTask) GetName() string {
+	return t.Name
+}
+
+func (t *MoveTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *MoveTask) OnFailed() {
+	result := fmt.Sprintf("%s:%s", t.GetName(), t.GetErr())
+	log.Debug(result)
+	go op.Notify("文件移动结果", result)
+}
+
+func (t *MoveTask) OnSucceeded() {
+	result := fmt.Sprintf("移动%s到%s成功", t.SrcObjPath, t.DstDirPath)
+	log.Debug(result)
+	go op.Notify("文件移动结果", result)
+}
+
+func (t *MoveTask) Run() error {
+	return moveBetween2Storages(t)
+}
+
+var MoveTaskManager *tache.Manager[*MoveTask]
+
+func moveAsTask(ctx context.Context, srcStorage, dstStorage driver.Driver, srcObjPath, dstDirPath string) (tache.TaskWithInfo, error) {
+	t := &MoveTask{
+		Name:       fmt.Sprintf("move [%s](%s) to [%s](%s)", srcStorage.GetStorage().MountPath, srcObjPath, dstStorage.GetStorage().MountPath, dstDirPath),
+		SrcObjPath: srcObjPath,
+		DstDirPath: dstDirPath,
+		Conflict:   conflictPolicy(ctx),
+	}
+	if ctx.Value(conf.NoTaskKey) != nil {
+		t.SetCtx(ctx)
+		return nil, t.Run()
+	}
+	MoveTaskManager.Add(t)
+	return t, nil
+}
+
+type moveEntry struct {
+	obj    model.Obj
+	srcDir string // actual path of the parent dir in the src storage
+	dstDir string // actual path of the parent dir in the dst storage
+}
+
+func moveBetween2Storages(t *MoveTask) error {
+	srcStorage, srcObjActualPath, err := op.GetStorageAndActualPath(t.SrcObjPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(t.DstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	t.Status = "getting src object"
+	srcObj, err := op.Get(t.Ctx(), srcStorage, srcObjActualPath)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get src [%s] file", t.SrcObjPath)
+	}
+	t.Status = "collecting src objects"
+	entries := []moveEntry{{obj: srcObj, srcDir: stdpath.Dir(srcObjActualPath), dstDir: dstDirActualPath}}
+	var totalSize int64
+	for i := 0; i < len(entries); i++ {
+		if utils.IsCanceled(t.Ctx()) {
+			return t.Ctx().Err()
+		}
+		e := entries[i]
+		if !e.obj.IsDir() {
+			totalSize += e.obj.GetSize()
+			continue
+		}
+		srcDirPath := stdpath.Join(e.srcDir, e.obj.GetName())
+		objs, err := op.List(t.Ctx(), srcStorage, srcDirPath, model.ListArgs{}, true)
+		if err != nil {
+			return errors.WithMessagef(err, "failed list src [%s] objs", srcDirPath)
+		}
+		for _, obj := range objs {
+			entries = append(entries, moveEntry{obj: obj, srcDir: srcDirPath, dstDir: stdpath.Join(e.dstDir, e.obj.GetName())})
+		}
+	}
+	var movedSize int64
+	for _, e := range entries {
+		if utils.IsCanceled(t.Ctx()) {
+			return t.Ctx().Err()
+		}
+		if e.obj.IsDir() {
+			dstPath := stdpath.Join(e.dstDir, e.obj.GetName())
+			t.Status = fmt.Sprintf("making dir [%s]", dstPath)
+			if err = op.MakeDir(t.Ctx(), dstStorage, dstPath); err != nil {
+				return errors.WithMessagef(err, "failed make dst dir [%s]", dstPath)
+			}
+			continue
+		}
+		srcFilePath := stdpath.Join(e.srcDir, e.obj.GetName())
+		t.Status = fmt.Sprintf("copying [%s]", srcFilePath)
+		size, base := e.obj.GetSize(), movedSize
+		dstName, err := copyFileBetween2Storages(t.Ctx(), srcStorage, dstStorage, srcFilePath, e.dstDir, t.Conflict, func(p float64) {
+			if totalSize > 0 {
+				t.SetProgress((float64(base) + float64(size)*p/100) / float64(totalSize) * 100)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		t.Status = fmt.Sprintf("verifying [%s]", srcFilePath)
+		if err = verifyCopiedFile(t.Ctx(), srcStorage, srcFilePath, e.obj, dstStorage, stdpath.Join(e.dstDir, dstName)); err != nil {
+			return err
+		}
+		movedSize += size
+	}
+	t.Status = "removing src object"
+	if err = op.Remove(t.Ctx(), srcStorage, srcObjActualPath); err != nil {
+		return errors.WithMessagef(err, "copied to dst, but failed remove src [%s]", t.SrcObjPath)
+	}
+	t.Status = "moved"
+	t.SetProgress(100)
+	return nil
+}
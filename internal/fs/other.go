@@ -6,6 +6,8 @@ import (
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/tache"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
 )
 
@@ -17,19 +19,24 @@ func makeDir(ctx context.Context, path string, lazyCache ...bool) error {
 	return op.MakeDir(ctx, storage, actualPath, lazyCache...)
 }
 
-func move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) error {
+// move if in the same storage, call move method
+// if not, add move task which copies and then removes the src
+func move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) (tache.TaskWithInfo, error) {
 	srcStorage, srcActualPath, err := op.GetStorageAndActualPath(srcPath)
 	if err != nil {
-		return errors.WithMessage(err, "failed get src storage")
+		return nil, errors.WithMessage(err, "failed get src storage")
 	}
 	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
 	if err != nil {
-		return errors.WithMessage(err, "failed get dst storage")
+		return nil, errors.WithMessage(err, "failed get dst storage")
 	}
 	if srcStorage.GetStorage() != dstStorage.GetStorage() {
-		return errors.WithStack(errs.MoveBetweenTwoStorages)
+		if dstStorage.Config().NoUpload {
+			return nil, errors.WithStack(errs.UploadNotSupported)
+		}
+		return moveAsTask(ctx, srcStorage, dstStorage, utils.FixAndCleanPath(srcPath), utils.FixAndCleanPath(dstDirPath))
 	}
-	return op.Move(ctx, srcStorage, srcActualPath, dstDirActualPath, lazyCache...)
+	return nil, op.Move(ctx, srcStorage, srcActualPath, dstDirActualPath, lazyCache...)
 }
 
 func rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) error {
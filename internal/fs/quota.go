@@ -0,0 +1,61 @@
+package fs
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	log "github.com/sirupsen/logrus"
+)
+
+// ReconcileUsage recomputes the usage of the users and the paths with a
+// quota by walking their paths, the usage of a user is the bytes stored in
+// the base path of the user
+func ReconcileUsage(ctx context.Context) {
+	users, err := db.GetUsersWithQuota()
+	if err != nil {
+		log.Errorf("failed get users with quota: %+v", err)
+		return
+	}
+	for _, user := range users {
+		used, err := walkUsage(ctx, user.BasePath)
+		if err != nil {
+			log.Errorf("failed walk usage of user %s: %+v", user.Username, err)
+			continue
+		}
+		if err = db.SetUserUsage(user.ID, used); err != nil {
+			log.Errorf("failed set usage of user %s: %+v", user.Username, err)
+		}
+	}
+	quotas, err := op.GetMetaQuotas()
+	if err != nil {
+		log.Errorf("failed get quotas: %+v", err)
+		return
+	}
+	for path := range quotas {
+		used, err := walkUsage(ctx, path)
+		if err != nil {
+			log.Errorf("failed walk usage of %s: %+v", path, err)
+			continue
+		}
+		if err = db.SetUsage(path, used); err != nil {
+			log.Errorf("failed set usage of %s: %+v", path, err)
+		}
+	}
+}
+
+func walkUsage(ctx context.Context, path string) (int64, error) {
+	obj, err := Get(ctx, path, &GetArgs{NoLog: true})
+	if err != nil {
+		return 0, err
+	}
+	var used int64
+	err = WalkFS(ctx, -1, path, obj, func(reqPath string, info model.Obj) error {
+		if !info.IsDir() {
+			used += info.GetSize()
+		}
+		return nil
+	})
+	return used, err
+}
@@ -0,0 +1,125 @@
+package fs
+
+import (
+	"context"
+	"net/http"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// verifyHashTypes are the hash types that can be computed by reading the file,
+// the driver-specific ones may need params that only the driver knows
+var verifyHashTypes = []*utils.HashType{utils.MD5, utils.SHA1, utils.SHA256}
+
+// errCopyMismatch is wrapped by the errors of a dst differing from the src
+var errCopyMismatch = errors.New("the copied file differs from the src")
+
+// verifyCopiedFile checks that dstPath is a complete copy of srcObj, the dst
+// differing from the src is removed, so that the retry doesn't keep it by the
+// skip policy or rename the new copy beside it
+func verifyCopiedFile(ctx context.Context, srcStorage driver.Driver, srcPath string, srcObj model.Obj, dstStorage driver.Driver, dstPath string) error {
+	err := compareCopiedFile(ctx, srcStorage, srcPath, srcObj, dstStorage, dstPath)
+	if errors.Is(err, errCopyMismatch) {
+		if rerr := op.Remove(ctx, dstStorage, dstPath); rerr != nil {
+			return errors.WithMessagef(err, "failed remove the dst: %v", rerr)
+		}
+	}
+	return err
+}
+
+// compareCopiedFile compares dstPath with srcObj. The size is always compared,
+// if the verify_copy setting is on the hashes are compared too, the files are
+// read and hashed if they have no hash type in common.
+func compareCopiedFile(ctx context.Context, srcStorage driver.Driver, srcPath string, srcObj model.Obj, dstStorage driver.Driver, dstPath string) error {
+	dstObj, err := getCopiedFile(ctx, dstStorage, dstPath, srcObj.GetSize())
+	if err != nil {
+		return errors.WithMessagef(err, "failed get dst [%s] file", dstPath)
+	}
+	if dstObj.GetSize() != srcObj.GetSize() {
+		return errors.WithMessagef(errCopyMismatch, "size of dst [%s] is %d, but src is %d", dstPath, dstObj.GetSize(), srcObj.GetSize())
+	}
+	if !setting.GetBool(conf.VerifyCopy) {
+		return nil
+	}
+	srcHash, dstHash := srcObj.GetHash(), dstObj.GetHash()
+	if equal, ok := utils.CompareHashInfo(srcHash, dstHash); ok {
+		if !equal {
+			return errors.WithMessagef(errCopyMismatch, "hash of dst [%s] is %s, but src is %s", dstPath, dstHash, srcHash)
+		}
+		return nil
+	}
+	// hash the side missing the hash that the other side has, or both sides
+	ht, srcHas, dstHas := utils.MD5, false, false
+	for _, t := range verifyHashTypes {
+		if srcHash.GetHash(t) != "" || dstHash.GetHash(t) != "" {
+			ht, srcHas, dstHas = t, srcHash.GetHash(t) != "", dstHash.GetHash(t) != ""
+			break
+		}
+	}
+	if !srcHas {
+		if srcHash, err = hashFile(ctx, srcStorage, srcPath, srcObj, ht); err != nil {
+			return errors.WithMessagef(err, "failed hash src [%s]", srcPath)
+		}
+	}
+	if !dstHas {
+		if dstHash, err = hashFile(ctx, dstStorage, dstPath, dstObj, ht); err != nil {
+			return errors.WithMessagef(err, "failed hash dst [%s]", dstPath)
+		}
+	}
+	if equal, _ := utils.CompareHashInfo(srcHash, dstHash); !equal {
+		return errors.WithMessagef(errCopyMismatch, "%s of dst [%s] is %s, but src is %s", ht.Name, dstPath, dstHash.GetHash(ht), srcHash.GetHash(ht))
+	}
+	return nil
+}
+
+// getCopiedFile gets the file just put, the list of the dir is refreshed if
+// the cached one is outdated since the put may not update the cache
+func getCopiedFile(ctx context.Context, storage driver.Driver, path string, size int64) (model.Obj, error) {
+	obj, err := op.Get(ctx, storage, path)
+	if err == nil && obj.GetSize() == size {
+		return obj, nil
+	}
+	if err != nil && !errs.IsObjectNotFound(err) {
+		return nil, err
+	}
+	dir, name := stdpath.Split(path)
+	objs, err := op.List(ctx, storage, dir, model.ListArgs{}, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objs {
+		if obj.GetName() == name {
+			return obj, nil
+		}
+	}
+	return nil, errors.WithStack(errs.ObjectNotFound)
+}
+
+// hashFile reads the file through its link and computes the hashes of the types
+func hashFile(ctx context.Context, storage driver.Driver, path string, obj model.Obj, types ...*utils.HashType) (utils.HashInfo, error) {
+	link, _, err := op.Link(ctx, storage, path, model.LinkArgs{
+		Header: http.Header{},
+	})
+	if err != nil {
+		return utils.HashInfo{}, errors.WithMessagef(err, "failed get [%s] link", path)
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return utils.HashInfo{}, errors.WithMessagef(err, "failed get [%s] stream", path)
+	}
+	defer ss.Close()
+	h := utils.NewMultiHasher(types)
+	if _, err = utils.CopyWithBuffer(h, ss); err != nil {
+		return utils.HashInfo{}, errors.WithStack(err)
+	}
+	return *h.GetHashInfo(), nil
+}
@@ -0,0 +1,4 @@
+package fuse
+
+// DefaultReadAhead is the default size of the read-ahead buffer
+const DefaultReadAhead = 4 * 1024 * 1024
@@ -1,170 +1,375 @@
-package fuse
+//go:build fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+package fuse
 
+import (
+	"context"
+	"errors"
+	stdpath "path"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	log "github.com/sirupsen/logrus"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+// Fs exposes the alist virtual file system below RootFolder through FUSE.
+// All paths received from the kernel are relative to the mount point.
 type Fs struct {
 	RootFolder string
+	// ReadAhead is the buffer size used for sequential reads of remote files
+	ReadAhead int
 	fuse.FileSystemBase
-}
-
-func (fs *Fs) Init() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Destroy() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mknod(path string, mode uint32, dev uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mkdir(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Unlink(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rmdir(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Link(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Symlink(target string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readlink(path string) (int, string) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rename(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chmod(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chown(path string, uid uint32, gid uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Access(path string, mask uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Open(path string, flags int) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Truncate(path string, size int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Flush(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Release(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsync(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Opendir(path string) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Releasedir(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsyncdir(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Setxattr(path string, name string, value []byte, flags int) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getxattr(path string, name string) (int, []byte) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Removexattr(path string, name string) int {
-	//TODO implement me
-	panic("implement me")
-}
 
-func (fs *Fs) Listxattr(path string, fill func(name string) bool) int {
-	//TODO implement me
-	panic("implement me")
+	ctx     context.Context
+	mu      sync.Mutex
+	handles map[uint64]*handle
+	nextFh  uint64
+}
+
+func (f *Fs) Init() {
+	f.ctx = context.Background()
+	f.handles = make(map[uint64]*handle)
+	if f.ReadAhead <= 0 {
+		f.ReadAhead = DefaultReadAhead
+	}
+}
+
+func (f *Fs) Destroy() {
+	f.mu.Lock()
+	handles := f.handles
+	f.handles = make(map[uint64]*handle)
+	f.mu.Unlock()
+	for _, h := range handles {
+		_ = h.release()
+	}
+}
+
+func (f *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
+	*stat = fuse.Statfs_t{
+		Bsize:   blockSize,
+		Frsize:  blockSize,
+		Namemax: 255,
+	}
+	return 0
+}
+
+func (f *Fs) Mkdir(path string, mode uint32) int {
+	return errno(fs.MakeDir(f.ctx, f.realPath(path)))
+}
+
+func (f *Fs) Unlink(path string) int {
+	return errno(fs.Remove(f.ctx, f.realPath(path)))
+}
+
+func (f *Fs) Rmdir(path string) int {
+	objs, err := fs.List(f.ctx, f.realPath(path), &fs.ListArgs{NoLog: true})
+	if err != nil {
+		return errno(err)
+	}
+	if len(objs) > 0 {
+		return -fuse.ENOTEMPTY
+	}
+	return errno(fs.Remove(f.ctx, f.realPath(path)))
+}
+
+// Rename works like the webdav MOVE method: a rename inside the same
+// directory maps to fs.Rename, otherwise the object is moved first.
+func (f *Fs) Rename(oldpath string, newpath string) int {
+	src, dst := f.realPath(oldpath), f.realPath(newpath)
+	dstDir, dstName := stdpath.Dir(dst), stdpath.Base(dst)
+	dstObj, err := fs.Get(f.ctx, dst, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return errno(f.rename(src, dst))
+	}
+	if dstObj.IsDir() {
+		return -fuse.EEXIST
+	}
+	// rename(2) replaces an existing file, editors rely on it when saving.
+	// The old file is put aside first and only dropped once the rename
+	// succeeded, so a failed move doesn't lose it.
+	backupName := "." + dstName + ".old-" + random.String(8)
+	if err := fs.Rename(f.ctx, dst, backupName); err != nil {
+		return errno(err)
+	}
+	backup := stdpath.Join(dstDir, backupName)
+	if err := f.rename(src, dst); err != nil {
+		if rerr := fs.Rename(f.ctx, backup, dstName); rerr != nil {
+			log.Errorf("failed to restore %s after failed rename: %+v", dst, rerr)
+		}
+		return errno(err)
+	}
+	if err := fs.Remove(f.ctx, backup); err != nil {
+		log.Warnf("failed to remove replaced file %s: %+v", backup, err)
+	}
+	return 0
+}
+
+func (f *Fs) rename(src, dst string) error {
+	srcDir, dstDir := stdpath.Dir(src), stdpath.Dir(dst)
+	srcName, dstName := stdpath.Base(src), stdpath.Base(dst)
+	if srcDir == dstDir {
+		return fs.Rename(f.ctx, src, dstName)
+	}
+	// moving between storages copies the file, wait for it instead of adding a task
+	if _, err := fs.Move(context.WithValue(f.ctx, conf.NoTaskKey, struct{}{}), src, dstDir); err != nil {
+		return err
+	}
+	if srcName != dstName {
+		return fs.Rename(f.ctx, stdpath.Join(dstDir, srcName), dstName)
+	}
+	return nil
+}
+
+// Chmod, Chown and Utimens are accepted but ignored, so that tools like
+// `cp -p` or `touch` don't fail on a mounted storage.
+func (f *Fs) Chmod(path string, mode uint32) int {
+	return 0
+}
+
+func (f *Fs) Chown(path string, uid uint32, gid uint32) int {
+	return 0
+}
+
+func (f *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
+	return 0
+}
+
+func (f *Fs) Access(path string, mask uint32) int {
+	return 0
+}
+
+func (f *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
+	h, err := newWriteHandle(f, path, nil)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	// an empty file must be uploaded even if nothing is written
+	h.dirty = true
+	return 0, f.addHandle(h)
+}
+
+func (f *Fs) Open(path string, flags int) (int, uint64) {
+	obj, err := fs.Get(f.ctx, f.realPath(path), &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if obj.IsDir() {
+		return -fuse.EISDIR, ^uint64(0)
+	}
+	if flags&fuse.O_ACCMODE == fuse.O_RDONLY {
+		return 0, f.addHandle(newReadHandle(f, path, obj))
+	}
+	if flags&fuse.O_TRUNC != 0 {
+		obj = nil
+	}
+	h, err := newWriteHandle(f, path, obj)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	return 0, f.addHandle(h)
+}
+
+func (f *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		// the kernel may stat a newly created file before it's uploaded
+		h = f.getWriteHandle(path)
+	}
+	if h != nil && h.writer != nil {
+		size, err := h.size()
+		if err != nil {
+			return errno(err)
+		}
+		fillStat(stat, h.obj)
+		stat.Size = size
+		return 0
+	}
+	obj, err := fs.Get(f.ctx, f.realPath(path), &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return errno(err)
+	}
+	fillStat(stat, obj)
+	return 0
+}
+
+func (f *Fs) Truncate(path string, size int64, fh uint64) int {
+	if h := f.getHandle(fh); h != nil {
+		return errno(h.truncate(size))
+	}
+	obj, err := fs.Get(f.ctx, f.realPath(path), &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return errno(err)
+	}
+	if size == 0 {
+		obj = nil
+	}
+	h, err := newWriteHandle(f, path, obj)
+	if err != nil {
+		return errno(err)
+	}
+	if err = h.truncate(size); err == nil {
+		err = h.flush()
+	}
+	return errno(errors.Join(err, h.release()))
+}
+
+func (f *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	n, err := h.readAt(buff, ofst)
+	if err != nil {
+		return errno(err)
+	}
+	return n
+}
+
+func (f *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	n, err := h.writeAt(buff, ofst)
+	if err != nil {
+		return errno(err)
+	}
+	return n
+}
+
+func (f *Fs) Flush(path string, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	return errno(h.flush())
+}
+
+func (f *Fs) Release(path string, fh uint64) int {
+	f.mu.Lock()
+	h, ok := f.handles[fh]
+	delete(f.handles, fh)
+	f.mu.Unlock()
+	if !ok {
+		return -fuse.EBADF
+	}
+	return errno(errors.Join(h.flush(), h.release()))
+}
+
+func (f *Fs) Fsync(path string, datasync bool, fh uint64) int {
+	return f.Flush(path, fh)
+}
+
+func (f *Fs) Opendir(path string) (int, uint64) {
+	obj, err := fs.Get(f.ctx, f.realPath(path), &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if !obj.IsDir() {
+		return -fuse.ENOTDIR, ^uint64(0)
+	}
+	return 0, 0
+}
+
+func (f *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
+	objs, err := fs.List(f.ctx, f.realPath(path), &fs.ListArgs{})
+	if err != nil {
+		return errno(err)
+	}
+	fill(".", nil, 0)
+	fill("..", nil, 0)
+	for _, obj := range objs {
+		stat := &fuse.Stat_t{}
+		fillStat(stat, obj)
+		if !fill(obj.GetName(), stat, 0) {
+			break
+		}
+	}
+	return 0
+}
+
+func (f *Fs) realPath(path string) string {
+	return utils.FixAndCleanPath(stdpath.Join(f.RootFolder, path))
+}
+
+func (f *Fs) addHandle(h *handle) uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextFh++
+	f.handles[f.nextFh] = h
+	return f.nextFh
+}
+
+func (f *Fs) getHandle(fh uint64) *handle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.handles[fh]
+}
+
+func (f *Fs) getWriteHandle(path string) *handle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, h := range f.handles {
+		if h.path == path && h.writer != nil {
+			return h
+		}
+	}
+	return nil
+}
+
+const blockSize = 4096
+
+func fillStat(stat *fuse.Stat_t, obj model.Obj) {
+	*stat = fuse.Stat_t{}
+	if obj == nil {
+		stat.Mode = fuse.S_IFREG | 0644
+		stat.Nlink = 1
+		now := fuse.Now()
+		stat.Mtim, stat.Ctim, stat.Atim, stat.Birthtim = now, now, now, now
+		return
+	}
+	if obj.IsDir() {
+		stat.Mode = fuse.S_IFDIR | 0755
+		stat.Nlink = 2
+	} else {
+		stat.Mode = fuse.S_IFREG | 0644
+		stat.Nlink = 1
+		stat.Size = obj.GetSize()
+		stat.Blocks = (stat.Size + 511) / 512
+	}
+	stat.Blksize = blockSize
+	stat.Mtim = fuse.NewTimespec(obj.ModTime())
+	stat.Atim = stat.Mtim
+	stat.Ctim = stat.Mtim
+	stat.Birthtim = fuse.NewTimespec(obj.CreateTime())
+}
+
+// errno converts an error of the fs/op layer to a negative errno value
+func errno(err error) int {
+	if err == nil {
+		return 0
+	}
+	log.Debugf("fuse: %+v", err)
+	switch {
+	case errs.IsNotFoundError(err):
+		return -fuse.ENOENT
+	case errs.IsNotSupportError(err), errors.Is(err, errs.NotImplement):
+		return -fuse.ENOSYS
+	case errors.Is(err, errs.UploadNotSupported):
+		return -fuse.EROFS
+	case errors.Is(err, errs.NotFolder):
+		return -fuse.ENOTDIR
+	case errors.Is(err, errs.NotFile):
+		return -fuse.EISDIR
+	default:
+		return -fuse.EIO
+	}
 }
 
 var _ fuse.FileSystemInterface = (*Fs)(nil)
@@ -0,0 +1,189 @@
+//go:build fuse
+
+package fuse
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// handle is an opened file. A handle opened for reading streams the
+// content from the storage link, a handle opened for writing buffers the
+// content in a temp file which is uploaded on flush.
+type handle struct {
+	fs   *Fs
+	path string
+	obj  model.Obj
+	mu   sync.Mutex
+
+	// read side
+	lr *stream.LinkReader
+
+	// write side
+	writer *os.File
+	dirty  bool
+}
+
+func newReadHandle(f *Fs, path string, obj model.Obj) *handle {
+	return &handle{fs: f, path: path, obj: obj}
+}
+
+// newWriteHandle creates a handle backed by a temp file, if obj is not nil
+// its current content is downloaded first so that it can be partially modified.
+func newWriteHandle(f *Fs, path string, obj model.Obj) (*handle, error) {
+	tmp, err := os.CreateTemp(conf.Conf.TempDir, "fuse-*")
+	if err != nil {
+		return nil, err
+	}
+	h := &handle{fs: f, path: path, obj: obj, writer: tmp}
+	if obj == nil || obj.GetSize() == 0 {
+		return h, nil
+	}
+	lr, err := h.linkReader()
+	var rc io.ReadCloser
+	if err == nil {
+		rc, err = lr.RangeRead(0)
+	}
+	if err == nil {
+		_, err = utils.CopyWithBuffer(tmp, rc)
+		_ = rc.Close()
+	}
+	if err != nil {
+		_ = h.release()
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *handle) readAt(buff []byte, ofst int64) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.writer != nil {
+		n, err := h.writer.ReadAt(buff, ofst)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		return n, nil
+	}
+	if ofst >= h.obj.GetSize() {
+		return 0, nil
+	}
+	lr, err := h.linkReader()
+	if err != nil {
+		return 0, err
+	}
+	n, err := lr.ReadAt(buff, ofst)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, err
+	}
+	return n, nil
+}
+
+// linkReader gets the link of the file on the first read
+func (h *handle) linkReader() (*stream.LinkReader, error) {
+	if h.lr == nil {
+		link, _, err := fs.Link(h.fs.ctx, h.fs.realPath(h.path), model.LinkArgs{
+			Header: http.Header{},
+		})
+		if err != nil {
+			return nil, err
+		}
+		h.lr = stream.NewLinkReader(h.fs.ctx, link, h.obj.GetSize(), h.fs.ReadAhead)
+	}
+	return h.lr, nil
+}
+
+func (h *handle) writeAt(buff []byte, ofst int64) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.writer == nil {
+		return 0, errors.New("file is not opened for writing")
+	}
+	h.dirty = true
+	return h.writer.WriteAt(buff, ofst)
+}
+
+func (h *handle) truncate(size int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.writer == nil {
+		return errors.New("file is not opened for writing")
+	}
+	h.dirty = true
+	return h.writer.Truncate(size)
+}
+
+func (h *handle) size() (int64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	info, err := h.writer.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// flush uploads the temp file if it has been modified since the last flush
+func (h *handle) flush() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.writer == nil || !h.dirty {
+		return nil
+	}
+	info, err := h.writer.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err = h.writer.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	realPath := h.fs.realPath(h.path)
+	obj := &model.Object{
+		Name:     stdpath.Base(realPath),
+		Size:     info.Size(),
+		Modified: time.Now(),
+	}
+	if h.obj != nil {
+		obj.Ctime = h.obj.CreateTime()
+	}
+	s := &stream.FileStream{
+		Ctx:      h.fs.ctx,
+		Obj:      obj,
+		Reader:   h.writer,
+		Mimetype: utils.GetMimeType(obj.Name),
+	}
+	err = fs.PutDirectly(h.fs.ctx, stdpath.Dir(realPath), s)
+	_ = s.Close()
+	if err != nil {
+		return err
+	}
+	h.obj = obj
+	h.dirty = false
+	return nil
+}
+
+func (h *handle) release() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var err error
+	if h.lr != nil {
+		err = h.lr.Close()
+		h.lr = nil
+	}
+	if h.writer != nil {
+		err = errors.Join(err, h.writer.Close(), os.Remove(h.writer.Name()))
+		h.writer = nil
+	}
+	return err
+}
@@ -1,9 +1,32 @@
+//go:build fuse
+
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+import (
+	"context"
+	"fmt"
+
+	"github.com/winfsp/cgofuse/fuse"
+)
 
-func Mount(mountSrc, mountDst string, opts []string) {
-	fs := &Fs{RootFolder: mountSrc}
+// Mount mounts mountSrc of the virtual file system at mountDst and blocks
+// until ctx is done or the file system is unmounted externally.
+func Mount(ctx context.Context, mountSrc, mountDst string, readAhead int, opts []string) error {
+	fs := &Fs{RootFolder: mountSrc, ReadAhead: readAhead}
 	host := fuse.NewFileSystemHost(fs)
-	go host.Mount(mountDst, opts)
+	done := make(chan bool, 1)
+	go func() {
+		done <- host.Mount(mountDst, opts)
+	}()
+	select {
+	case ok := <-done:
+		if !ok {
+			return fmt.Errorf("failed to mount %s at %s", mountSrc, mountDst)
+		}
+		return nil
+	case <-ctx.Done():
+		host.Unmount()
+		<-done
+		return nil
+	}
 }
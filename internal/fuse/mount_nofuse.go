@@ -0,0 +1,15 @@
+//go:build !fuse
+
+package fuse
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+)
+
+// Mount is only available when alist is built with `-tags fuse`,
+// since it requires cgo and the libfuse headers.
+func Mount(ctx context.Context, mountSrc, mountDst string, readAhead int, opts []string) error {
+	return errs.NewErr(errs.NotSupport, "alist is built without fuse support, rebuild it with `-tags fuse`")
+}
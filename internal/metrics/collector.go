@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/tache"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var stateNames = map[tache.State]string{
+	tache.StatePending:      "pending",
+	tache.StateRunning:      "running",
+	tache.StateSucceeded:    "succeeded",
+	tache.StateCanceling:    "canceling",
+	tache.StateCanceled:     "canceled",
+	tache.StateErrored:      "errored",
+	tache.StateFailing:      "failing",
+	tache.StateFailed:       "failed",
+	tache.StateWaitingRetry: "waiting_retry",
+	tache.StateBeforeRetry:  "before_retry",
+}
+
+var (
+	tasksDesc = prometheus.NewDesc(namespace+"_tasks",
+		"Count of the tasks by manager and state.", []string{"manager", "state"}, nil)
+	queueDepthDesc = prometheus.NewDesc(namespace+"_task_queue_depth",
+		"Count of the pending tasks by manager.", []string{"manager"}, nil)
+	indexObjsDesc = prometheus.NewDesc(namespace+"_search_index_objects",
+		"Count of the objects in the search index.", nil, nil)
+	indexDoneDesc = prometheus.NewDesc(namespace+"_search_index_done",
+		"Whether the building of the search index is done.", nil, nil)
+	indexLastDoneDesc = prometheus.NewDesc(namespace+"_search_index_last_done_timestamp_seconds",
+		"Unix time of the last done building of the search index.", nil, nil)
+)
+
+// collector reads the states of the tasks and the index progress at scrape time
+type collector struct {
+	mu            sync.RWMutex
+	taskManagers  map[string]func() map[tache.State]int
+	indexProgress func() (*model.IndexProgress, error)
+}
+
+var c = &collector{taskManagers: map[string]func() map[tache.State]int{}}
+
+func init() {
+	prometheus.MustRegister(c)
+}
+
+// RegisterTaskManager exposes the count of the tasks of the manager by state
+func RegisterTaskManager[T tache.TaskWithInfo](name string, m *tache.Manager[T]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.taskManagers[name] = func() map[tache.State]int {
+		states := map[tache.State]int{}
+		for _, t := range m.GetAll() {
+			states[t.GetState()]++
+		}
+		return states
+	}
+}
+
+// RegisterIndexProgress exposes the progress of the search index
+func RegisterIndexProgress(f func() (*model.IndexProgress, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.indexProgress = f
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tasksDesc
+	ch <- queueDepthDesc
+	ch <- indexObjsDesc
+	ch <- indexDoneDesc
+	ch <- indexLastDoneDesc
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for name, getStates := range c.taskManagers {
+		states := getStates()
+		for state, stateName := range stateNames {
+			ch <- prometheus.MustNewConstMetric(tasksDesc, prometheus.GaugeValue, float64(states[state]), name, stateName)
+		}
+		ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(states[tache.StatePending]), name)
+	}
+	if c.indexProgress == nil {
+		return
+	}
+	progress, err := c.indexProgress()
+	if err != nil {
+		log.Warnf("failed get index progress for metrics: %+v", err)
+		return
+	}
+	done := 0.0
+	if progress.IsDone {
+		done = 1
+	}
+	ch <- prometheus.MustNewConstMetric(indexObjsDesc, prometheus.GaugeValue, float64(progress.ObjCount))
+	ch <- prometheus.MustNewConstMetric(indexDoneDesc, prometheus.GaugeValue, done)
+	if progress.LastDoneTime != nil {
+		ch <- prometheus.MustNewConstMetric(indexLastDoneDesc, prometheus.GaugeValue, float64(progress.LastDoneTime.Unix()))
+	}
+}
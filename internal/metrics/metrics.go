@@ -0,0 +1,77 @@
+// Package metrics defines the prometheus metrics of alist, the metrics
+// which are read at scrape time are registered by the owners of the data
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "alist"
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Count of the http requests by route, method and status code.",
+	}, []string{"route", "method", "code"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the http requests by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+	bytesServed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "served_bytes_total",
+		Help:      "Bytes of the files served by the download and proxy routes.",
+	}, []string{"route"})
+	driverCalls = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "driver_call_duration_seconds",
+		Help:      "Latency of the calls to the storage drivers, the count is the number of calls.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"storage", "driver", "method"})
+	driverErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "driver_call_errors_total",
+		Help:      "Count of the failed calls to the storage drivers.",
+	}, []string{"storage", "driver", "method"})
+	listCache = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "list_cache_requests_total",
+		Help:      "Count of the lookups of the list cache by result, hit or miss.",
+	}, []string{"result"})
+)
+
+func init() {
+	prometheus.MustRegister(httpRequests, httpDuration, bytesServed, driverCalls, driverErrors, listCache)
+}
+
+func ObserveRequest(route, method string, code int, duration time.Duration) {
+	httpRequests.WithLabelValues(route, method, strconv.Itoa(code)).Inc()
+	httpDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+func AddBytesServed(route string, n int64) {
+	if n > 0 {
+		bytesServed.WithLabelValues(route).Add(float64(n))
+	}
+}
+
+func ObserveDriverCall(storage, driver, method string, duration time.Duration, err error) {
+	driverCalls.WithLabelValues(storage, driver, method).Observe(duration.Seconds())
+	if err != nil {
+		driverErrors.WithLabelValues(storage, driver, method).Inc()
+	}
+}
+
+func ObserveListCache(hit bool) {
+	if hit {
+		listCache.WithLabelValues("hit").Inc()
+	} else {
+		listCache.WithLabelValues("miss").Inc()
+	}
+}
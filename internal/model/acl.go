@@ -0,0 +1,44 @@
+package model
+
+// rights of the acl entry
+const (
+	ACLRead = 1 << iota
+	ACLList
+	ACLWrite
+	ACLDelete
+	ACLShare
+	ACLOffline
+)
+
+type Group struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"unique" binding:"required"`
+	Description string `json:"description"`
+}
+
+// UserGroup is the membership of the user in the group
+type UserGroup struct {
+	UserID  uint `json:"user_id" gorm:"primaryKey"`
+	GroupID uint `json:"group_id" gorm:"primaryKey"`
+}
+
+// ACL binds the rights of the path prefix to a group or a user, the entry
+// with neither of them applies to all users, the admin is not restricted
+type ACL struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	Path    string `json:"path" binding:"required"`
+	GroupID uint   `json:"group_id" gorm:"index"`
+	UserID  uint   `json:"user_id" gorm:"index"`
+	// Determine rights by bit
+	//   0: read
+	//   1: list
+	//   2: write
+	//   3: delete
+	//   4: share
+	//   5: add offline download tasks
+	Permission int32 `json:"permission"`
+}
+
+func (a *ACL) Can(right int32) bool {
+	return a.Permission&right == right
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"path"
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+const APITokenPrefix = "alist-at-"
+
+const (
+	APITokenScopeRead  = "read"
+	APITokenScopeWrite = "write"
+	APITokenScopeAdmin = "admin"
+)
+
+const (
+	// the permissions kept by the read scope: see hides, access without password and webdav read
+	readPermissions = 1 | 1<<1 | 1<<8
+	// all permissions of the user table, given to the admin downgraded by the scope
+	allPermissions = 1<<10 - 1
+)
+
+// APIToken is a named credential of the user for the non-interactive clients,
+// it's used as the bearer token, the webdav password and the s3 secret key
+type APIToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	Name       string     `json:"name" binding:"required"`
+	Token      string     `json:"-" gorm:"unique;size:64"`
+	AccessKey  string     `json:"access_key" gorm:"unique;size:32"` // the access key id of s3
+	Scope      string     `json:"scope"`                            // read, write or admin
+	PathPrefix string     `json:"path_prefix"`                      // restricts the token to the path under the base path of the user
+	Expires    *time.Time `json:"expires"`                          // never expires if nil
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (t *APIToken) IsExpired() bool {
+	return t.Expires != nil && time.Now().After(*t.Expires)
+}
+
+func IsValidAPITokenScope(scope string) bool {
+	return scope == APITokenScopeRead || scope == APITokenScopeWrite || scope == APITokenScopeAdmin
+}
+
+// ApplyTo returns a copy of the user restricted by the scope and the path
+// prefix of the token, the copy must not be saved
+func (t *APIToken) ApplyTo(user *User) *User {
+	u := *user
+	if t.PathPrefix != "" {
+		u.BasePath = path.Join(user.BasePath, t.PathPrefix)
+	}
+	u.TokenScope, u.TokenPath = t.Scope, u.BasePath
+	switch t.Scope {
+	case APITokenScopeAdmin:
+		if u.IsAdmin() {
+			return &u
+		}
+		fallthrough
+	case APITokenScopeWrite:
+		if u.IsAdmin() {
+			u.Role = GENERAL
+			u.Permission = allPermissions
+		}
+	default:
+		if u.IsAdmin() {
+			u.Role = GENERAL
+			u.Permission = readPermissions
+		} else {
+			u.Permission &= readPermissions
+		}
+	}
+	return &u
+}
+
+// InTokenScope checks the right at the path against the api token the user is
+// authenticated by, the acl can't grant the rights beyond the token
+func (u *User) InTokenScope(reqPath string, right int32) bool {
+	if u.TokenScope == "" {
+		return true
+	}
+	if !utils.IsSubPath(u.TokenPath, reqPath) {
+		return false
+	}
+	if u.TokenScope == APITokenScopeRead {
+		return right&^(ACLRead|ACLList) == 0
+	}
+	return true
+}
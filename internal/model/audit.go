@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// AuditLog is the record of a file or admin operation, Target is the object
+// operated which is not a path, like the user or the setting keys
+type AuditLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Username  string    `json:"username" gorm:"index"`
+	IP        string    `json:"ip"`
+	Action    string    `json:"action" gorm:"index"`
+	SrcPath   string    `json:"src_path" gorm:"type:text"` // separated by newline if multiple objs are operated
+	DstPath   string    `json:"dst_path"`
+	Storage   string    `json:"storage"` // the mount path of the storage
+	Target    string    `json:"target"`
+	Success   bool      `json:"success"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+type AuditLogFilter struct {
+	Username string    `json:"username" form:"username"`
+	Action   string    `json:"action" form:"action"`
+	Path     string    `json:"path" form:"path"` // matches the prefix of src or dst path
+	Storage  string    `json:"storage" form:"storage"`
+	Success  *bool     `json:"success" form:"success"`
+	Start    time.Time `json:"start" form:"start"`
+	End      time.Time `json:"end" form:"end"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import "github.com/pkg/errors"
+
+// ConflictPolicy decides what to do when a file with the same name
+// already exists in the destination of an upload
+type ConflictPolicy string
+
+const (
+	// ConflictOverwrite replaces the existing file, it's the default policy
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictRename uploads the file with a name like "name (1).ext"
+	ConflictRename ConflictPolicy = "rename"
+	// ConflictSkip keeps the existing file if it has the same size and hash,
+	// or overwrites it otherwise
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictFail fails the upload with errs.ObjectAlreadyExists
+	ConflictFail ConflictPolicy = "fail"
+)
+
+// ParseConflictPolicy parses the policy from a request, empty means overwrite
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case "":
+		return ConflictOverwrite, nil
+	case ConflictOverwrite, ConflictRename, ConflictSkip, ConflictFail:
+		return p, nil
+	}
+	return "", errors.Errorf("invalid conflict policy: %s", s)
+}
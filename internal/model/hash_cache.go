@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// HashCache is the hashes of a file computed by reading it, for the drivers
+// that don't provide them. It's valid while the size and the modified time
+// of the file are unchanged.
+type HashCache struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	StorageID uint      `json:"storage_id" gorm:"index"`
+	Dir       string    `json:"dir" gorm:"index;size:512"` // the actual path of the parent dir in the storage
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	Modified  time.Time `json:"modified"`
+	Hash      string    `json:"hash"` // utils.HashInfo.String()
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Valid checks that obj is still the file the hashes are computed from,
+// the file without the modified time can't be told from a changed one
+func (c *HashCache) Valid(obj Obj) bool {
+	return !obj.ModTime().IsZero() && c.Size == obj.GetSize() && c.Modified.Unix() == obj.ModTime().Unix()
+}
+
+func (c *HashCache) HashInfo() utils.HashInfo {
+	return utils.FromString(c.Hash)
+}
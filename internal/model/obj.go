@@ -44,6 +44,9 @@ type FileStreamer interface {
 	IsForceStreamUpload() bool
 	GetExist() Obj
 	SetExist(Obj)
+	GetConflictPolicy() ConflictPolicy
+	// SetName renames the file to upload, it's used by ConflictRename
+	SetName(string)
 	//for a non-seekable Stream, RangeRead supports peeking some data, and CacheFullInTempFile still works
 	RangeRead(http_range.Range) (io.Reader, error)
 	//for a non-seekable Stream, if Read is called, this function won't work
@@ -22,6 +22,21 @@ func (o *ObjWrapName) GetName() string {
 	return o.Name
 }
 
+// ObjWrapHash adds the hashes from the hash cache to an obj, the hashes
+// provided by the driver are preferred
+type ObjWrapHash struct {
+	Obj
+	Hash utils.HashInfo
+}
+
+func (o *ObjWrapHash) Unwrap() Obj {
+	return UnwrapObj(o.Obj)
+}
+
+func (o *ObjWrapHash) GetHash() utils.HashInfo {
+	return o.Obj.GetHash().Merge(o.Hash)
+}
+
 type Object struct {
 	ID       string
 	Path     string
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// S3Key is a pair of s3 credentials issued to the user, the s3 clients using
+// it have the same permissions as the user
+type S3Key struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	Name       string     `json:"name"`
+	AccessKey  string     `json:"access_key" gorm:"unique;size:32"`
+	SecretKey  string     `json:"-" gorm:"size:64"` // kept in plain text since it's needed to verify the signature
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
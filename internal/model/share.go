@@ -0,0 +1,36 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Share gives access to some paths to anyone with the link, the paths are
+// shown as the children of the root of the share
+type Share struct {
+	ID           string     `json:"id" gorm:"primaryKey;size:32"`
+	CreatorID    uint       `json:"creator_id" gorm:"index"`
+	Creator      string     `json:"creator"`
+	Paths        string     `json:"paths" gorm:"type:text"` // the shared paths, one per line
+	Password     string     `json:"password"`
+	Expires      *time.Time `json:"expires"`       // never expires if nil
+	MaxDownloads int        `json:"max_downloads"` // unlimited if 0
+	Downloads    int        `json:"downloads"`
+	AllowPreview bool       `json:"allow_preview"`
+	AllowUpload  bool       `json:"allow_upload"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (s *Share) GetPaths() []string {
+	var paths []string
+	for _, p := range strings.Split(s.Paths, "\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+func (s *Share) IsExpired() bool {
+	return s.Expires != nil && time.Now().After(*s.Expires)
+}
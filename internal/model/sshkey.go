@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// SSHPublicKey is a public key of the user to log in the sftp server
+type SSHPublicKey struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"index"`
+	Title       string     `json:"title"`
+	Fingerprint string     `json:"fingerprint" gorm:"unique;size:64"`
+	Key         string     `json:"key" gorm:"type:text"` // in the format of authorized_keys
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+}
@@ -14,6 +14,8 @@ type Storage struct {
 	Modified        time.Time `json:"modified"`
 	Disabled        bool      `json:"disabled"` // if disabled
 	EnableSign      bool      `json:"enable_sign"`
+	EnableTrash     bool      `json:"enable_trash"` // removed objects are moved into the trash
+	TrashPath       string    `json:"trash_path"`   // the path of the trash in the storage, /.alist_trash if empty
 	Sort
 	Proxy
 }
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+const (
+	SyncModeMirror        = "mirror"        // make the destination identical to the source
+	SyncModeCopy          = "copy"          // only copy files missing in the destination
+	SyncModeBidirectional = "bidirectional" // copy new and newer files and deletions in both directions
+)
+
+type SyncJob struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name" binding:"required"`
+	SrcPath  string `json:"src_path" binding:"required"`
+	DstPath  string `json:"dst_path" binding:"required"`
+	Mode     string `json:"mode"`
+	Cron     string `json:"cron"`                     // empty means the job only runs manually
+	Include  string `json:"include" gorm:"type:text"` // glob patterns, one per line
+	Exclude  string `json:"exclude" gorm:"type:text"` // glob patterns, one per line
+	Disabled bool   `json:"disabled"`
+}
+
+const (
+	SyncRunRunning   = "running"
+	SyncRunSucceeded = "succeeded"
+	SyncRunFailed    = "failed"
+)
+
+// SyncRun is a history record of a sync job
+type SyncRun struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	JobID      uint      `json:"job_id" gorm:"index"`
+	DryRun     bool      `json:"dry_run"`
+	Status     string    `json:"status"`
+	Copied     int       `json:"copied"`
+	Deleted    int       `json:"deleted"`
+	Failed     int       `json:"failed"`
+	Error      string    `json:"error" gorm:"type:text"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// SyncState keeps the files that were in sync after the last run of a
+// bidirectional job, so a file deleted on one side can be told from a file
+// newly created on the other side
+type SyncState struct {
+	JobID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Files string `gorm:"type:text"` // json of the synced files by the paths relative to the job paths
+}
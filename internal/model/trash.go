@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// TrashItem is an object removed into the trash of its storage, the object
+// is moved into a dir named by the TrashDir in the trash to avoid conflicts
+type TrashItem struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	StorageID  uint      `json:"storage_id" gorm:"index"`
+	Path       string    `json:"path"`      // the original path, including the mount path
+	ActualPath string    `json:"-"`         // the original path in the storage
+	TrashDir   string    `json:"trash_dir"` // the actual path of the dir holding the object
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	IsDir      bool      `json:"is_dir"`
+	Deleter    string    `json:"deleter"`
+	DeletedAt  time.Time `json:"deleted_at" gorm:"index"`
+}
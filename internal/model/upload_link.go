@@ -0,0 +1,38 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// UploadLink lets anyone with the link upload files into the dir without an account
+type UploadLink struct {
+	ID          string     `json:"id" gorm:"primaryKey;size:32"`
+	CreatorID   uint       `json:"creator_id" gorm:"index"`
+	Creator     string     `json:"creator"`
+	Path        string     `json:"path"` // the dir the files are uploaded into
+	Password    string     `json:"password"`
+	Expires     *time.Time `json:"expires"`       // never expires if nil
+	MaxFileSize int64      `json:"max_file_size"` // in bytes, unlimited if 0
+	AllowedExts string     `json:"allowed_exts"`  // separated by comma, any if empty
+	Quota       int64      `json:"quota"`         // total bytes can be uploaded, unlimited if 0
+	Used        int64      `json:"used"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (l *UploadLink) IsExpired() bool {
+	return l.Expires != nil && time.Now().After(*l.Expires)
+}
+
+// IsExtAllowed checks the ext without dot, it's case-insensitive
+func (l *UploadLink) IsExtAllowed(ext string) bool {
+	if strings.TrimSpace(l.AllowedExts) == "" {
+		return true
+	}
+	for _, e := range strings.Split(l.AllowedExts, ",") {
+		if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(e), "."), ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Usage is the bytes stored under the path, it's kept for the paths
+// of metas which have a quota
+type Usage struct {
+	Path      string    `json:"path" gorm:"primaryKey;size:512"`
+	Used      int64     `json:"used"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserUsage is the bytes stored by the user, it's kept for the users
+// which have a quota
+type UserUsage struct {
+	UserID    uint      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Used      int64     `json:"used"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -29,6 +29,7 @@ type User struct {
 	Salt     string `json:"-"`                                         // unique salt
 	Password string `json:"password"`                                  // password
 	BasePath string `json:"base_path"`                                 // base path
+	Quota    int64  `json:"quota"`                                     // max bytes stored in base path, unlimited if 0
 	Role     int    `json:"role"`                                      // user's role
 	Disabled bool   `json:"disabled"`
 	// Determine permissions by bit
@@ -46,6 +47,9 @@ type User struct {
 	OtpSecret  string `json:"-"`
 	SsoID      string `json:"sso_id"` // unique by sso platform
 	Authn      string `gorm:"type:text" json:"-"`
+	// the scope and the path of the api token the user is authenticated by
+	TokenScope string `gorm:"-" json:"-"`
+	TokenPath  string `gorm:"-" json:"-"`
 }
 
 func (u *User) IsGuest() bool {
@@ -27,6 +27,7 @@ type AddURLArgs struct {
 	DstDirPath   string
 	Tool         string
 	DeletePolicy DeletePolicy
+	Conflict     model.ConflictPolicy
 }
 
 func AddURL(ctx context.Context, args *AddURLArgs) (tache.TaskWithInfo, error) {
@@ -72,6 +73,7 @@ func AddURL(ctx context.Context, args *AddURLArgs) (tache.TaskWithInfo, error) {
 		DstDirPath:   args.DstDirPath,
 		TempDir:      tempDir,
 		DeletePolicy: args.DeletePolicy,
+		Conflict:     args.Conflict,
 		tool:         tool,
 	}
 	if tool.Name() == "storage" {
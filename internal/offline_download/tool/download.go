@@ -6,6 +6,7 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/pkg/tache"
@@ -15,11 +16,12 @@ import (
 
 type DownloadTask struct {
 	tache.Base
-	Name         string       `json:"name"`
-	Url          string       `json:"url"`
-	DstDirPath   string       `json:"dst_dir_path"`
-	TempDir      string       `json:"temp_dir"`
-	DeletePolicy DeletePolicy `json:"delete_policy"`
+	Name         string               `json:"name"`
+	Url          string               `json:"url"`
+	DstDirPath   string               `json:"dst_dir_path"`
+	TempDir      string               `json:"temp_dir"`
+	DeletePolicy DeletePolicy         `json:"delete_policy"`
+	Conflict     model.ConflictPolicy `json:"conflict"`
 
 	Status            string   `json:"status"`
 	Signal            chan int `json:"-"`
@@ -154,6 +156,7 @@ func (t *DownloadTask) Complete() error {
 			dstDirPath:   t.DstDirPath,
 			tempDir:      t.TempDir,
 			deletePolicy: t.DeletePolicy,
+			conflict:     t.Conflict,
 		})
 	}
 	return nil
@@ -22,6 +22,7 @@ type TransferTask struct {
 	dstDirPath   string
 	tempDir      string
 	deletePolicy DeletePolicy
+	conflict     model.ConflictPolicy
 }
 
 func (t *TransferTask) Run() error {
@@ -48,6 +49,7 @@ func (t *TransferTask) Run() error {
 		Reader:   rc,
 		Mimetype: mimetype,
 		Closers:  utils.NewClosers(rc),
+		Conflict: t.conflict,
 	}
 	relDir, err := filepath.Rel(t.tempDir, filepath.Dir(t.file.Path))
 	if err != nil {
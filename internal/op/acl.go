@@ -0,0 +1,179 @@
+package op
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// aclCache is the snapshot of all acl entries and memberships, they're
+// small and read by every access check, so that they're loaded at once
+type aclCache struct {
+	acls   []model.ACL     // sorted by the length of path in desc order
+	groups map[uint][]uint // the groups of each user
+}
+
+var aclSnapshot atomic.Pointer[aclCache]
+
+func loadACLCache() (*aclCache, error) {
+	if c := aclSnapshot.Load(); c != nil {
+		return c, nil
+	}
+	acls, err := db.GetAllACLs()
+	if err != nil {
+		return nil, err
+	}
+	ugs, err := db.GetAllUserGroups()
+	if err != nil {
+		return nil, err
+	}
+	c := &aclCache{acls: acls, groups: map[uint][]uint{}}
+	for i := range c.acls {
+		c.acls[i].Path = utils.FixAndCleanPath(c.acls[i].Path)
+	}
+	sort.SliceStable(c.acls, func(i, j int) bool {
+		return len(c.acls[i].Path) > len(c.acls[j].Path)
+	})
+	for _, ug := range ugs {
+		c.groups[ug.UserID] = append(c.groups[ug.UserID], ug.GroupID)
+	}
+	aclSnapshot.Store(c)
+	return c, nil
+}
+
+func clearACLCache() {
+	aclSnapshot.Store(nil)
+}
+
+// the level of the subject of the entry, the higher one overrides the lower
+const (
+	aclForAll = iota + 1
+	aclForGroup
+	aclForUser
+)
+
+func aclLevel(acl *model.ACL, user *model.User, groups []uint) int {
+	switch {
+	case acl.UserID != 0:
+		if user != nil && acl.UserID == user.ID {
+			return aclForUser
+		}
+	case acl.GroupID != 0:
+		if utils.SliceContains(groups, acl.GroupID) {
+			return aclForGroup
+		}
+	default:
+		return aclForAll
+	}
+	return 0
+}
+
+// CheckACL checks the right of the user at the path by the nearest acl
+// entries applying to the user, matched is false if there is none of them,
+// then the caller should fall back to the permission of the user and meta.
+// At the same path, the user entries override the group ones which override
+// the entries for all users, and the rights of the same level are merged.
+// The nil user is the anonymous one that only the entries for all apply to,
+// and the rights beyond the api token of the user are denied before all.
+func CheckACL(user *model.User, path string, right int32) (allowed bool, matched bool) {
+	if user != nil && !user.InTokenScope(path, right) {
+		return false, true
+	}
+	if user != nil && user.IsAdmin() {
+		return true, true
+	}
+	c, err := loadACLCache()
+	if err != nil {
+		log.Errorf("failed load acl: %+v", err)
+		return false, true
+	}
+	path = utils.FixAndCleanPath(path)
+	var groups []uint
+	if user != nil {
+		groups = c.groups[user.ID]
+	}
+	matchedLen, level, perm := -1, 0, int32(0)
+	for i := range c.acls {
+		acl := &c.acls[i]
+		if matchedLen >= 0 && len(acl.Path) < matchedLen {
+			break
+		}
+		if !utils.IsSubPath(acl.Path, path) {
+			continue
+		}
+		l := aclLevel(acl, user, groups)
+		if l == 0 || l < level {
+			continue
+		}
+		if l > level {
+			perm = 0
+		}
+		matchedLen, level, perm = len(acl.Path), l, perm|acl.Permission
+	}
+	if matchedLen < 0 {
+		return false, false
+	}
+	return perm&right == right, true
+}
+
+func GetGroupById(id uint) (*model.Group, error) {
+	return db.GetGroupById(id)
+}
+
+func GetGroups(pageIndex, pageSize int) ([]model.Group, int64, error) {
+	return db.GetGroups(pageIndex, pageSize)
+}
+
+func CreateGroup(g *model.Group) error {
+	return db.CreateGroup(g)
+}
+
+func UpdateGroup(g *model.Group) error {
+	return db.UpdateGroup(g)
+}
+
+func DeleteGroupById(id uint) error {
+	defer clearACLCache()
+	return db.DeleteGroupById(id)
+}
+
+func GetGroupMembers(groupId uint) ([]uint, error) {
+	return db.GetGroupMembers(groupId)
+}
+
+func SetGroupMembers(groupId uint, userIds []uint) error {
+	if _, err := db.GetGroupById(groupId); err != nil {
+		return err
+	}
+	defer clearACLCache()
+	return db.SetGroupMembers(groupId, userIds)
+}
+
+func GetACLById(id uint) (*model.ACL, error) {
+	return db.GetACLById(id)
+}
+
+func GetACLs(pageIndex, pageSize int) ([]model.ACL, int64, error) {
+	return db.GetACLs(pageIndex, pageSize)
+}
+
+func CreateACL(acl *model.ACL) error {
+	acl.Path = utils.FixAndCleanPath(acl.Path)
+	defer clearACLCache()
+	return db.CreateACL(acl)
+}
+
+func UpdateACL(acl *model.ACL) error {
+	acl.Path = utils.FixAndCleanPath(acl.Path)
+	defer clearACLCache()
+	return db.UpdateACL(acl)
+}
+
+func DeleteACLById(id uint) error {
+	defer clearACLCache()
+	return db.DeleteACLById(id)
+}
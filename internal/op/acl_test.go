@@ -0,0 +1,35 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestCheckACLTokenScope(t *testing.T) {
+	user := &model.User{ID: 2001, Username: "scoped", BasePath: "/acl"}
+	if err := op.CreateACL(&model.ACL{Path: "/acl", UserID: user.ID, Permission: model.ACLRead | model.ACLList | model.ACLWrite}); err != nil {
+		t.Fatal(err)
+	}
+	token := &model.APIToken{Scope: model.APITokenScopeRead, PathPrefix: "/sub"}
+	scoped := token.ApplyTo(user)
+	tests := []struct {
+		path    string
+		right   int32
+		allowed bool
+	}{
+		{"/acl/sub/a", model.ACLRead, true},
+		{"/acl/sub/a", model.ACLWrite, false},
+		{"/acl/other", model.ACLRead, false},
+	}
+	for _, tt := range tests {
+		if allowed, _ := op.CheckACL(scoped, tt.path, tt.right); allowed != tt.allowed {
+			t.Errorf("CheckACL(%s, %d) = %v, want %v", tt.path, tt.right, allowed, tt.allowed)
+		}
+	}
+	// the acl still grants the write right without the token
+	if allowed, _ := op.CheckACL(user, "/acl/other", model.ACLWrite); !allowed {
+		t.Errorf("the write right granted by the acl is denied without the token")
+	}
+}
@@ -0,0 +1,125 @@
+package op
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+// the tokens are cached by both the token and the access key
+var apiTokenCache = cache.NewMemCache(cache.WithShards[*model.APIToken](2))
+
+// the last used time is saved at most once per interval to avoid a write per request
+const apiTokenTouchInterval = time.Minute
+
+func CreateAPIToken(user *model.User, t *model.APIToken) error {
+	if !model.IsValidAPITokenScope(t.Scope) {
+		return errors.Errorf("invalid scope: %s", t.Scope)
+	}
+	if t.Scope == model.APITokenScopeAdmin && !user.IsAdmin() {
+		return errors.WithStack(errs.PermissionDenied)
+	}
+	if t.PathPrefix != "" {
+		t.PathPrefix = utils.FixAndCleanPath(t.PathPrefix)
+		if t.PathPrefix == "/" {
+			t.PathPrefix = ""
+		}
+	}
+	t.ID = 0
+	t.UserID = user.ID
+	t.Token = model.APITokenPrefix + random.String(48)
+	t.AccessKey = "AK" + strings.ToUpper(random.String(18))
+	t.LastUsedAt = nil
+	t.CreatedAt = time.Now()
+	return db.CreateAPIToken(t)
+}
+
+func GetAPITokens(userId uint, pageIndex, pageSize int) ([]model.APIToken, int64, error) {
+	return db.GetAPITokens(userId, pageIndex, pageSize)
+}
+
+func GetAPITokenById(id uint) (*model.APIToken, error) {
+	return db.GetAPITokenById(id)
+}
+
+func DeleteAPITokenById(id uint) error {
+	t, err := db.GetAPITokenById(id)
+	if err != nil {
+		return err
+	}
+	apiTokenCache.Del(t.Token)
+	apiTokenCache.Del(t.AccessKey)
+	return db.DeleteAPITokenById(id)
+}
+
+func deleteAPITokensByUser(userId uint) error {
+	apiTokenCache.Clear()
+	return db.DeleteAPITokensByUserId(userId)
+}
+
+func getAPIToken(key string, get func(string) (*model.APIToken, error)) (*model.APIToken, error) {
+	if t, ok := apiTokenCache.Get(key); ok {
+		return t, nil
+	}
+	t, err := get(key)
+	if err != nil {
+		return nil, err
+	}
+	apiTokenCache.Set(key, t, cache.WithEx[*model.APIToken](time.Minute*10))
+	return t, nil
+}
+
+// GetUserByAPIToken validates the token and returns the user restricted by it
+func GetUserByAPIToken(token string) (*model.User, error) {
+	t, err := getAPIToken(token, db.GetAPITokenByToken)
+	if err != nil {
+		return nil, err
+	}
+	return useAPIToken(t)
+}
+
+// GetUserByAccessKey is like GetUserByAPIToken but finds the token by the
+// s3 access key, the token is also returned as the secret key
+func GetUserByAccessKey(accessKey string) (*model.User, *model.APIToken, error) {
+	t, err := getAPIToken(accessKey, db.GetAPITokenByAccessKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := useAPIToken(t)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, t, nil
+}
+
+func useAPIToken(t *model.APIToken) (*model.User, error) {
+	if t.IsExpired() {
+		return nil, errors.New("api token is expired")
+	}
+	user, err := db.GetUserById(t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, errors.New("user of the api token is disabled")
+	}
+	now := time.Now()
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) > apiTokenTouchInterval {
+		// the cached token is shared, so that it's replaced instead of modified
+		touched := *t
+		touched.LastUsedAt = &now
+		apiTokenCache.Set(t.Token, &touched, cache.WithEx[*model.APIToken](time.Minute*10))
+		apiTokenCache.Set(t.AccessKey, &touched, cache.WithEx[*model.APIToken](time.Minute*10))
+		if err = db.UpdateAPITokenLastUsed(t.ID, now); err != nil {
+			return nil, err
+		}
+	}
+	return t.ApplyTo(user), nil
+}
@@ -0,0 +1,27 @@
+package op
+
+import (
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// RecordAudit saves the audit log, the storage is resolved by the first
+// src path if it's not set
+func RecordAudit(l *model.AuditLog) {
+	if l.Storage == "" && l.SrcPath != "" {
+		src, _, _ := strings.Cut(l.SrcPath, "\n")
+		if storage, _, err := GetStorageAndActualPath(src); err == nil {
+			l.Storage = storage.GetStorage().MountPath
+		}
+	}
+	if l.CreatedAt.IsZero() {
+		l.CreatedAt = time.Now()
+	}
+	if err := db.CreateAuditLog(l); err != nil {
+		log.Errorf("failed record audit log of %s: %+v", l.Action, err)
+	}
+}
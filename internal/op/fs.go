@@ -2,12 +2,15 @@ package op
 
 import (
 	"context"
+	"fmt"
 	stdpath "path"
+	"strings"
 	"time"
 
 	"github.com/Xhofe/go-cache"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/generic_sync"
 	"github.com/alist-org/alist/v3/pkg/singleflight"
@@ -22,8 +25,7 @@ var listCache = cache.NewMemCache(cache.WithShards[[]model.Obj](64))
 var listG singleflight.Group[[]model.Obj]
 
 func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj model.Obj) {
-	key := Key(storage, path)
-	objs, ok := listCache.Get(key)
+	objs, ok := getListCache(storage, path)
 	if ok {
 		for i, obj := range objs {
 			if obj.GetName() == oldObj.GetName() {
@@ -31,13 +33,12 @@ func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj
 				break
 			}
 		}
-		listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		setListCache(storage, path, objs)
 	}
 }
 
 func delCacheObj(storage driver.Driver, path string, obj model.Obj) {
-	key := Key(storage, path)
-	objs, ok := listCache.Get(key)
+	objs, ok := getListCache(storage, path)
 	if ok {
 		for i, oldObj := range objs {
 			if oldObj.GetName() == obj.GetName() {
@@ -45,7 +46,7 @@ func delCacheObj(storage driver.Driver, path string, obj model.Obj) {
 				break
 			}
 		}
-		listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		setListCache(storage, path, objs)
 	}
 }
 
@@ -53,11 +54,12 @@ var addSortDebounceMap generic_sync.MapOf[string, func(func())]
 
 func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
 	key := Key(storage, path)
-	objs, ok := listCache.Get(key)
+	objs, ok := getListCache(storage, path)
 	if ok {
 		for i, obj := range objs {
 			if obj.GetName() == newObj.GetName() {
 				objs[i] = newObj
+				setListCache(storage, path, objs)
 				return
 			}
 		}
@@ -79,16 +81,23 @@ func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
 			})
 		}
 
-		listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		setListCache(storage, path, objs)
 	}
 }
 
 func ClearCache(storage driver.Driver, path string) {
+	clearMemCache(storage, path)
+	if err := delPersistCacheTree(storage.GetStorage().MountPath, path); err != nil {
+		log.Errorf("failed clear persisted list cache of %s: %+v", Key(storage, path), err)
+	}
+}
+
+func clearMemCache(storage driver.Driver, path string) {
 	objs, ok := listCache.Get(Key(storage, path))
 	if ok {
 		for _, obj := range objs {
 			if obj.IsDir() {
-				ClearCache(storage, stdpath.Join(path, obj.GetName()))
+				clearMemCache(storage, stdpath.Join(path, obj.GetName()))
 			}
 		}
 	}
@@ -108,9 +117,11 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 	log.Debugf("op.List %s", path)
 	key := Key(storage, path)
 	if !utils.IsBool(refresh...) {
-		if files, ok := listCache.Get(key); ok {
+		files, ok := getListCache(storage, path)
+		metrics.ObserveListCache(ok)
+		if ok {
 			log.Debugf("use cache when list %s", path)
-			return files, nil
+			return withHashCache(storage, path, hideTrash(storage, path, files)), nil
 		}
 	}
 	dir, err := GetUnwrap(ctx, storage, path)
@@ -122,7 +133,9 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 		return nil, errors.WithStack(errs.NotFolder)
 	}
 	objs, err, _ := listG.Do(key, func() ([]model.Obj, error) {
+		start := time.Now()
 		files, err := storage.List(ctx, dir, args)
+		observeCall(storage, "list", start, err)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to list objs")
 		}
@@ -150,15 +163,15 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 		if !storage.Config().NoCache {
 			if len(files) > 0 {
 				log.Debugf("set cache: %s => %+v", key, files)
-				listCache.Set(key, files, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+				setListCache(storage, path, files)
 			} else {
 				log.Debugf("del cache: %s", key)
-				listCache.Del(key)
+				delListCache(storage, path)
 			}
 		}
 		return files, nil
 	})
-	return objs, err
+	return withHashCache(storage, path, hideTrash(storage, path, objs)), err
 }
 
 // Get object from list of files
@@ -168,9 +181,11 @@ func Get(ctx context.Context, storage driver.Driver, path string) (model.Obj, er
 
 	// get the obj directly without list so that we can reduce the io
 	if g, ok := storage.(driver.Getter); ok {
+		start := time.Now()
 		obj, err := g.Get(ctx, path)
+		observeCall(storage, "get", start, err)
 		if err == nil {
-			return model.WrapObjName(obj), nil
+			return withHashCache(storage, stdpath.Dir(path), []model.Obj{model.WrapObjName(obj)})[0], nil
 		}
 	}
 
@@ -257,7 +272,9 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 		return link, file, nil
 	}
 	fn := func() (*model.Link, error) {
+		start := time.Now()
 		link, err := storage.Link(ctx, file, args)
+		observeCall(storage, "link", start, err)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed get link")
 		}
@@ -328,10 +345,12 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string, lazyCache
 					return nil, errors.WithMessagef(err, "failed to get parent dir [%s]", parentPath)
 				}
 
+				start := time.Now()
 				switch s := storage.(type) {
 				case driver.MkdirResult:
 					var newObj model.Obj
 					newObj, err = s.MakeDir(ctx, parentDir, dirName)
+					observeCall(storage, "mkdir", start, err)
 					if err == nil {
 						if newObj != nil {
 							addCacheObj(storage, parentPath, model.WrapObjName(newObj))
@@ -341,6 +360,7 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string, lazyCache
 					}
 				case driver.Mkdir:
 					err = s.MakeDir(ctx, parentDir, dirName)
+					observeCall(storage, "mkdir", start, err)
 					if err == nil && !utils.IsBool(lazyCache...) {
 						ClearCache(storage, parentPath)
 					}
@@ -378,10 +398,12 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	}
 	srcDirPath := stdpath.Dir(srcPath)
 
+	start := time.Now()
 	switch s := storage.(type) {
 	case driver.MoveResult:
 		var newObj model.Obj
 		newObj, err = s.Move(ctx, srcObj, dstDir)
+		observeCall(storage, "move", start, err)
 		if err == nil {
 			delCacheObj(storage, srcDirPath, srcRawObj)
 			if newObj != nil {
@@ -392,6 +414,7 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 		}
 	case driver.Move:
 		err = s.Move(ctx, srcObj, dstDir)
+		observeCall(storage, "move", start, err)
 		if err == nil {
 			delCacheObj(storage, srcDirPath, srcRawObj)
 			if !utils.IsBool(lazyCache...) {
@@ -416,10 +439,12 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string,
 	srcObj := model.UnwrapObj(srcRawObj)
 	srcDirPath := stdpath.Dir(srcPath)
 
+	start := time.Now()
 	switch s := storage.(type) {
 	case driver.RenameResult:
 		var newObj model.Obj
 		newObj, err = s.Rename(ctx, srcObj, dstName)
+		observeCall(storage, "rename", start, err)
 		if err == nil {
 			if newObj != nil {
 				updateCacheObj(storage, srcDirPath, srcRawObj, model.WrapObjName(newObj))
@@ -429,6 +454,7 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string,
 		}
 	case driver.Rename:
 		err = s.Rename(ctx, srcObj, dstName)
+		observeCall(storage, "rename", start, err)
 		if err == nil && !utils.IsBool(lazyCache...) {
 			ClearCache(storage, srcDirPath)
 		}
@@ -453,11 +479,17 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	if err != nil {
 		return errors.WithMessage(err, "failed to get dst dir")
 	}
+	delta := usageOf(ctx, storage, srcPath, srcObj)
+	if err = checkQuota(storage, dstDirPath, delta); err != nil {
+		return err
+	}
 
+	start := time.Now()
 	switch s := storage.(type) {
 	case driver.CopyResult:
 		var newObj model.Obj
 		newObj, err = s.Copy(ctx, srcObj, dstDir)
+		observeCall(storage, "copy", start, err)
 		if err == nil {
 			if newObj != nil {
 				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
@@ -467,16 +499,26 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 		}
 	case driver.Copy:
 		err = s.Copy(ctx, srcObj, dstDir)
+		observeCall(storage, "copy", start, err)
 		if err == nil && !utils.IsBool(lazyCache...) {
 			ClearCache(storage, dstDirPath)
 		}
 	default:
 		return errs.NotImplement
 	}
+	if err == nil {
+		addUsage(storage, dstDirPath, delta)
+	}
 	return errors.WithStack(err)
 }
 
 func Remove(ctx context.Context, storage driver.Driver, path string) error {
+	return remove(ctx, storage, path, false)
+}
+
+// remove removes the obj, it's removed permanently without counting the usage
+// if it's replaced by a new one
+func remove(ctx context.Context, storage driver.Driver, path string, replaced bool) error {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
@@ -491,16 +533,30 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 		return errors.WithMessage(err, "failed to get object")
 	}
 	dirPath := stdpath.Dir(path)
+	var size int64
+	if !replaced && !inTrash(storage, path) {
+		size = usageOf(ctx, storage, path, rawObj)
+	}
+	if !replaced && useTrash(storage, path) {
+		err = moveToTrash(ctx, storage, path, rawObj, size)
+		if err == nil {
+			addUsage(storage, dirPath, -size)
+		}
+		return err
+	}
 
+	start := time.Now()
 	switch s := storage.(type) {
 	case driver.Remove:
 		err = s.Remove(ctx, model.UnwrapObj(rawObj))
+		observeCall(storage, "remove", start, err)
 		if err == nil {
 			delCacheObj(storage, dirPath, rawObj)
 			// clear folder cache recursively
 			if rawObj.IsDir() {
 				ClearCache(storage, path)
 			}
+			addUsage(storage, dirPath, -size)
 		}
 	default:
 		return errs.NotImplement
@@ -524,8 +580,26 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	tempPath := stdpath.Join(dstDirPath, tempName)
 	fi, err := GetUnwrap(ctx, storage, dstPath)
 	if err == nil {
+		var skip bool
+		fi, skip, err = resolveConflict(ctx, storage, dstDirPath, file, fi)
+		if err != nil {
+			return err
+		}
+		if skip {
+			log.Debugf("skip put [%s], the same file exists", dstPath)
+			if up != nil {
+				up(100)
+			}
+			return nil
+		}
+		// the file is renamed if the existing obj is dropped
+		dstPath = stdpath.Join(dstDirPath, file.GetName())
+		tempName = file.GetName() + ".alist_to_delete"
+		tempPath = stdpath.Join(dstDirPath, tempName)
+	}
+	if fi != nil {
 		if fi.GetSize() == 0 {
-			err = Remove(ctx, storage, dstPath)
+			err = remove(ctx, storage, dstPath, true)
 			if err != nil {
 				return errors.WithMessagef(err, "while uploading, failed remove existing file which size = 0")
 			}
@@ -539,6 +613,14 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 			file.SetExist(fi)
 		}
 	}
+	// the existing obj is replaced, only the difference is counted
+	delta := file.GetSize()
+	if fi != nil {
+		delta -= fi.GetSize()
+	}
+	if err = checkQuota(storage, dstDirPath, delta); err != nil {
+		return err
+	}
 	err = MakeDir(ctx, storage, dstDirPath)
 	if err != nil {
 		return errors.WithMessagef(err, "failed to make dir [%s]", dstDirPath)
@@ -553,10 +635,12 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		up = func(p float64) {}
 	}
 
+	start := time.Now()
 	switch s := storage.(type) {
 	case driver.PutResult:
 		var newObj model.Obj
 		newObj, err = s.Put(ctx, parentDir, file, up)
+		observeCall(storage, "put", start, err)
 		if err == nil {
 			if newObj != nil {
 				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
@@ -566,6 +650,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		}
 	case driver.Put:
 		err = s.Put(ctx, parentDir, file, up)
+		observeCall(storage, "put", start, err)
 		if err == nil && !utils.IsBool(lazyCache...) {
 			ClearCache(storage, dstDirPath)
 		}
@@ -573,6 +658,9 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		return errs.NotImplement
 	}
 	log.Debugf("put file [%s] done", file.GetName())
+	if err == nil {
+		addUsage(storage, dstDirPath, delta)
+	}
 	if storage.Config().NoOverwriteUpload && fi != nil && fi.GetSize() > 0 {
 		if err != nil {
 			// upload failed, recover old obj
@@ -582,7 +670,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 			}
 		} else {
 			// upload success, remove old obj
-			err := Remove(ctx, storage, tempPath)
+			err := remove(ctx, storage, tempPath, true)
 			if err != nil {
 				return err
 			} else {
@@ -593,3 +681,35 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	}
 	return errors.WithStack(err)
 }
+
+// resolveConflict applies the conflict policy of the file to the obj existing
+// in the destination, it returns the obj to overwrite, which is nil if the file
+// is renamed, and whether the put should be skipped
+func resolveConflict(ctx context.Context, storage driver.Driver, dstDirPath string, file model.FileStreamer, exist model.Obj) (model.Obj, bool, error) {
+	switch file.GetConflictPolicy() {
+	case model.ConflictFail:
+		return nil, false, errors.WithStack(errs.ObjectAlreadyExists)
+	case model.ConflictSkip:
+		if exist.GetSize() == file.GetSize() {
+			// only the size is compared if they have no hash in common
+			if equal, ok := utils.CompareHashInfo(exist.GetHash(), file.GetHash()); equal || !ok {
+				return exist, true, nil
+			}
+		}
+	case model.ConflictRename:
+		ext := stdpath.Ext(file.GetName())
+		base := strings.TrimSuffix(file.GetName(), ext)
+		for i := 1; ; i++ {
+			name := fmt.Sprintf("%s (%d)%s", base, i, ext)
+			_, err := GetUnwrap(ctx, storage, stdpath.Join(dstDirPath, name))
+			if errs.IsObjectNotFound(err) {
+				file.SetName(name)
+				return nil, false, nil
+			}
+			if err != nil {
+				return nil, false, errors.WithMessagef(err, "failed get [%s]", name)
+			}
+		}
+	}
+	return exist, false, nil
+}
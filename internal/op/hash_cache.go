@@ -0,0 +1,87 @@
+package op
+
+import (
+	stdpath "path"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// hashCacheStorages is the set of the storages having hash caches, so that
+// the db is not queried when listing the other storages
+var (
+	hashCacheStorages     sync.Map
+	loadHashCacheStorages sync.Once
+)
+
+func hasHashCache(storage driver.Driver) bool {
+	loadHashCacheStorages.Do(func() {
+		ids, err := db.GetHashCacheStorageIds()
+		if err != nil {
+			log.Errorf("failed get storages of hash caches: %+v", err)
+			return
+		}
+		for _, id := range ids {
+			hashCacheStorages.Store(id, struct{}{})
+		}
+	})
+	_, ok := hashCacheStorages.Load(storage.GetStorage().ID)
+	return ok
+}
+
+// SaveHashCache saves the hashes computed from the file obj at path, it's
+// skipped if the driver gives no modified time, since the cache can't be
+// invalidated when the file changes
+func SaveHashCache(storage driver.Driver, path string, obj model.Obj, hash utils.HashInfo) error {
+	if obj.ModTime().IsZero() {
+		return nil
+	}
+	path = utils.FixAndCleanPath(path)
+	hasHashCache(storage)
+	err := db.SaveHashCache(&model.HashCache{
+		StorageID: storage.GetStorage().ID,
+		Dir:       stdpath.Dir(path),
+		Name:      stdpath.Base(path),
+		Size:      obj.GetSize(),
+		Modified:  obj.ModTime(),
+		Hash:      hash.String(),
+	})
+	if err != nil {
+		return errors.WithMessage(err, "failed save hash cache")
+	}
+	hashCacheStorages.Store(storage.GetStorage().ID, struct{}{})
+	return nil
+}
+
+// withHashCache merges the valid hash caches of the dir into the objs,
+// the objs in the list cache are kept untouched
+func withHashCache(storage driver.Driver, dirPath string, objs []model.Obj) []model.Obj {
+	if len(objs) == 0 || !hasHashCache(storage) {
+		return objs
+	}
+	caches, err := db.GetHashCaches(storage.GetStorage().ID, dirPath)
+	if err != nil {
+		log.Errorf("failed get hash caches of %s: %+v", dirPath, err)
+		return objs
+	}
+	if len(caches) == 0 {
+		return objs
+	}
+	byName := make(map[string]*model.HashCache, len(caches))
+	for i := range caches {
+		byName[caches[i].Name] = &caches[i]
+	}
+	res := make([]model.Obj, len(objs))
+	for i, obj := range objs {
+		res[i] = obj
+		if c, ok := byName[obj.GetName()]; ok && !obj.IsDir() && c.Valid(obj) {
+			res[i] = &model.ObjWrapHash{Obj: obj, Hash: c.HashInfo()}
+		}
+	}
+	return res
+}
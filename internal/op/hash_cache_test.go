@@ -0,0 +1,64 @@
+package op_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+func TestHashCache(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/hash_cache",
+		Addition:  `{"root_folder_path":"` + filepath.ToSlash(root) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/hash_cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	obj, err := op.Get(ctx, storage, "/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	md5 := utils.HashData(utils.MD5, []byte("hello"))
+	if err = op.SaveHashCache(storage, "/a.txt", obj, utils.NewHashInfo(utils.MD5, md5)); err != nil {
+		t.Fatal(err)
+	}
+	obj, err = op.Get(ctx, storage, "/a.txt")
+	if err != nil || obj.GetHash().GetHash(utils.MD5) != md5 {
+		t.Errorf("expected md5 %s from get, got %+v, %v", md5, obj, err)
+	}
+	objs, err := op.List(ctx, storage, "/", model.ListArgs{}, true)
+	if err != nil || len(objs) != 1 || objs[0].GetHash().GetHash(utils.MD5) != md5 {
+		t.Errorf("expected md5 %s from list, got %+v, %v", md5, objs, err)
+	}
+	if _, ok := model.UnwrapObj(objs[0]).(*model.ObjWrapHash); ok {
+		t.Errorf("expected the obj of the driver after unwrap")
+	}
+	// the file without the modified time is never cached
+	noTime := &model.Object{Name: "a.txt", Size: obj.GetSize()}
+	if (&model.HashCache{Size: noTime.Size}).Valid(noTime) {
+		t.Errorf("expected the cache invalid for the file without the modified time")
+	}
+	// the cache is outdated once the file changes
+	if err = os.WriteFile(filepath.Join(root, "a.txt"), []byte("hello world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	objs, err = op.List(ctx, storage, "/", model.ListArgs{}, true)
+	if err != nil || len(objs) != 1 || objs[0].GetHash().GetHash(utils.MD5) != "" {
+		t.Errorf("expected no md5 after the file changes, got %+v, %v", objs, err)
+	}
+}
@@ -0,0 +1,270 @@
+package op
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// persistCache keeps the list cache on disk, so that it survives a restart.
+// Each storage has a bucket named by its mount path, whose keys are the
+// actual paths in the storage. It's only a second level of listCache.
+var persistCache *bolt.DB
+
+type persistCacheItem struct {
+	ExpireAt time.Time         `json:"expire_at"`
+	Objs     []persistCacheObj `json:"objs"`
+}
+
+type persistCacheObj struct {
+	ID        string    `json:"id,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	Modified  time.Time `json:"modified"`
+	Ctime     time.Time `json:"ctime"`
+	IsFolder  bool      `json:"is_folder"`
+	Hash      string    `json:"hash,omitempty"`
+	Thumbnail string    `json:"thumbnail,omitempty"`
+	Url       string    `json:"url,omitempty"`
+}
+
+// PersistCacheEntry is the brief info of a persisted list cache entry
+type PersistCacheEntry struct {
+	Path     string    `json:"path"`
+	Count    int       `json:"count"`
+	ExpireAt time.Time `json:"expire_at"`
+}
+
+// InitPersistCache opens the persistent list cache at path
+func InitPersistCache(path string) error {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return errors.Wrapf(err, "failed open list cache [%s]", path)
+	}
+	persistCache = db
+	return nil
+}
+
+func ClosePersistCache() {
+	if persistCache == nil {
+		return
+	}
+	if err := persistCache.Close(); err != nil {
+		log.Errorf("failed close list cache: %+v", err)
+	}
+	persistCache = nil
+}
+
+func setListCache(storage driver.Driver, path string, objs []model.Obj) {
+	expiration := time.Minute * time.Duration(storage.GetStorage().CacheExpiration)
+	listCache.Set(Key(storage, path), objs, cache.WithEx[[]model.Obj](expiration))
+	if persistCache == nil {
+		return
+	}
+	item := persistCacheItem{ExpireAt: time.Now().Add(expiration)}
+	for _, obj := range objs {
+		o, ok := toPersistCacheObj(obj)
+		if !ok {
+			// the driver needs its own obj type, which can't be restored
+			delPersistCache(storage, path)
+			return
+		}
+		item.Objs = append(item.Objs, o)
+	}
+	value, err := utils.Json.Marshal(item)
+	if err != nil {
+		log.Errorf("failed marshal list cache of %s: %+v", Key(storage, path), err)
+		return
+	}
+	err = persistCache.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(storage.GetStorage().MountPath))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(utils.FixAndCleanPath(path)), value)
+	})
+	if err != nil {
+		log.Errorf("failed persist list cache of %s: %+v", Key(storage, path), err)
+	}
+}
+
+func getListCache(storage driver.Driver, path string) ([]model.Obj, bool) {
+	key := Key(storage, path)
+	if objs, ok := listCache.Get(key); ok {
+		return objs, true
+	}
+	if persistCache == nil {
+		return nil, false
+	}
+	var value []byte
+	_ = persistCache.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(storage.GetStorage().MountPath)); b != nil {
+			value = append(value, b.Get([]byte(utils.FixAndCleanPath(path)))...)
+		}
+		return nil
+	})
+	if len(value) == 0 {
+		return nil, false
+	}
+	var item persistCacheItem
+	if err := utils.Json.Unmarshal(value, &item); err != nil || time.Now().After(item.ExpireAt) {
+		delPersistCache(storage, path)
+		return nil, false
+	}
+	objs := make([]model.Obj, 0, len(item.Objs))
+	for _, o := range item.Objs {
+		objs = append(objs, model.WrapObjName(o.toObj()))
+	}
+	log.Debugf("restore list cache of %s from disk", key)
+	listCache.Set(key, objs, cache.WithExAt[[]model.Obj](item.ExpireAt))
+	return objs, true
+}
+
+func delListCache(storage driver.Driver, path string) {
+	listCache.Del(Key(storage, path))
+	delPersistCache(storage, path)
+}
+
+func delPersistCache(storage driver.Driver, path string) {
+	if persistCache == nil {
+		return
+	}
+	err := persistCache.Update(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(storage.GetStorage().MountPath)); b != nil {
+			return b.Delete([]byte(utils.FixAndCleanPath(path)))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("failed delete persisted list cache of %s: %+v", Key(storage, path), err)
+	}
+}
+
+// ListPersistCache lists the persisted list cache entries of a storage
+func ListPersistCache(mountPath string) ([]PersistCacheEntry, error) {
+	entries := make([]PersistCacheEntry, 0)
+	if persistCache == nil {
+		return entries, nil
+	}
+	err := persistCache.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(utils.FixAndCleanPath(mountPath)))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var item persistCacheItem
+			if err := utils.Json.Unmarshal(v, &item); err != nil {
+				return errors.Wrapf(err, "failed unmarshal list cache of %s", k)
+			}
+			entries = append(entries, PersistCacheEntry{
+				Path:     string(k),
+				Count:    len(item.Objs),
+				ExpireAt: item.ExpireAt,
+			})
+			return nil
+		})
+	})
+	return entries, err
+}
+
+// PurgePersistCache removes the cache of a storage both in memory and on disk,
+// only the entries under path are removed if it's not empty
+func PurgePersistCache(mountPath string, path string) error {
+	mountPath = utils.FixAndCleanPath(mountPath)
+	if storage, err := GetStorageByMountPath(mountPath); err == nil {
+		ClearCache(storage, utils.FixAndCleanPath(path))
+		return nil
+	}
+	// the storage may have been deleted or disabled
+	return delPersistCacheTree(mountPath, path)
+}
+
+// delPersistCacheTree deletes the persisted entries of path and all its sub dirs
+func delPersistCacheTree(mountPath string, path string) error {
+	if persistCache == nil {
+		return nil
+	}
+	return persistCache.Update(func(tx *bolt.Tx) error {
+		if path == "" || utils.PathEqual(path, "/") {
+			err := tx.DeleteBucket([]byte(mountPath))
+			if errors.Is(err, bolt.ErrBucketNotFound) {
+				return nil
+			}
+			return err
+		}
+		b := tx.Bucket([]byte(mountPath))
+		if b == nil {
+			return nil
+		}
+		path = utils.FixAndCleanPath(path)
+		var keys [][]byte
+		c := b.Cursor()
+		for k, _ := c.Seek([]byte(path)); k != nil && strings.HasPrefix(string(k), path); k, _ = c.Next() {
+			if string(k) == path || strings.HasPrefix(string(k), path+"/") {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+		}
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func toPersistCacheObj(obj model.Obj) (persistCacheObj, bool) {
+	var res persistCacheObj
+	var o model.Object
+	switch v := model.UnwrapObj(obj).(type) {
+	case *model.Object:
+		o = *v
+	case *model.ObjThumb:
+		o, res.Thumbnail = v.Object, v.Thumbnail.Thumbnail
+	case *model.ObjectURL:
+		o, res.Url = v.Object, v.Url.Url
+	case *model.ObjThumbURL:
+		o, res.Thumbnail, res.Url = v.Object, v.Thumbnail.Thumbnail, v.Url.Url
+	default:
+		return res, false
+	}
+	res.ID, res.Path, res.Name, res.Size = o.ID, o.Path, o.Name, o.Size
+	res.Modified, res.Ctime, res.IsFolder = o.Modified, o.Ctime, o.IsFolder
+	if len(o.HashInfo.Export()) > 0 {
+		res.Hash = o.HashInfo.String()
+	}
+	return res, true
+}
+
+func (o persistCacheObj) toObj() model.Obj {
+	obj := model.Object{
+		ID:       o.ID,
+		Path:     o.Path,
+		Name:     o.Name,
+		Size:     o.Size,
+		Modified: o.Modified,
+		Ctime:    o.Ctime,
+		IsFolder: o.IsFolder,
+	}
+	if o.Hash != "" {
+		obj.HashInfo = utils.FromString(o.Hash)
+	}
+	switch {
+	case o.Thumbnail != "" && o.Url != "":
+		return &model.ObjThumbURL{Object: obj, Thumbnail: model.Thumbnail{Thumbnail: o.Thumbnail}, Url: model.Url{Url: o.Url}}
+	case o.Thumbnail != "":
+		return &model.ObjThumb{Object: obj, Thumbnail: model.Thumbnail{Thumbnail: o.Thumbnail}}
+	case o.Url != "":
+		return &model.ObjectURL{Object: obj, Url: model.Url{Url: o.Url}}
+	default:
+		return &obj
+	}
+}
@@ -0,0 +1,50 @@
+package op_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestPersistCache(t *testing.T) {
+	if err := op.InitPersistCache(filepath.Join(t.TempDir(), "list_cache.db")); err != nil {
+		t.Fatalf("failed to init persist cache: %+v", err)
+	}
+	defer op.ClosePersistCache()
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:          "Virtual",
+		MountPath:       "/persist_cache",
+		CacheExpiration: 30,
+		Addition:        `{"num_file":2,"num_folder":1,"max_file_size":1024,"min_file_size":1}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/persist_cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = op.List(context.Background(), storage, "/", model.ListArgs{}); err != nil {
+		t.Fatalf("failed to list: %+v", err)
+	}
+	entries, err := op.ListPersistCache("/persist_cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Path != "/" || entries[0].Count != 3 {
+		t.Errorf("expected one entry of / with 3 objs, got: %+v", entries)
+	}
+	if err = op.PurgePersistCache("/persist_cache", ""); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = op.ListPersistCache("/persist_cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected no entries after purge, got: %+v", entries)
+	}
+}
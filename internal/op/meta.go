@@ -68,6 +68,7 @@ func DeleteMetaById(id uint) error {
 		return err
 	}
 	metaCache.Del(old.Path)
+	defer clearMetaQuotas()
 	return db.DeleteMetaById(id)
 }
 
@@ -78,12 +79,14 @@ func UpdateMeta(u *model.Meta) error {
 		return err
 	}
 	metaCache.Del(old.Path)
+	defer clearMetaQuotas()
 	return db.UpdateMeta(u)
 }
 
 func CreateMeta(u *model.Meta) error {
 	u.Path = utils.FixAndCleanPath(u.Path)
 	metaCache.Del(u.Path)
+	defer clearMetaQuotas()
 	return db.CreateMeta(u)
 }
 
@@ -0,0 +1,13 @@
+package op
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/metrics"
+)
+
+// observeCall records the latency and the error of the call to the driver
+func observeCall(storage driver.Driver, method string, start time.Time, err error) {
+	metrics.ObserveDriverCall(storage.GetStorage().MountPath, storage.Config().Name, method, time.Since(start), err)
+}
@@ -0,0 +1,66 @@
+package op_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+)
+
+func TestPutConflict(t *testing.T) {
+	root := t.TempDir()
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/conflict",
+		Addition:  `{"root_folder_path":"` + filepath.ToSlash(root) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	d, err := op.GetStorageByMountPath("/conflict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	put := func(content string, conflict model.ConflictPolicy) (string, error) {
+		s := &stream.FileStream{
+			Obj:      &model.Object{Name: "a.txt", Size: int64(len(content))},
+			Reader:   strings.NewReader(content),
+			Conflict: conflict,
+		}
+		err := op.Put(context.Background(), d, "/", s, nil)
+		return s.GetName(), err
+	}
+	read := func(name string) string {
+		b, _ := os.ReadFile(filepath.Join(root, name))
+		return string(b)
+	}
+	if _, err = put("hello", model.ConflictOverwrite); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = put("world", model.ConflictFail); !errors.Is(err, errs.ObjectAlreadyExists) {
+		t.Errorf("expected object already exists, got %v", err)
+	}
+	// same size, the local driver doesn't report hashes
+	if _, err = put("world", model.ConflictSkip); err != nil || read("a.txt") != "hello" {
+		t.Errorf("expected skipped, got %v, content %s", err, read("a.txt"))
+	}
+	if _, err = put("world!", model.ConflictSkip); err != nil || read("a.txt") != "world!" {
+		t.Errorf("expected overwritten, got %v, content %s", err, read("a.txt"))
+	}
+	for _, want := range []string{"a (1).txt", "a (2).txt"} {
+		name, err := put("renamed", model.ConflictRename)
+		if err != nil || name != want || read(want) != "renamed" {
+			t.Errorf("expected %s, got %s, %v", want, name, err)
+		}
+	}
+	if read("a.txt") != "world!" {
+		t.Errorf("expected a.txt untouched, got %s", read("a.txt"))
+	}
+}
@@ -0,0 +1,223 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// the quotas of metas and users are checked on every put and remove, so
+// they are kept in memory until a meta or user is changed
+var (
+	metaQuotas   map[string]int64
+	metaQuotasMu sync.Mutex
+	quotaUsers   []model.User
+	quotaUsersMu sync.Mutex
+)
+
+// GetMetaQuotas returns the quotas of the paths of metas
+func GetMetaQuotas() (map[string]int64, error) {
+	metaQuotasMu.Lock()
+	defer metaQuotasMu.Unlock()
+	if metaQuotas != nil {
+		return metaQuotas, nil
+	}
+	metas, err := db.GetMetasWithQuota()
+	if err != nil {
+		return nil, err
+	}
+	quotas := make(map[string]int64, len(metas))
+	for _, m := range metas {
+		quotas[utils.FixAndCleanPath(m.Path)] = m.Quota
+	}
+	metaQuotas = quotas
+	return quotas, nil
+}
+
+func clearMetaQuotas() {
+	metaQuotasMu.Lock()
+	defer metaQuotasMu.Unlock()
+	metaQuotas = nil
+}
+
+// GetQuotaUsers returns the users with a quota
+func GetQuotaUsers() ([]model.User, error) {
+	quotaUsersMu.Lock()
+	defer quotaUsersMu.Unlock()
+	if quotaUsers != nil {
+		return quotaUsers, nil
+	}
+	users, err := db.GetUsersWithQuota()
+	if err != nil {
+		return nil, err
+	}
+	if users == nil {
+		users = []model.User{}
+	}
+	quotaUsers = users
+	return users, nil
+}
+
+func clearQuotaUsers() {
+	quotaUsersMu.Lock()
+	defer quotaUsersMu.Unlock()
+	quotaUsers = nil
+}
+
+// pathUsers returns the users with a quota whose base path contains the
+// path in the storage, the usage of a user is the bytes stored in the base
+// path of the user no matter who writes them
+func pathUsers(storage driver.Driver, path string) ([]model.User, error) {
+	users, err := GetQuotaUsers()
+	if err != nil || len(users) == 0 {
+		return nil, err
+	}
+	fullPath := utils.GetFullPath(storage.GetStorage().MountPath, path)
+	var res []model.User
+	for _, user := range users {
+		if utils.IsSubPath(user.BasePath, fullPath) {
+			res = append(res, user)
+		}
+	}
+	return res, nil
+}
+
+// pathQuotas returns the quotas of metas applied to the path in the storage
+func pathQuotas(storage driver.Driver, path string) (map[string]int64, error) {
+	quotas, err := GetMetaQuotas()
+	if err != nil || len(quotas) == 0 {
+		return nil, err
+	}
+	fullPath := utils.GetFullPath(storage.GetStorage().MountPath, path)
+	res := map[string]int64{}
+	for p, quota := range quotas {
+		if utils.IsSubPath(p, fullPath) {
+			res[p] = quota
+		}
+	}
+	return res, nil
+}
+
+// hasQuota returns whether the usage of the path has to be counted
+func hasQuota(storage driver.Driver, path string) bool {
+	users, err := pathUsers(storage, path)
+	if err != nil {
+		log.Errorf("failed get quota users: %+v", err)
+	}
+	if len(users) > 0 {
+		return true
+	}
+	quotas, err := pathQuotas(storage, path)
+	if err != nil {
+		log.Errorf("failed get quotas: %+v", err)
+	}
+	return len(quotas) > 0
+}
+
+// checkQuota checks whether delta bytes can be added to the path
+func checkQuota(storage driver.Driver, path string, delta int64) error {
+	if delta <= 0 {
+		return nil
+	}
+	users, err := pathUsers(storage, path)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		used, err := db.GetUserUsage(user.ID)
+		if err != nil {
+			return err
+		}
+		if used+delta > user.Quota {
+			return errors.WithMessagef(errs.QuotaExceeded, "user %s has used %d of %d bytes, can't add %d bytes", user.Username, used, user.Quota, delta)
+		}
+	}
+	quotas, err := pathQuotas(storage, path)
+	if err != nil {
+		return err
+	}
+	for p, quota := range quotas {
+		used, err := db.GetUsage(p)
+		if err != nil {
+			return err
+		}
+		if used+delta > quota {
+			return errors.WithMessagef(errs.QuotaExceeded, "%s has used %d of %d bytes, can't add %d bytes", p, used, quota, delta)
+		}
+	}
+	return nil
+}
+
+// addUsage counts the bytes added to or removed from the path
+// to the users and the metas whose paths contain it
+func addUsage(storage driver.Driver, path string, delta int64) {
+	if delta == 0 {
+		return
+	}
+	users, err := pathUsers(storage, path)
+	if err != nil {
+		log.Errorf("failed get quota users: %+v", err)
+	}
+	for _, user := range users {
+		if err = db.AddUserUsage(user.ID, delta); err != nil {
+			log.Errorf("failed add usage of user %s: %+v", user.Username, err)
+		}
+	}
+	quotas, err := pathQuotas(storage, path)
+	if err != nil {
+		log.Errorf("failed get quotas: %+v", err)
+		return
+	}
+	for p := range quotas {
+		if err = db.AddUsage(p, delta); err != nil {
+			log.Errorf("failed add usage of %s: %+v", p, err)
+		}
+	}
+}
+
+// usageOf returns the bytes taken by the obj, a dir is only walked
+// if a quota applies to it since it may be large
+func usageOf(ctx context.Context, storage driver.Driver, path string, obj model.Obj) int64 {
+	if !obj.IsDir() {
+		return obj.GetSize()
+	}
+	if !hasQuota(storage, path) {
+		return 0
+	}
+	size, err := dirSize(ctx, storage, path)
+	if err != nil {
+		log.Warnf("failed get size of dir %s, the usage is counted by the reconciliation: %+v", path, err)
+	}
+	return size
+}
+
+func dirSize(ctx context.Context, storage driver.Driver, path string) (int64, error) {
+	objs, err := List(ctx, storage, path, model.ListArgs{})
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, obj := range objs {
+		if utils.IsCanceled(ctx) {
+			return size, ctx.Err()
+		}
+		if !obj.IsDir() {
+			size += obj.GetSize()
+			continue
+		}
+		s, err := dirSize(ctx, storage, stdpath.Join(path, obj.GetName()))
+		size += s
+		if err != nil {
+			return size, err
+		}
+	}
+	return size, nil
+}
@@ -0,0 +1,74 @@
+package op_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+)
+
+func TestUserQuota(t *testing.T) {
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/quota",
+		Addition:  `{"root_folder_path":"` + filepath.ToSlash(t.TempDir()) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	d, err := op.GetStorageByMountPath("/quota")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := &model.User{Username: "quota_inner", BasePath: "/quota/u", Quota: 10}
+	// the base path contains the dir of the inner user, so it's charged too
+	outer := &model.User{Username: "quota_outer", BasePath: "/quota", Quota: 100}
+	for _, user := range []*model.User{inner, outer} {
+		if err = op.CreateUser(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the usage is counted by the path no matter who writes it
+	ctx := context.Background()
+	put := func(dir, name, content string) error {
+		return op.Put(ctx, d, dir, &stream.FileStream{
+			Obj:    &model.Object{Name: name, Size: int64(len(content))},
+			Reader: strings.NewReader(content),
+		}, nil)
+	}
+	usage := func(user *model.User) int64 {
+		used, err := db.GetUserUsage(user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return used
+	}
+	if err = put("/u/dir", "a.txt", "12345"); err != nil {
+		t.Fatal(err)
+	}
+	if err = put("/u/dir", "b.txt", "1234"); err != nil {
+		t.Fatal(err)
+	}
+	if err = put("/", "d.txt", "123"); err != nil {
+		t.Fatal(err)
+	}
+	if usage(inner) != 9 || usage(outer) != 12 {
+		t.Errorf("expected usage 9 and 12, got %d and %d", usage(inner), usage(outer))
+	}
+	if err = put("/u", "c.txt", "12"); !errors.Is(err, errs.QuotaExceeded) {
+		t.Errorf("expected quota exceeded, got %v", err)
+	}
+	if err = op.Remove(ctx, d, "/u/dir"); err != nil {
+		t.Fatal(err)
+	}
+	if usage(inner) != 0 || usage(outer) != 3 {
+		t.Errorf("expected the usage of the removed dir subtracted, got %d and %d", usage(inner), usage(outer))
+	}
+}
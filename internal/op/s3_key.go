@@ -0,0 +1,97 @@
+package op
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+// the keys are cached by the access key
+var s3KeyCache = cache.NewMemCache(cache.WithShards[*model.S3Key](2))
+
+// CreateS3Key issues a pair of s3 credentials to the user, the secret key is
+// only returned here
+func CreateS3Key(user *model.User, name string) (*model.S3Key, error) {
+	if user.IsGuest() {
+		return nil, errors.WithStack(errs.PermissionDenied)
+	}
+	k := &model.S3Key{
+		UserID:    user.ID,
+		Name:      name,
+		AccessKey: "AS" + strings.ToUpper(random.String(18)),
+		SecretKey: random.String(40),
+		CreatedAt: time.Now(),
+	}
+	return k, db.CreateS3Key(k)
+}
+
+func GetS3Keys(userId uint, pageIndex, pageSize int) ([]model.S3Key, int64, error) {
+	return db.GetS3Keys(userId, pageIndex, pageSize)
+}
+
+func GetS3KeyById(id uint) (*model.S3Key, error) {
+	return db.GetS3KeyById(id)
+}
+
+func DeleteS3KeyById(id uint) error {
+	k, err := db.GetS3KeyById(id)
+	if err != nil {
+		return err
+	}
+	s3KeyCache.Del(k.AccessKey)
+	return db.DeleteS3KeyById(id)
+}
+
+func deleteS3KeysByUser(userId uint) error {
+	s3KeyCache.Clear()
+	return db.DeleteS3KeysByUserId(userId)
+}
+
+func getS3Key(accessKey string) (*model.S3Key, error) {
+	if k, ok := s3KeyCache.Get(accessKey); ok {
+		return k, nil
+	}
+	k, err := db.GetS3KeyByAccessKey(accessKey)
+	if err != nil {
+		return nil, err
+	}
+	s3KeyCache.Set(accessKey, k, cache.WithEx[*model.S3Key](time.Minute*10))
+	return k, nil
+}
+
+// GetS3Credential returns the user and the secret key of the access key, the
+// access key is either a s3 key of the user or the access key of an api token
+func GetS3Credential(accessKey string) (*model.User, string, error) {
+	k, err := getS3Key(accessKey)
+	if err != nil {
+		user, t, err := GetUserByAccessKey(accessKey)
+		if err != nil {
+			return nil, "", err
+		}
+		return user, t.Token, nil
+	}
+	user, err := GetUserById(k.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user.Disabled {
+		return nil, "", errors.New("user of the s3 key is disabled")
+	}
+	now := time.Now()
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) > apiTokenTouchInterval {
+		// the cached key is shared, so that it's replaced instead of modified
+		touched := *k
+		touched.LastUsedAt = &now
+		s3KeyCache.Set(k.AccessKey, &touched, cache.WithEx[*model.S3Key](time.Minute*10))
+		if err = db.UpdateS3KeyLastUsed(k.ID, now); err != nil {
+			return nil, "", err
+		}
+	}
+	return user, k.SecretKey, nil
+}
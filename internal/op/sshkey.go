@@ -0,0 +1,66 @@
+package op
+
+import (
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// CreateSSHPublicKey parses the key in the format of authorized_keys and
+// adds it to the user, a key can only belong to one user
+func CreateSSHPublicKey(user *model.User, title, key string) (*model.SSHPublicKey, error) {
+	pub, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid public key")
+	}
+	fingerprint := ssh.FingerprintSHA256(pub)
+	if _, err = db.GetSSHPublicKeyByFingerprint(fingerprint); err == nil {
+		return nil, errors.New("the public key has been added")
+	}
+	if title == "" {
+		title = comment
+	}
+	k := &model.SSHPublicKey{
+		UserID:      user.ID,
+		Title:       title,
+		Fingerprint: fingerprint,
+		Key:         strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub))),
+		CreatedAt:   time.Now(),
+	}
+	return k, db.CreateSSHPublicKey(k)
+}
+
+func GetSSHPublicKeys(userId uint, pageIndex, pageSize int) ([]model.SSHPublicKey, int64, error) {
+	return db.GetSSHPublicKeys(userId, pageIndex, pageSize)
+}
+
+func GetSSHPublicKeyById(id uint) (*model.SSHPublicKey, error) {
+	return db.GetSSHPublicKeyById(id)
+}
+
+func DeleteSSHPublicKeyById(id uint) error {
+	return db.DeleteSSHPublicKeyById(id)
+}
+
+// GetUserBySSHPublicKey returns the user if the key belongs to the user
+func GetUserBySSHPublicKey(username string, pub ssh.PublicKey) (*model.User, error) {
+	user, err := GetUserByName(username)
+	if err != nil {
+		return nil, err
+	}
+	k, err := db.GetSSHPublicKeyByFingerprint(ssh.FingerprintSHA256(pub))
+	if err != nil {
+		return nil, err
+	}
+	if k.UserID != user.ID {
+		return nil, errors.New("the public key doesn't belong to the user")
+	}
+	if err = db.UpdateSSHPublicKeyLastUsed(k.ID, time.Now()); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -51,6 +51,9 @@ func CreateStorage(ctx context.Context, storage model.Storage) (uint, error) {
 		return 0, errors.WithMessage(err, "failed get driver new")
 	}
 	storageDriver := driverNew()
+	if err = checkTrash(storage, storageDriver); err != nil {
+		return 0, err
+	}
 	// insert storage to database
 	err = db.CreateStorage(&storage)
 	if err != nil {
@@ -163,12 +166,23 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	if oldStorage.Driver != storage.Driver {
 		return errors.Errorf("driver cannot be changed")
 	}
+	driverNew, err := GetDriver(storage.Driver)
+	if err != nil {
+		return errors.WithMessage(err, "failed get driver new")
+	}
+	if err = checkTrash(storage, driverNew()); err != nil {
+		return err
+	}
 	storage.Modified = time.Now()
 	storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
 	err = db.UpdateStorage(&storage)
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in database")
 	}
+	// the persisted list cache may not match the new addition
+	if err := delPersistCacheTree(oldStorage.MountPath, "/"); err != nil {
+		log.Errorf("failed purge list cache of %s: %+v", oldStorage.MountPath, err)
+	}
 	if storage.Disabled {
 		return nil
 	}
@@ -213,6 +227,13 @@ func DeleteStorageById(ctx context.Context, id uint) error {
 	if err := db.DeleteStorageById(id); err != nil {
 		return errors.WithMessage(err, "failed delete storage in database")
 	}
+	if err := delPersistCacheTree(storage.MountPath, "/"); err != nil {
+		log.Errorf("failed purge list cache of %s: %+v", storage.MountPath, err)
+	}
+	if err := db.DeleteHashCachesByStorageId(id); err != nil {
+		log.Errorf("failed delete hash caches of %s: %+v", storage.MountPath, err)
+	}
+	hashCacheStorages.Delete(id)
 	return nil
 }
 
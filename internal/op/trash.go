@@ -0,0 +1,153 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultTrashPath = "/.alist_trash"
+
+func trashPath(storage driver.Driver) string {
+	return utils.FixAndCleanPath(utils.GetNoneEmpty(storage.GetStorage().TrashPath, defaultTrashPath))
+}
+
+func inTrash(storage driver.Driver, path string) bool {
+	return storage.GetStorage().EnableTrash && utils.IsSubPath(trashPath(storage), path)
+}
+
+// useTrash returns whether the obj should be moved into the trash when
+// removing, the objs in the trash are removed permanently
+func useTrash(storage driver.Driver, path string) bool {
+	return storage.GetStorage().EnableTrash && !inTrash(storage, path)
+}
+
+// checkTrash rejects enabling the trash on a storage whose driver can't
+// make dirs or move objs, since every remove would fail then
+func checkTrash(storage model.Storage, storageDriver driver.Driver) error {
+	if !storage.EnableTrash {
+		return nil
+	}
+	_, canMove := storageDriver.(driver.Move)
+	_, canMoveResult := storageDriver.(driver.MoveResult)
+	_, canMkdir := storageDriver.(driver.Mkdir)
+	_, canMkdirResult := storageDriver.(driver.MkdirResult)
+	if !(canMove || canMoveResult) || !(canMkdir || canMkdirResult) {
+		return errors.Errorf("driver [%s] can't move objects, the trash can't be enabled", storage.Driver)
+	}
+	return nil
+}
+
+// hideTrash removes the trash dir from the objs of the dir
+func hideTrash(storage driver.Driver, dirPath string, objs []model.Obj) []model.Obj {
+	if !storage.GetStorage().EnableTrash {
+		return objs
+	}
+	trash := trashPath(storage)
+	if !utils.PathEqual(dirPath, stdpath.Dir(trash)) {
+		return objs
+	}
+	name := stdpath.Base(trash)
+	for i, obj := range objs {
+		if obj.GetName() == name {
+			res := make([]model.Obj, 0, len(objs)-1)
+			res = append(res, objs[:i]...)
+			return append(res, objs[i+1:]...)
+		}
+	}
+	return objs
+}
+
+// moveToTrash moves the obj into a new dir in the trash and records it,
+// size is the bytes taken by the obj which are counted back if it's restored
+func moveToTrash(ctx context.Context, storage driver.Driver, path string, obj model.Obj, size int64) error {
+	trashDir := stdpath.Join(trashPath(storage), strconv.FormatInt(time.Now().UnixNano(), 10))
+	if err := MakeDir(ctx, storage, trashDir); err != nil {
+		return errors.WithMessage(err, "failed make trash dir")
+	}
+	if err := Move(ctx, storage, path, trashDir); err != nil {
+		return errors.WithMessage(err, "failed move to trash")
+	}
+	item := &model.TrashItem{
+		StorageID:  storage.GetStorage().ID,
+		Path:       utils.GetFullPath(storage.GetStorage().MountPath, path),
+		ActualPath: path,
+		TrashDir:   trashDir,
+		Name:       obj.GetName(),
+		Size:       size,
+		IsDir:      obj.IsDir(),
+		DeletedAt:  time.Now(),
+	}
+	if user, ok := ctx.Value("user").(*model.User); ok {
+		item.Deleter = user.Username
+	}
+	return db.CreateTrashItem(item)
+}
+
+func getTrashItemStorage(item *model.TrashItem) (driver.Driver, error) {
+	for _, storage := range GetAllStorages() {
+		if storage.GetStorage().ID == item.StorageID {
+			return storage, nil
+		}
+	}
+	return nil, errors.Errorf("storage of trash item [%d] not found", item.ID)
+}
+
+// RestoreTrashItem moves the obj back to its original path
+func RestoreTrashItem(ctx context.Context, item *model.TrashItem) error {
+	storage, err := getTrashItemStorage(item)
+	if err != nil {
+		return err
+	}
+	actualPath := item.ActualPath
+	if _, err = Get(ctx, storage, actualPath); err == nil {
+		return errors.WithStack(errs.ObjectAlreadyExists)
+	}
+	dstDir := stdpath.Dir(actualPath)
+	if err = MakeDir(ctx, storage, dstDir); err != nil {
+		return errors.WithMessage(err, "failed make original dir")
+	}
+	if err = Move(ctx, storage, stdpath.Join(item.TrashDir, item.Name), dstDir); err != nil {
+		return errors.WithMessage(err, "failed restore from trash")
+	}
+	addUsage(storage, dstDir, item.Size)
+	if err = Remove(ctx, storage, item.TrashDir); err != nil {
+		log.Warnf("failed remove trash dir %s: %+v", item.TrashDir, err)
+	}
+	return db.DeleteTrashItemById(item.ID)
+}
+
+// PurgeTrashItem removes the obj in the trash permanently
+func PurgeTrashItem(ctx context.Context, item *model.TrashItem) error {
+	storage, err := getTrashItemStorage(item)
+	if err != nil {
+		return err
+	}
+	if err = Remove(ctx, storage, item.TrashDir); err != nil {
+		return errors.WithMessage(err, "failed purge trash item")
+	}
+	return db.DeleteTrashItemById(item.ID)
+}
+
+// CleanTrash purges the items removed longer than the retention
+func CleanTrash(ctx context.Context, retention time.Duration) {
+	items, err := db.GetTrashItemsDeletedBefore(time.Now().Add(-retention))
+	if err != nil {
+		log.Errorf("failed get expired trash items: %+v", err)
+		return
+	}
+	for i := range items {
+		if err = PurgeTrashItem(ctx, &items[i]); err != nil {
+			log.Errorf("failed purge trash item %s: %+v", items[i].Path, err)
+		}
+	}
+}
@@ -69,6 +69,7 @@ func GetUsers(pageIndex, pageSize int) (users []model.User, count int64, err err
 }
 
 func CreateUser(u *model.User) error {
+	defer clearQuotaUsers()
 	u.BasePath = utils.FixAndCleanPath(u.BasePath)
 	return db.CreateUser(u)
 }
@@ -81,7 +82,24 @@ func DeleteUserById(id uint) error {
 	if old.IsAdmin() || old.IsGuest() {
 		return errs.DeleteAdminOrGuest
 	}
+	defer clearQuotaUsers()
 	userCache.Del(old.Username)
+	if err = db.DeleteUserAccess(id); err != nil {
+		return err
+	}
+	clearACLCache()
+	if err = deleteAPITokensByUser(id); err != nil {
+		return err
+	}
+	if err = db.DeleteSSHPublicKeysByUserId(id); err != nil {
+		return err
+	}
+	if err = deleteS3KeysByUser(id); err != nil {
+		return err
+	}
+	if err = db.DeleteUserUsage(id); err != nil {
+		return err
+	}
 	return db.DeleteUserById(id)
 }
 
@@ -97,6 +115,7 @@ func UpdateUser(u *model.User) error {
 		guestUser = nil
 	}
 	userCache.Del(old.Username)
+	defer clearQuotaUsers()
 	u.BasePath = utils.FixAndCleanPath(u.BasePath)
 	return db.UpdateUser(u)
 }
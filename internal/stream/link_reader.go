@@ -0,0 +1,125 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+)
+
+// maxSkip is the max gap skipped by reading instead of reopening the file,
+// the requests of the clients may arrive slightly out of order
+const maxSkip = 1 << 20
+
+// LinkReader reads the file from its link at any offset for the servers of
+// the file protocols, the stream is reused by the sequential reads and
+// reopened at the offset otherwise
+type LinkReader struct {
+	ctx     context.Context
+	link    *model.Link
+	size    int64
+	bufSize int
+
+	mu     sync.Mutex
+	rrc    model.RangeReadCloserIF
+	rc     io.ReadCloser
+	reader *bufio.Reader
+	pos    int64
+}
+
+func NewLinkReader(ctx context.Context, link *model.Link, size int64, bufSize int) *LinkReader {
+	if bufSize <= 0 {
+		bufSize = 128 * 1024
+	}
+	return &LinkReader{ctx: ctx, link: link, size: size, bufSize: bufSize}
+}
+
+func (r *LinkReader) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	if r.link.MFile != nil {
+		return r.link.MFile.ReadAt(p, off)
+	}
+	if r.reader != nil && off > r.pos && off-r.pos <= maxSkip {
+		if _, err := r.reader.Discard(int(off - r.pos)); err == nil {
+			r.pos = off
+		}
+	}
+	if r.reader == nil || off != r.pos {
+		// not a sequential read, restart the stream at off
+		r.closeReader()
+		rc, err := r.rangeRead(off)
+		if err != nil {
+			return 0, err
+		}
+		r.rc = rc
+		r.reader = bufio.NewReaderSize(rc, r.bufSize)
+		r.pos = off
+	}
+	n, err := io.ReadFull(r.reader, p)
+	r.pos += int64(n)
+	if err != nil {
+		r.closeReader()
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			err = io.EOF
+		}
+	}
+	return n, err
+}
+
+// RangeRead opens the file from off to the end, the returned reader is
+// independent of ReadAt but must be closed before the LinkReader
+func (r *LinkReader) RangeRead(off int64) (io.ReadCloser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rangeRead(off)
+}
+
+func (r *LinkReader) rangeRead(off int64) (io.ReadCloser, error) {
+	if r.link.MFile != nil {
+		return io.NopCloser(io.NewSectionReader(r.link.MFile, off, r.size-off)), nil
+	}
+	if r.rrc == nil {
+		if r.link.RangeReadCloser != nil {
+			r.rrc = r.link.RangeReadCloser
+		} else {
+			rrc, err := GetRangeReadCloserFromLink(r.size, r.link)
+			if err != nil {
+				return nil, err
+			}
+			r.rrc = rrc
+		}
+	}
+	return r.rrc.RangeRead(r.ctx, http_range.Range{Start: off, Length: r.size - off})
+}
+
+func (r *LinkReader) closeReader() {
+	if r.rc != nil {
+		_ = r.rc.Close()
+	}
+	r.rc = nil
+	r.reader = nil
+}
+
+// Close closes the stream and the link
+func (r *LinkReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closeReader()
+	var err error
+	if r.rrc != nil {
+		err = r.rrc.Close()
+		r.rrc = nil
+	}
+	if r.link.MFile != nil {
+		err = errors.Join(err, r.link.MFile.Close())
+	}
+	return err
+}
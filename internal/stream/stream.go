@@ -21,7 +21,8 @@ type FileStream struct {
 	Mimetype          string
 	WebPutAsTask      bool
 	ForceStreamUpload bool
-	Exist             model.Obj //the file existed in the destination, we can reuse some info since we wil overwrite it
+	Exist             model.Obj            //the file existed in the destination, we can reuse some info since we wil overwrite it
+	Conflict          model.ConflictPolicy // what to do if the file exists in the destination, overwrite if empty
 	utils.Closers
 	tmpFile  *os.File //if present, tmpFile has full content, it will be deleted at last
 	peekBuff *bytes.Reader
@@ -69,6 +70,14 @@ func (f *FileStream) SetExist(obj model.Obj) {
 	f.Exist = obj
 }
 
+func (f *FileStream) GetConflictPolicy() model.ConflictPolicy {
+	return f.Conflict
+}
+
+func (f *FileStream) SetName(name string) {
+	f.Obj = &model.ObjWrapName{Name: name, Obj: f.Obj}
+}
+
 // CacheFullInTempFile save all data into tmpFile. Not recommended since it wears disk,
 // and can't start upload until the file is written. It's not thread-safe!
 func (f *FileStream) CacheFullInTempFile() (model.File, error) {
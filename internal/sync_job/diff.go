@@ -0,0 +1,319 @@
+package sync_job
+
+import (
+	"context"
+	stdpath "path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	ActionMkdir  = "mkdir"
+	ActionCopy   = "copy"
+	ActionDelete = "delete"
+)
+
+// mtimeTolerance is the max difference of modified times that are treated as equal,
+// some storages only keep the modified time in seconds
+const mtimeTolerance = 2 * time.Second
+
+// Action is one step to make the destination in sync with the source.
+// Paths are mount paths, DstPath of a copy action is the dir to copy into.
+type Action struct {
+	Op      string `json:"op"`
+	SrcPath string `json:"src_path,omitempty"`
+	DstPath string `json:"dst_path"`
+	Size    int64  `json:"size"`
+	Reason  string `json:"reason"`
+	// rel is the path relative to the job paths, obj is the obj on the from
+	// side, and copied is the file on the other side after it's copied
+	rel    string
+	from   string
+	obj    model.Obj
+	copied model.Obj
+}
+
+type side struct {
+	name       string
+	mountPath  string
+	storage    driver.Driver
+	actualPath string
+	objs       map[string]model.Obj // relative path -> obj
+}
+
+// plan is the result of a comparison, synced are the objs that are the
+// same on both sides already
+type plan struct {
+	actions []Action
+	synced  map[string]syncedFile
+}
+
+// Diff compares the source and destination of the job and returns the
+// actions needed to sync them, nothing is changed
+func Diff(ctx context.Context, job *model.SyncJob) ([]Action, error) {
+	p, err := diff(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	return p.actions, nil
+}
+
+func diff(ctx context.Context, job *model.SyncJob) (*plan, error) {
+	f := newFilter(job.Include, job.Exclude)
+	src, err := walk(ctx, "src", job.SrcPath, f)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed walk src")
+	}
+	dst, err := walk(ctx, "dst", job.DstPath, f)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed walk dst")
+	}
+	var last map[string]syncedFile
+	if job.Mode == model.SyncModeBidirectional {
+		if last, err = getSyncedFiles(job.ID); err != nil {
+			return nil, err
+		}
+	}
+	p := &plan{synced: map[string]syncedFile{}}
+	for _, rel := range sortedKeys(src.objs) {
+		s := src.objs[rel]
+		d, ok := dst.objs[rel]
+		switch {
+		case !ok:
+			continue
+		case s.IsDir() != d.IsDir():
+			// it's not sure which one should be kept, leave it to the user
+			continue
+		case s.IsDir():
+			p.synced[rel] = syncedFile{}
+		case job.Mode == model.SyncModeCopy:
+			continue
+		default:
+			newer, reason := compare(s, d)
+			switch {
+			case newer == 0:
+				p.synced[rel] = syncedFile{Src: newFileState(s), Dst: newFileState(d)}
+			case newer > 0 || job.Mode == model.SyncModeMirror:
+				// the mirror must be exact, a changed dst is overwritten even if it's newer
+				p.actions = append(p.actions, newAction(src, dst, rel, s, reason))
+			default:
+				p.actions = append(p.actions, newAction(dst, src, rel, d, reason))
+			}
+		}
+	}
+	p.addOneSided(src, dst, job.Mode, last)
+	p.addOneSided(dst, src, job.Mode, last)
+	return p, nil
+}
+
+// addOneSided adds the actions for the objs that only exist in from.
+// In the bidirectional mode an obj that was in sync in the last run has been
+// deleted on the other side, so it's deleted too unless it's changed since
+// then or a dir with new or changed objs in it, the change is kept by copying
+// it to the other side like the new objs.
+func (p *plan) addOneSided(from, to *side, mode string, last map[string]syncedFile) {
+	// dirs that have objs not synced in the last run or changed since then
+	keep := map[string]bool{}
+	if last != nil {
+		for rel, obj := range from.objs {
+			if f, ok := last[rel]; ok && !f.changed(from.name, obj) {
+				continue
+			}
+			for dir := stdpath.Dir(rel); dir != "."; dir = stdpath.Dir(dir) {
+				keep[dir] = true
+			}
+		}
+	}
+	var deleted []string
+	for _, rel := range sortedKeys(from.objs) {
+		if _, ok := to.objs[rel]; ok {
+			continue
+		}
+		// the parent dir is deleted already
+		if len(deleted) > 0 && strings.HasPrefix(rel, deleted[len(deleted)-1]+"/") {
+			continue
+		}
+		obj := from.objs[rel]
+		remove, reason := false, "missing in "+to.name
+		switch mode {
+		case model.SyncModeCopy:
+			if from.name != "src" {
+				continue
+			}
+		case model.SyncModeMirror:
+			remove = from.name != "src"
+		case model.SyncModeBidirectional:
+			if f, synced := last[rel]; synced {
+				if f.changed(from.name, obj) {
+					reason = "changed in " + from.name + " but deleted in " + to.name
+				} else {
+					remove, reason = !keep[rel], "deleted in "+to.name
+				}
+			}
+		}
+		if !remove {
+			p.actions = append(p.actions, newAction(from, to, rel, obj, reason))
+			continue
+		}
+		deleted = append(deleted, rel)
+		p.actions = append(p.actions, Action{
+			Op:      ActionDelete,
+			DstPath: stdpath.Join(from.mountPath, rel),
+			Size:    obj.GetSize(),
+			Reason:  reason,
+			rel:     rel,
+		})
+	}
+}
+
+func newAction(from, to *side, rel string, obj model.Obj, reason string) Action {
+	if obj.IsDir() {
+		return Action{
+			Op:      ActionMkdir,
+			DstPath: stdpath.Join(to.mountPath, rel),
+			Reason:  reason,
+			rel:     rel,
+		}
+	}
+	return Action{
+		Op:      ActionCopy,
+		SrcPath: stdpath.Join(from.mountPath, rel),
+		DstPath: stdpath.Dir(stdpath.Join(to.mountPath, rel)),
+		Size:    obj.GetSize(),
+		Reason:  reason,
+		rel:     rel,
+		from:    from.name,
+		obj:     obj,
+	}
+}
+
+// compare returns 0 if s and d are the same, otherwise 1 if s is newer and -1 if d is newer.
+// The hashes are trusted if both sides have a hash of the same type, otherwise the modified time decides.
+func compare(s, d model.Obj) (int, string) {
+	newer := 1
+	if d.ModTime().After(s.ModTime().Add(mtimeTolerance)) {
+		newer = -1
+	}
+	if s.GetSize() != d.GetSize() {
+		return newer, "size changed"
+	}
+	for ht, sh := range s.GetHash().Export() {
+		if dh := d.GetHash().GetHash(ht); dh != "" && sh != "" {
+			if strings.EqualFold(sh, dh) {
+				return 0, ""
+			}
+			return newer, ht.Name + " changed"
+		}
+	}
+	mtimeDiff := s.ModTime().Sub(d.ModTime())
+	if mtimeDiff > mtimeTolerance {
+		return 1, "src is newer"
+	}
+	if mtimeDiff < -mtimeTolerance {
+		return -1, "dst is newer"
+	}
+	return 0, ""
+}
+
+func walk(ctx context.Context, name, mountPath string, f *filter) (*side, error) {
+	mountPath = utils.FixAndCleanPath(mountPath)
+	storage, actualPath, err := op.GetStorageAndActualPath(mountPath)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get storage of [%s]", mountPath)
+	}
+	res := &side{name: name, mountPath: mountPath, storage: storage, actualPath: actualPath, objs: map[string]model.Obj{}}
+	dirs := []string{""}
+	for len(dirs) > 0 {
+		if utils.IsCanceled(ctx) {
+			return nil, ctx.Err()
+		}
+		dir := dirs[0]
+		dirs = dirs[1:]
+		objs, err := op.List(ctx, storage, stdpath.Join(actualPath, dir), model.ListArgs{}, true)
+		if err != nil {
+			// the root of the dst may not exist yet
+			if dir == "" && errs.IsObjectNotFound(err) {
+				return res, nil
+			}
+			return nil, errors.WithMessagef(err, "failed list [%s]", stdpath.Join(mountPath, dir))
+		}
+		for _, obj := range objs {
+			rel := strings.TrimPrefix(stdpath.Join(dir, obj.GetName()), "/")
+			if !f.match(rel, obj.IsDir()) {
+				continue
+			}
+			res.objs[rel] = obj
+			if obj.IsDir() {
+				dirs = append(dirs, rel)
+			}
+		}
+	}
+	return res, nil
+}
+
+func sortedKeys(m map[string]model.Obj) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// filter matches relative paths with glob patterns, a pattern without "/"
+// is matched against the name only
+type filter struct {
+	include, exclude []string
+}
+
+func newFilter(include, exclude string) *filter {
+	return &filter{include: splitPatterns(include), exclude: splitPatterns(exclude)}
+}
+
+func splitPatterns(s string) []string {
+	var res []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			res = append(res, line)
+		}
+	}
+	return res
+}
+
+func (f *filter) match(rel string, isDir bool) bool {
+	if matchAny(f.exclude, rel) {
+		return false
+	}
+	// dirs are always walked, so that the files in them can be included
+	return isDir || len(f.include) == 0 || matchAny(f.include, rel)
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		target := rel
+		if !strings.Contains(p, "/") {
+			target = stdpath.Base(rel)
+		}
+		if ok, _ := stdpath.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func validatePatterns(s string) error {
+	for _, p := range splitPatterns(s) {
+		if _, err := stdpath.Match(p, ""); err != nil {
+			return errors.Errorf("invalid pattern [%s]", p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package sync_job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+func TestCompare(t *testing.T) {
+	now := time.Now()
+	md5 := func(s string) utils.HashInfo { return utils.NewHashInfo(utils.MD5, s) }
+	tests := []struct {
+		name string
+		s, d model.Object
+		want int
+	}{
+		{"same", model.Object{Size: 1, Modified: now}, model.Object{Size: 1, Modified: now.Add(time.Second)}, 0},
+		{"src newer", model.Object{Size: 1, Modified: now.Add(time.Hour)}, model.Object{Size: 1, Modified: now}, 1},
+		{"dst newer", model.Object{Size: 1, Modified: now}, model.Object{Size: 1, Modified: now.Add(time.Hour)}, -1},
+		{"size changed", model.Object{Size: 1, Modified: now}, model.Object{Size: 2, Modified: now}, 1},
+		{"same hash", model.Object{Size: 1, Modified: now.Add(time.Hour), HashInfo: md5("AB")}, model.Object{Size: 1, Modified: now, HashInfo: md5("ab")}, 0},
+		{"hash changed", model.Object{Size: 1, Modified: now, HashInfo: md5("ab")}, model.Object{Size: 1, Modified: now, HashInfo: md5("cd")}, 1},
+	}
+	for _, tt := range tests {
+		if got, _ := compare(&tt.s, &tt.d); got != tt.want {
+			t.Errorf("%s: compare() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	f := newFilter("*.mp4\nmusic/*.flac", "tmp\n*.part")
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"a.mp4", false, true},
+		{"video/a.mp4", false, true},
+		{"a.txt", false, false},
+		{"music/a.flac", false, true},
+		{"other/a.flac", false, false},
+		{"video", true, true},
+		{"video/tmp", true, false},
+		{"a.mp4.part", false, false},
+	}
+	for _, tt := range tests {
+		if got := f.match(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("match(%s) = %v, want %v", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestAddOneSided(t *testing.T) {
+	now := time.Now()
+	src := &side{name: "src", mountPath: "/src", objs: map[string]model.Obj{
+		"new.txt":    &model.Object{Size: 1},
+		"old.txt":    &model.Object{Size: 1, Modified: now},
+		"edited.txt": &model.Object{Size: 2, Modified: now},
+		"dir":        &model.Object{IsFolder: true},
+		"dir/a.txt":  &model.Object{Size: 1, Modified: now},
+		"dir/b.txt":  &model.Object{Size: 1},
+		"dir2":       &model.Object{IsFolder: true},
+		"dir2/a.txt": &model.Object{Size: 1, Modified: now.Add(time.Hour)},
+	}}
+	dst := &side{name: "dst", mountPath: "/dst", objs: map[string]model.Obj{}}
+	synced := func(size int64, modified time.Time) syncedFile {
+		return syncedFile{Src: newFileState(&model.Object{Size: size, Modified: modified})}
+	}
+	last := map[string]syncedFile{
+		"old.txt":    synced(1, now),
+		"edited.txt": synced(1, now.Add(-time.Hour)),
+		"dir":        {},
+		"dir/a.txt":  synced(1, now),
+		"dir2":       {},
+		"dir2/a.txt": synced(1, now),
+	}
+	p := &plan{}
+	p.addOneSided(src, dst, model.SyncModeBidirectional, last)
+	got := map[string]string{}
+	for _, a := range p.actions {
+		got[a.rel] = a.Op
+	}
+	want := map[string]string{
+		// dir has a new file in it, so it's kept
+		"dir":       ActionMkdir,
+		"dir/a.txt": ActionDelete,
+		"dir/b.txt": ActionCopy,
+		// the file edited in src and deleted in dst is kept with the dir of it
+		"dir2":       ActionMkdir,
+		"dir2/a.txt": ActionCopy,
+		"edited.txt": ActionCopy,
+		"new.txt":    ActionCopy,
+		"old.txt":    ActionDelete,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got actions %v, want %v", got, want)
+	}
+	for rel, op := range want {
+		if got[rel] != op {
+			t.Errorf("%s: got %s, want %s", rel, got[rel], op)
+		}
+	}
+}
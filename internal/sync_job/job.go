@@ -0,0 +1,136 @@
+package sync_job
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/cron"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	timers   = map[uint]*time.Timer{}
+	timersMu sync.Mutex
+)
+
+// Init schedules all enabled jobs
+func Init() error {
+	jobs, err := db.GetEnabledSyncJobs()
+	if err != nil {
+		return err
+	}
+	for i := range jobs {
+		schedule(&jobs[i])
+	}
+	return nil
+}
+
+func CreateJob(job *model.SyncJob) error {
+	if err := validate(job); err != nil {
+		return err
+	}
+	if err := db.CreateSyncJob(job); err != nil {
+		return errors.WithMessage(err, "failed create sync job in database")
+	}
+	schedule(job)
+	return nil
+}
+
+func UpdateJob(job *model.SyncJob) error {
+	old, err := db.GetSyncJobById(job.ID)
+	if err != nil {
+		return errors.WithMessage(err, "failed get old sync job")
+	}
+	if err := validate(job); err != nil {
+		return err
+	}
+	if err := db.UpdateSyncJob(job); err != nil {
+		return errors.WithMessage(err, "failed update sync job in database")
+	}
+	// the files synced last time mean nothing to the new paths
+	if old.SrcPath != job.SrcPath || old.DstPath != job.DstPath || old.Mode != job.Mode ||
+		old.Include != job.Include || old.Exclude != job.Exclude {
+		if err := db.DeleteSyncState(job.ID); err != nil {
+			return errors.WithMessage(err, "failed reset sync state")
+		}
+	}
+	schedule(job)
+	return nil
+}
+
+func DeleteJob(id uint) error {
+	unschedule(id)
+	return db.DeleteSyncJobById(id)
+}
+
+func validate(job *model.SyncJob) error {
+	job.SrcPath = utils.FixAndCleanPath(job.SrcPath)
+	job.DstPath = utils.FixAndCleanPath(job.DstPath)
+	if job.Mode == "" {
+		job.Mode = model.SyncModeMirror
+	}
+	switch job.Mode {
+	case model.SyncModeMirror, model.SyncModeCopy, model.SyncModeBidirectional:
+	default:
+		return errors.Errorf("unknown sync mode [%s]", job.Mode)
+	}
+	if utils.IsSubPath(job.SrcPath, job.DstPath) || utils.IsSubPath(job.DstPath, job.SrcPath) {
+		return errors.New("src and dst can't contain each other")
+	}
+	if job.Cron != "" {
+		if _, err := cron.ParseSchedule(job.Cron); err != nil {
+			return err
+		}
+	}
+	if err := validatePatterns(job.Include); err != nil {
+		return err
+	}
+	return validatePatterns(job.Exclude)
+}
+
+// schedule (re)starts the timer of the job for its next run
+func schedule(job *model.SyncJob) {
+	unschedule(job.ID)
+	if job.Disabled || job.Cron == "" {
+		return
+	}
+	s, err := cron.ParseSchedule(job.Cron)
+	if err != nil {
+		log.Errorf("failed parse cron of sync job [%s]: %+v", job.Name, err)
+		return
+	}
+	next := s.Next(time.Now())
+	if next.IsZero() {
+		return
+	}
+	id := job.ID
+	timersMu.Lock()
+	defer timersMu.Unlock()
+	timers[id] = time.AfterFunc(time.Until(next), func() {
+		// the job may have been changed since it's scheduled
+		job, err := db.GetSyncJobById(id)
+		if err != nil {
+			log.Warnf("sync job %d not found, stop scheduling it", id)
+			return
+		}
+		if _, ok := running.Load(id); ok {
+			log.Warnf("sync job [%s] is still running, skip this run", job.Name)
+		} else if !job.Disabled {
+			Run(job, false)
+		}
+		schedule(job)
+	})
+}
+
+func unschedule(id uint) {
+	timersMu.Lock()
+	defer timersMu.Unlock()
+	if t, ok := timers[id]; ok {
+		t.Stop()
+		delete(timers, id)
+	}
+}
@@ -0,0 +1,110 @@
+package sync_job
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// syncedFile is an obj in sync after the last run, the states of the files
+// on both sides tell whether they are changed since then, they are nil for
+// the dirs and the files whose state is unknown
+type syncedFile struct {
+	Src *fileState `json:"src,omitempty"`
+	Dst *fileState `json:"dst,omitempty"`
+}
+
+type fileState struct {
+	Size     int64 `json:"size"`
+	Modified int64 `json:"modified"` // unix seconds
+}
+
+func newFileState(obj model.Obj) *fileState {
+	if obj == nil || obj.IsDir() {
+		return nil
+	}
+	return &fileState{Size: obj.GetSize(), Modified: obj.ModTime().Unix()}
+}
+
+func (f *syncedFile) set(sideName string, obj model.Obj) {
+	if sideName == "src" {
+		f.Src = newFileState(obj)
+	} else {
+		f.Dst = newFileState(obj)
+	}
+}
+
+// changed returns whether the file on the side is changed since the last run,
+// the file of the unknown state is seen as changed so that it's never lost
+func (f syncedFile) changed(sideName string, obj model.Obj) bool {
+	if obj.IsDir() {
+		return false
+	}
+	st := f.Src
+	if sideName != "src" {
+		st = f.Dst
+	}
+	if st == nil || st.Size != obj.GetSize() {
+		return true
+	}
+	d := time.Duration(obj.ModTime().Unix()-st.Modified) * time.Second
+	return d > mtimeTolerance || d < -mtimeTolerance
+}
+
+// getSyncedFiles returns the objs that were in sync after the last run of
+// the job by the relative paths, nil if it has never run
+func getSyncedFiles(jobId uint) (map[string]syncedFile, error) {
+	state, err := db.GetSyncState(jobId)
+	if err != nil || state == nil {
+		return nil, err
+	}
+	var files map[string]syncedFile
+	if err := utils.Json.UnmarshalFromString(state.Files, &files); err == nil {
+		return files, nil
+	}
+	// the state saved by the old version only has the paths
+	var paths []string
+	if err := utils.Json.UnmarshalFromString(state.Files, &paths); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshal sync state")
+	}
+	files = make(map[string]syncedFile, len(paths))
+	for _, p := range paths {
+		files[p] = syncedFile{}
+	}
+	return files, nil
+}
+
+// saveSyncedFiles records the objs in sync after a run, the failed
+// actions are left out so that they are retried by the next run
+func saveSyncedFiles(jobId uint, p *plan, done []Action) error {
+	files := make(map[string]syncedFile, len(p.synced)+len(done))
+	for rel, f := range p.synced {
+		files[rel] = f
+	}
+	for _, a := range done {
+		if a.Op == ActionDelete {
+			continue
+		}
+		var f syncedFile
+		if a.Op == ActionCopy {
+			f.set(a.from, a.obj)
+			f.set(otherSide(a.from), a.copied)
+		}
+		files[a.rel] = f
+	}
+	data, err := utils.Json.MarshalToString(files)
+	if err != nil {
+		return errors.Wrapf(err, "failed marshal sync state")
+	}
+	return db.SaveSyncState(&model.SyncState{JobID: jobId, Files: data})
+}
+
+func otherSide(name string) string {
+	if name == "src" {
+		return "dst"
+	}
+	return "src"
+}
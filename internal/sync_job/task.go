@@ -0,0 +1,188 @@
+package sync_job
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/tache"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxRunErrors is the max number of errors kept in the history of a run
+const maxRunErrors = 20
+
+type SyncTask struct {
+	tache.Base
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	JobID  uint   `json:"job_id"`
+	DryRun bool   `json:"dry_run"`
+}
+
+func (t *SyncTask) GetName() string {
+	return t.Name
+}
+
+func (t *SyncTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *SyncTask) OnFailed() {
+	result := fmt.Sprintf("%s:%s", t.GetName(), t.GetErr())
+	log.Debug(result)
+	go op.Notify("同步任务结果", result)
+}
+
+func (t *SyncTask) OnSucceeded() {
+	result := fmt.Sprintf("%s成功", t.GetName())
+	log.Debug(result)
+	go op.Notify("同步任务结果", result)
+}
+
+func (t *SyncTask) Run() error {
+	job, err := db.GetSyncJobById(t.JobID)
+	if err != nil {
+		return err
+	}
+	if _, loaded := running.LoadOrStore(job.ID, struct{}{}); loaded {
+		return errors.Errorf("sync job [%s] is already running", job.Name)
+	}
+	defer running.Delete(job.ID)
+	run := &model.SyncRun{
+		JobID:     job.ID,
+		DryRun:    t.DryRun,
+		Status:    model.SyncRunRunning,
+		StartedAt: time.Now(),
+	}
+	if err = db.CreateSyncRun(run); err != nil {
+		return err
+	}
+	err = t.sync(job, run)
+	run.FinishedAt = time.Now()
+	run.Status = model.SyncRunSucceeded
+	if err != nil {
+		run.Status = model.SyncRunFailed
+		run.Error = strings.TrimSpace(err.Error() + "\n" + run.Error)
+	}
+	if e := db.UpdateSyncRun(run); e != nil {
+		log.Errorf("failed update sync run: %+v", e)
+	}
+	return err
+}
+
+func (t *SyncTask) sync(job *model.SyncJob, run *model.SyncRun) error {
+	t.Status = "comparing"
+	p, err := diff(t.Ctx(), job)
+	if err != nil {
+		return err
+	}
+	if t.DryRun {
+		for _, a := range p.actions {
+			countAction(run, a)
+		}
+		t.Status = fmt.Sprintf("dry run, %d copy, %d delete", run.Copied, run.Deleted)
+		t.SetProgress(100)
+		return nil
+	}
+	var errs []string
+	var done []Action
+	for i, a := range p.actions {
+		if utils.IsCanceled(t.Ctx()) {
+			return t.Ctx().Err()
+		}
+		t.Status = fmt.Sprintf("%s [%s]", a.Op, a.DstPath)
+		if err := execute(t.Ctx(), a); err != nil {
+			run.Failed++
+			if len(errs) < maxRunErrors {
+				errs = append(errs, err.Error())
+			}
+		} else {
+			countAction(run, a)
+			if a.Op == ActionCopy && job.Mode == model.SyncModeBidirectional {
+				a.copied = copiedFile(t.Ctx(), a)
+			}
+			done = append(done, a)
+		}
+		t.SetProgress(float64(i+1) / float64(len(p.actions)) * 100)
+	}
+	if job.Mode == model.SyncModeBidirectional {
+		if err := saveSyncedFiles(job.ID, p, done); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	run.Error = strings.Join(errs, "\n")
+	t.Status = fmt.Sprintf("synced, %d copied, %d deleted, %d failed", run.Copied, run.Deleted, run.Failed)
+	t.SetProgress(100)
+	if run.Failed > 0 {
+		return errors.Errorf("%d actions failed", run.Failed)
+	}
+	return nil
+}
+
+func countAction(run *model.SyncRun, a Action) {
+	switch a.Op {
+	case ActionCopy:
+		run.Copied++
+	case ActionDelete:
+		run.Deleted++
+	}
+}
+
+func execute(ctx context.Context, a Action) error {
+	dstStorage, dstActualPath, err := op.GetStorageAndActualPath(a.DstPath)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get storage of [%s]", a.DstPath)
+	}
+	switch a.Op {
+	case ActionMkdir:
+		err = op.MakeDir(ctx, dstStorage, dstActualPath)
+	case ActionDelete:
+		err = op.Remove(ctx, dstStorage, dstActualPath)
+	case ActionCopy:
+		err = fs.CopyFile(ctx, a.SrcPath, a.DstPath, model.ConflictOverwrite)
+	default:
+		err = errors.Errorf("unknown action")
+	}
+	return errors.WithMessagef(err, "failed %s [%s]", a.Op, a.DstPath)
+}
+
+// copiedFile gets the file just copied for the sync state, it's nil if
+// failed, then the file is seen as changed by the next run
+func copiedFile(ctx context.Context, a Action) model.Obj {
+	obj, err := fs.Get(ctx, stdpath.Join(a.DstPath, stdpath.Base(a.SrcPath)), &fs.GetArgs{})
+	if err != nil {
+		log.Warnf("failed get the copied file of [%s]: %+v", a.SrcPath, err)
+		return nil
+	}
+	return obj
+}
+
+var TaskManager *tache.Manager[*SyncTask]
+
+// running records the ids of the jobs being run, a job never runs concurrently
+var running sync.Map
+
+// Run adds a task to run the job
+func Run(job *model.SyncJob, dryRun bool) tache.TaskWithInfo {
+	name := fmt.Sprintf("sync [%s] %s to %s", job.Name, job.SrcPath, job.DstPath)
+	if dryRun {
+		name += " (dry run)"
+	}
+	t := &SyncTask{
+		Name:   name,
+		JobID:  job.ID,
+		DryRun: dryRun,
+	}
+	TaskManager.Add(t)
+	return t
+}
@@ -0,0 +1,264 @@
+// Package tus stores the resumable uploads of the tus protocol, the received
+// data and the info of an upload are kept in the temp dir, so that the upload
+// can be resumed after the server restarts
+package tus
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	stdpath "path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/tache"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// DirName is the dir of the uploads in the temp dir, it's kept when the temp
+// dir is cleaned on start
+const DirName = "tus"
+
+// Expiration is how long an upload is kept after the last received data
+const Expiration = 24 * time.Hour
+
+var (
+	ErrNotFound       = errors.New("upload not found")
+	ErrOffsetMismatch = errors.New("upload offset mismatch")
+	ErrLocked         = errors.New("upload is locked by another request")
+)
+
+// Upload is a resumable upload of the user
+type Upload struct {
+	ID        string               `json:"id"`
+	UserID    uint                 `json:"user_id"`
+	Path      string               `json:"path"` // the path of the file to put
+	Size      int64                `json:"size"`
+	Mimetype  string               `json:"mimetype"`
+	Modified  time.Time            `json:"modified"`
+	Metadata  string               `json:"metadata"` // the raw Upload-Metadata of the creation
+	Conflict  model.ConflictPolicy `json:"conflict"`
+	ExpiresAt time.Time            `json:"expires_at"`
+	Offset    int64                `json:"-"` // the size of the received data
+}
+
+var (
+	locks     sync.Map // id -> *sync.Mutex
+	sweepMu   sync.Mutex
+	lastSweep time.Time
+)
+
+func dir() string {
+	return filepath.Join(conf.Conf.TempDir, DirName)
+}
+
+func infoPath(id string) string {
+	return filepath.Join(dir(), id, "info.json")
+}
+
+func dataPath(id string) string {
+	return filepath.Join(dir(), id, "data")
+}
+
+// putPath is the data file being put by the task after the upload is completed
+func putPath(id string) string {
+	return filepath.Join(dir(), id, "put")
+}
+
+func lock(id string) (*sync.Mutex, bool) {
+	mu, _ := locks.LoadOrStore(id, &sync.Mutex{})
+	m := mu.(*sync.Mutex)
+	return m, m.TryLock()
+}
+
+// Create saves the info of the upload and creates the empty data file
+func Create(u *Upload) error {
+	sweep()
+	u.ID = random.String(32)
+	u.Offset = 0
+	u.ExpiresAt = time.Now().Add(Expiration)
+	if err := os.MkdirAll(filepath.Join(dir(), u.ID), 0o700); err != nil {
+		return errors.WithStack(err)
+	}
+	f, err := os.Create(dataPath(u.ID))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_ = f.Close()
+	return save(u)
+}
+
+func save(u *Upload) error {
+	data, err := json.Marshal(u)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(infoPath(u.ID), data, 0o600))
+}
+
+// Get returns the upload with the offset, the expired upload is removed
+func Get(id string) (*Upload, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(infoPath(id))
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	var u Upload
+	if err = json.Unmarshal(data, &u); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if time.Now().After(u.ExpiresAt) && !putting(id) {
+		_ = Remove(id)
+		return nil, ErrNotFound
+	}
+	info, err := os.Stat(dataPath(id))
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	u.Offset = info.Size()
+	return &u, nil
+}
+
+func validID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Write appends the data at the offset, the data received before the error
+// is kept so that the client can resume from there
+func Write(u *Upload, offset int64, r io.Reader) error {
+	mu, ok := lock(u.ID)
+	if !ok {
+		return ErrLocked
+	}
+	defer mu.Unlock()
+	info, err := os.Stat(dataPath(u.ID))
+	if err != nil {
+		return ErrNotFound
+	}
+	u.Offset = info.Size()
+	if offset != u.Offset {
+		return ErrOffsetMismatch
+	}
+	f, err := os.OpenFile(dataPath(u.ID), os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	n, err := io.Copy(f, io.LimitReader(r, u.Size-u.Offset))
+	u.Offset += n
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if n > 0 {
+		u.ExpiresAt = time.Now().Add(Expiration)
+		if serr := save(u); err == nil {
+			err = serr
+		}
+	}
+	return errors.WithStack(err)
+}
+
+// Remove removes the upload and the received data
+func Remove(id string) error {
+	if !validID(id) {
+		return ErrNotFound
+	}
+	locks.Delete(id)
+	return errors.WithStack(os.RemoveAll(filepath.Join(dir(), id)))
+}
+
+// Complete puts the received data as an upload task. The data file is renamed
+// in the upload dir, so that the upload can't be written or completed again,
+// and it's removed when the task finishes. It's restored if the task can't
+// be added, then the client can complete the upload again.
+func Complete(u *Upload) (tache.TaskWithInfo, error) {
+	mu, ok := lock(u.ID)
+	if !ok {
+		return nil, ErrLocked
+	}
+	defer mu.Unlock()
+	if err := os.Rename(dataPath(u.ID), putPath(u.ID)); err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, errors.WithStack(err)
+	}
+	// the time of the put file tells how long the task has run
+	now := time.Now()
+	_ = os.Chtimes(putPath(u.ID), now, now)
+	restore := func() {
+		if err := os.Rename(putPath(u.ID), dataPath(u.ID)); err != nil {
+			log.Errorf("failed restore tus upload %s: %+v", u.ID, err)
+		}
+	}
+	f, err := os.Open(putPath(u.ID))
+	if err != nil {
+		restore()
+		return nil, errors.WithStack(err)
+	}
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     stdpath.Base(u.Path),
+			Size:     u.Size,
+			Modified: u.Modified,
+		},
+		Mimetype: u.Mimetype,
+		Conflict: u.Conflict,
+	}
+	s.SetTmpFile(f)
+	t, err := fs.PutAsTask(stdpath.Dir(u.Path), s)
+	if err != nil {
+		// s.Close would remove the data
+		_ = f.Close()
+		restore()
+		return nil, err
+	}
+	return t, nil
+}
+
+// putting returns whether the data of the upload is being put by a task,
+// the upload is removed by the sweep after the task removes the data
+func putting(id string) bool {
+	info, err := os.Stat(putPath(id))
+	return err == nil && time.Since(info.ModTime()) < Expiration
+}
+
+// sweep removes the expired uploads at most once per hour
+func sweep() {
+	sweepMu.Lock()
+	if time.Since(lastSweep) < time.Hour {
+		sweepMu.Unlock()
+		return
+	}
+	lastSweep = time.Now()
+	sweepMu.Unlock()
+	entries, err := os.ReadDir(dir())
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if putting(e.Name()) {
+			continue
+		}
+		// Get removes the expired upload
+		if _, err = Get(e.Name()); errors.Is(err, ErrNotFound) {
+			_ = Remove(e.Name())
+		}
+	}
+}
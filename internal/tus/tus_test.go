@@ -0,0 +1,71 @@
+package tus
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+)
+
+func TestWriteResume(t *testing.T) {
+	conf.Conf = conf.DefaultConfig()
+	conf.Conf.TempDir = t.TempDir()
+	u := &Upload{UserID: 1, Path: "/local/a.txt", Size: 10}
+	if err := Create(u); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(u, 0, strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	// the upload is loaded again as after a restart
+	got, err := Get(u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Offset != 5 || got.Path != u.Path || got.Size != 10 {
+		t.Fatalf("unexpected upload: %+v", got)
+	}
+	if err = Write(got, 3, strings.NewReader("world")); !errors.Is(err, ErrOffsetMismatch) {
+		t.Fatalf("expect offset mismatch, got %v", err)
+	}
+	// the data beyond the upload length is dropped
+	if err = Write(got, 5, strings.NewReader("world!!")); err != nil {
+		t.Fatal(err)
+	}
+	if got.Offset != 10 {
+		t.Fatalf("expect offset 10, got %d", got.Offset)
+	}
+	if err = Remove(u.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Get(u.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect not found, got %v", err)
+	}
+	if _, err = Get("../" + u.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect not found, got %v", err)
+	}
+}
+
+func TestCompleteFailed(t *testing.T) {
+	conf.Conf = conf.DefaultConfig()
+	conf.Conf.TempDir = t.TempDir()
+	u := &Upload{UserID: 1, Path: "/not_mounted/a.txt", Size: 5}
+	if err := Create(u); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(u, 0, strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	// the upload is kept so that it can be completed again
+	if _, err := Complete(u); err == nil {
+		t.Fatal("expect the task can't be added")
+	}
+	got, err := Get(u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Offset != 5 {
+		t.Fatalf("expect offset 5, got %d", got.Offset)
+	}
+}
@@ -0,0 +1,38 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	base := time.Date(2024, 2, 28, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{spec: "* * * * *", want: time.Date(2024, 2, 28, 10, 31, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", want: time.Date(2024, 2, 28, 10, 45, 0, 0, time.UTC)},
+		{spec: "0 3 * * *", want: time.Date(2024, 2, 29, 3, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", want: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 9 * * 1-5", want: time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * 7", want: time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{spec: "@monthly", want: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "@every 90m", want: base.Add(90 * time.Minute)},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("failed to parse %s: %+v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(base); !got.Equal(tt.want) {
+			t.Errorf("next of %s: expected %s, got %s", tt.spec, tt.want, got)
+		}
+	}
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "5-1 * * * *", "@every 1ms", "*/0 * * * *"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("expected error when parsing %q", spec)
+		}
+	}
+}
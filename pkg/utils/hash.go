@@ -10,6 +10,7 @@ import (
 	"errors"
 	"hash"
 	"io"
+	"strings"
 
 	"github.com/alist-org/alist/v3/internal/errs"
 	log "github.com/sirupsen/logrus"
@@ -226,3 +227,31 @@ func (hi HashInfo) GetHash(ht *HashType) string {
 func (hi HashInfo) Export() map[*HashType]string {
 	return hi.h
 }
+
+// CompareHashInfo compares the hashes of the types that a and b both have,
+// ok is false if they have no hash type in common
+func CompareHashInfo(a, b HashInfo) (equal, ok bool) {
+	for ht, v := range a.h {
+		if w := b.h[ht]; v != "" && w != "" {
+			if !strings.EqualFold(v, w) {
+				return false, true
+			}
+			ok = true
+		}
+	}
+	return ok, ok
+}
+
+// Merge returns the hashes of both, the one of hi is kept if both have a type
+func (hi HashInfo) Merge(other HashInfo) HashInfo {
+	h := make(map[*HashType]string, len(hi.h)+len(other.h))
+	for k, v := range other.h {
+		h[k] = v
+	}
+	for k, v := range hi.h {
+		if v != "" {
+			h[k] = v
+		}
+	}
+	return HashInfo{h: h}
+}
@@ -64,3 +64,15 @@ func TestMultiHasher(t *testing.T) {
 
 	}
 }
+
+func TestCompareHashInfo(t *testing.T) {
+	a := NewHashInfoByMap(map[*HashType]string{MD5: "BF13FC19E5151AC57D4252E0E0F87ABE", SHA1: "3ab6543c08a75f292a5ecedac87ec41642d12166"})
+	equal, ok := CompareHashInfo(a, NewHashInfo(MD5, "bf13fc19e5151ac57d4252e0e0f87abe"))
+	assert.True(t, equal)
+	assert.True(t, ok)
+	equal, ok = CompareHashInfo(a, NewHashInfo(SHA1, "da39a3ee5e6b4b0d3255bfef95601890afd80709"))
+	assert.False(t, equal)
+	assert.True(t, ok)
+	_, ok = CompareHashInfo(a, NewHashInfo(SHA256, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"))
+	assert.False(t, ok)
+}
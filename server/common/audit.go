@@ -0,0 +1,47 @@
+package common
+
+import (
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+const auditKey = "audit"
+
+// Audit returns the audit log of the request for the handler to fill the
+// details, a discarded one is returned if the route is not audited
+func Audit(c *gin.Context) *model.AuditLog {
+	if l, ok := c.Value(auditKey).(*model.AuditLog); ok {
+		return l
+	}
+	return &model.AuditLog{}
+}
+
+// SetAudit sets the audit log of the request, the error response marks it failed
+func SetAudit(c *gin.Context, l *model.AuditLog) {
+	c.Set(auditKey, l)
+}
+
+// AuditPath sets the src and dst paths of the audit log of the request
+func AuditPath(c *gin.Context, src, dst string) {
+	l := Audit(c)
+	l.SrcPath, l.DstPath = src, dst
+}
+
+// JoinNames joins the names in the dir as the src paths of the audit log
+func JoinNames(dir string, names []string) string {
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		paths = append(paths, stdpath.Join(dir, name))
+	}
+	return strings.Join(paths, "\n")
+}
+
+func auditFailed(c *gin.Context, msg string) {
+	if l, ok := c.Value(auditKey).(*model.AuditLog); ok {
+		l.Success = false
+		l.Message = msg
+	}
+}
@@ -24,6 +24,12 @@ func CanWrite(meta *model.Meta, path string) bool {
 	return meta.WSub || meta.Path == path
 }
 
+// CanWritePath checks the write right of the user at the path, the permission
+// of the user and the meta is used if no acl entry applies
+func CanWritePath(user *model.User, meta *model.Meta, reqPath string) bool {
+	return HasRight(user, reqPath, model.ACLWrite, user.CanWrite() || CanWrite(meta, reqPath))
+}
+
 func IsApply(metaPath, reqPath string, applySub bool) bool {
 	if utils.PathEqual(metaPath, reqPath) {
 		return true
@@ -31,7 +37,20 @@ func IsApply(metaPath, reqPath string, applySub bool) bool {
 	return utils.IsSubPath(metaPath, reqPath) && applySub
 }
 
+// HasRight checks the right of the user at the path by the acl, the fallback
+// which is the legacy permission is returned if no acl entry applies
+func HasRight(user *model.User, reqPath string, right int32, fallback bool) bool {
+	if allowed, matched := op.CheckACL(user, reqPath, right); matched {
+		return allowed
+	}
+	return fallback
+}
+
 func CanAccess(user *model.User, meta *model.Meta, reqPath string, password string) bool {
+	// the read right denied by the acl can't be granted by the meta
+	if !HasRight(user, reqPath, model.ACLRead, true) {
+		return false
+	}
 	// if the reqPath is in hide (only can check the nearest meta) and user can't see hides, can't access
 	if meta != nil && !user.CanSeeHides() && meta.Hide != "" &&
 		IsApply(meta.Path, path.Dir(reqPath), meta.HSub) { // the meta should apply to the parent of current path
@@ -45,9 +45,11 @@ func ErrorWithDataResp(c *gin.Context, err error, code int, data interface{}, l
 			log.Errorf("%v", err)
 		}
 	}
+	msg := hidePrivacy(err.Error())
+	auditFailed(c, msg)
 	c.JSON(200, Resp[interface{}]{
 		Code:    code,
-		Message: hidePrivacy(err.Error()),
+		Message: msg,
 		Data:    data,
 	})
 	c.Abort()
@@ -57,9 +59,11 @@ func ErrorStrResp(c *gin.Context, str string, code int, l ...bool) {
 	if len(l) != 0 && l[0] {
 		log.Error(str)
 	}
+	msg := hidePrivacy(str)
+	auditFailed(c, msg)
 	c.JSON(200, Resp[interface{}]{
 		Code:    code,
-		Message: hidePrivacy(str),
+		Message: msg,
 		Data:    nil,
 	})
 	c.Abort()
@@ -0,0 +1,601 @@
+package ftp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+type command struct {
+	// fn handles the command, the connection is closed if it returns true
+	fn    func(c *conn, arg string) bool
+	login bool // the command needs login
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"USER": {fn: cmdUser},
+		"PASS": {fn: cmdPass},
+		"AUTH": {fn: cmdAuth},
+		"PBSZ": {fn: cmdPbsz},
+		"PROT": {fn: cmdProt},
+		"FEAT": {fn: cmdFeat},
+		"OPTS": {fn: cmdOpts},
+		"SYST": {fn: cmdSyst},
+		"NOOP": {fn: cmdNoop},
+		"QUIT": {fn: cmdQuit},
+		"PWD":  {fn: cmdPwd, login: true},
+		"XPWD": {fn: cmdPwd, login: true},
+		"CWD":  {fn: cmdCwd, login: true},
+		"XCWD": {fn: cmdCwd, login: true},
+		"CDUP": {fn: cmdCdup, login: true},
+		"XCUP": {fn: cmdCdup, login: true},
+		"TYPE": {fn: cmdType, login: true},
+		"MODE": {fn: cmdMode, login: true},
+		"STRU": {fn: cmdStru, login: true},
+		"ALLO": {fn: cmdAllo, login: true},
+		"PASV": {fn: cmdPasv, login: true},
+		"EPSV": {fn: cmdEpsv, login: true},
+		"LIST": {fn: cmdList, login: true},
+		"NLST": {fn: cmdNlst, login: true},
+		"MLSD": {fn: cmdMlsd, login: true},
+		"MLST": {fn: cmdMlst, login: true},
+		"SIZE": {fn: cmdSize, login: true},
+		"MDTM": {fn: cmdMdtm, login: true},
+		"REST": {fn: cmdRest, login: true},
+		"RETR": {fn: cmdRetr, login: true},
+		"STOR": {fn: cmdStor, login: true},
+		"DELE": {fn: cmdDele, login: true},
+		"MKD":  {fn: cmdMkd, login: true},
+		"XMKD": {fn: cmdMkd, login: true},
+		"RMD":  {fn: cmdRmd, login: true},
+		"XRMD": {fn: cmdRmd, login: true},
+		"RNFR": {fn: cmdRnfr, login: true},
+		"RNTO": {fn: cmdRnto, login: true},
+		"ABOR": {fn: cmdAbor, login: true},
+	}
+}
+
+func cmdUser(c *conn, arg string) bool {
+	if c.server.conf.ForceTLS && !c.tls {
+		c.reply(530, "TLS is required, use AUTH TLS first")
+		return false
+	}
+	c.username = arg
+	c.user = nil
+	c.reply(331, "Password required for "+arg)
+	return false
+}
+
+func cmdPass(c *conn, arg string) bool {
+	if c.username == "" {
+		c.reply(503, "Login with USER first")
+		return false
+	}
+	if err := c.login(c.username, arg); err != nil {
+		// slow down the brute force of the password
+		time.Sleep(time.Second)
+		c.reply(530, "Login incorrect")
+		return false
+	}
+	c.reply(230, "User logged in")
+	return false
+}
+
+func cmdAuth(c *conn, arg string) bool {
+	if c.server.tlsConfig == nil {
+		c.reply(502, "TLS is not enabled")
+		return false
+	}
+	if t := strings.ToUpper(arg); t != "TLS" && t != "SSL" {
+		c.reply(504, "Unsupported security mechanism")
+		return false
+	}
+	if c.tls {
+		c.reply(503, "TLS is already in use")
+		return false
+	}
+	c.reply(234, "AUTH TLS successful")
+	if err := c.upgradeTLS(); err != nil {
+		return true
+	}
+	return false
+}
+
+func cmdPbsz(c *conn, arg string) bool {
+	if !c.tls {
+		c.reply(503, "Use AUTH TLS first")
+		return false
+	}
+	c.reply(200, "PBSZ=0")
+	return false
+}
+
+func cmdProt(c *conn, arg string) bool {
+	if !c.tls {
+		c.reply(503, "Use AUTH TLS first")
+		return false
+	}
+	switch strings.ToUpper(arg) {
+	case "P":
+		c.prot = true
+	case "C":
+		if c.server.conf.ForceTLS {
+			c.reply(521, "Data connections must be protected")
+			return false
+		}
+		c.prot = false
+	default:
+		c.reply(504, "Unsupported protection level")
+		return false
+	}
+	c.reply(200, "Protection level set to "+strings.ToUpper(arg))
+	return false
+}
+
+func cmdFeat(c *conn, arg string) bool {
+	feats := []string{"UTF8", "SIZE", "MDTM", "REST STREAM", "EPSV", "PASV",
+		"MLST type*;size*;modify*;"}
+	if c.server.tlsConfig != nil {
+		feats = append(feats, "AUTH TLS", "PBSZ", "PROT")
+	}
+	c.replyLines(211, "Features:", feats, "End")
+	return false
+}
+
+func cmdOpts(c *conn, arg string) bool {
+	if strings.HasPrefix(strings.ToUpper(arg), "UTF8") {
+		c.reply(200, "UTF8 is always on")
+		return false
+	}
+	c.reply(501, "Unsupported option")
+	return false
+}
+
+func cmdSyst(c *conn, arg string) bool {
+	c.reply(215, "UNIX Type: L8")
+	return false
+}
+
+func cmdNoop(c *conn, arg string) bool {
+	c.reply(200, "OK")
+	return false
+}
+
+func cmdQuit(c *conn, arg string) bool {
+	c.reply(221, "Goodbye")
+	return true
+}
+
+func cmdPwd(c *conn, arg string) bool {
+	c.reply(257, fmt.Sprintf("%q is the current directory", c.cwd))
+	return false
+}
+
+func cmdCwd(c *conn, arg string) bool {
+	reqPath, err := c.realPath(arg)
+	if err != nil || !c.hasRight(reqPath, model.ACLRead) {
+		c.reply(550, "Permission denied")
+		return false
+	}
+	obj, err := fs.Get(c.ctx, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil || !obj.IsDir() {
+		c.reply(550, "No such directory")
+		return false
+	}
+	c.cwd = c.path(arg)
+	c.reply(250, "Directory changed to "+c.cwd)
+	return false
+}
+
+func cmdCdup(c *conn, arg string) bool {
+	return cmdCwd(c, "..")
+}
+
+func cmdType(c *conn, arg string) bool {
+	// the files are always transferred as binary
+	c.reply(200, "Type set to "+strings.ToUpper(arg))
+	return false
+}
+
+func cmdMode(c *conn, arg string) bool {
+	if strings.ToUpper(arg) != "S" {
+		c.reply(504, "Only stream mode is supported")
+		return false
+	}
+	c.reply(200, "Mode set to S")
+	return false
+}
+
+func cmdStru(c *conn, arg string) bool {
+	if strings.ToUpper(arg) != "F" {
+		c.reply(504, "Only file structure is supported")
+		return false
+	}
+	c.reply(200, "Structure set to F")
+	return false
+}
+
+func cmdAllo(c *conn, arg string) bool {
+	c.reply(202, "No storage allocation necessary")
+	return false
+}
+
+func (c *conn) listenPassive() (int, bool) {
+	if c.server.conf.ForceTLS && !c.prot {
+		c.reply(521, "Data connections must be protected, use PROT P first")
+		return 0, false
+	}
+	if c.pasv != nil {
+		_ = c.pasv.Close()
+		c.pasv = nil
+	}
+	l, err := c.server.listenPassive()
+	if err != nil {
+		c.reply(425, "Can't open passive connection: "+err.Error())
+		return 0, false
+	}
+	c.pasv = l
+	return l.Addr().(*net.TCPAddr).Port, true
+}
+
+func cmdPasv(c *conn, arg string) bool {
+	ip := net.ParseIP(c.server.conf.PublicHost)
+	if ip == nil && c.server.conf.PublicHost != "" {
+		if addrs, err := net.LookupIP(c.server.conf.PublicHost); err == nil && len(addrs) > 0 {
+			ip = addrs[0]
+		}
+	}
+	if ip == nil {
+		ip = c.nc.LocalAddr().(*net.TCPAddr).IP
+	}
+	ip = ip.To4()
+	if ip == nil {
+		c.reply(425, "PASV is not available for ipv6, use EPSV")
+		return false
+	}
+	port, ok := c.listenPassive()
+	if !ok {
+		return false
+	}
+	c.reply(227, fmt.Sprintf("Entering Passive Mode (%d,%d,%d,%d,%d,%d)",
+		ip[0], ip[1], ip[2], ip[3], port>>8, port&0xff))
+	return false
+}
+
+func cmdEpsv(c *conn, arg string) bool {
+	port, ok := c.listenPassive()
+	if !ok {
+		return false
+	}
+	c.reply(229, fmt.Sprintf("Entering Extended Passive Mode (|||%d|)", port))
+	return false
+}
+
+// listDir lists the dir of the arg, the flags like -la are ignored
+func (c *conn) listDir(arg string) ([]model.Obj, bool) {
+	var args []string
+	for _, a := range strings.Fields(arg) {
+		if !strings.HasPrefix(a, "-") {
+			args = append(args, a)
+		}
+	}
+	reqPath, err := c.realPath(strings.Join(args, " "))
+	if err != nil || !c.hasRight(reqPath, model.ACLList) {
+		c.reply(550, "Permission denied")
+		return nil, false
+	}
+	obj, err := fs.Get(c.ctx, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		c.reply(550, "No such file or directory")
+		return nil, false
+	}
+	if !obj.IsDir() {
+		return []model.Obj{obj}, true
+	}
+	meta, _ := op.GetNearestMeta(reqPath)
+	objs, err := fs.List(context.WithValue(c.ctx, "meta", meta), reqPath, &fs.ListArgs{})
+	if err != nil {
+		c.reply(550, err.Error())
+		return nil, false
+	}
+	res := make([]model.Obj, 0, len(objs))
+	for _, o := range objs {
+		if c.hasRight(stdpath.Join(reqPath, o.GetName()), model.ACLRead) {
+			res = append(res, o)
+		}
+	}
+	return res, true
+}
+
+func cmdList(c *conn, arg string) bool {
+	objs, ok := c.listDir(arg)
+	if !ok {
+		return false
+	}
+	c.transfer(func(ctx context.Context, dc net.Conn) error {
+		return writeLines(dc, objs, formatList)
+	})
+	return false
+}
+
+func cmdNlst(c *conn, arg string) bool {
+	objs, ok := c.listDir(arg)
+	if !ok {
+		return false
+	}
+	c.transfer(func(ctx context.Context, dc net.Conn) error {
+		return writeLines(dc, objs, model.Obj.GetName)
+	})
+	return false
+}
+
+func cmdMlsd(c *conn, arg string) bool {
+	objs, ok := c.listDir(arg)
+	if !ok {
+		return false
+	}
+	c.transfer(func(ctx context.Context, dc net.Conn) error {
+		return writeLines(dc, objs, func(obj model.Obj) string {
+			return formatFacts(obj) + " " + obj.GetName()
+		})
+	})
+	return false
+}
+
+func cmdMlst(c *conn, arg string) bool {
+	obj, ok := c.getFile(arg, false)
+	if !ok {
+		return false
+	}
+	c.replyLines(250, "Listing "+c.path(arg), []string{formatFacts(obj) + " " + c.path(arg)}, "End")
+	return false
+}
+
+// getFile gets the obj of the arg, it must be a file if file is true
+func (c *conn) getFile(arg string, file bool) (model.Obj, bool) {
+	reqPath, err := c.realPath(arg)
+	if err != nil || !c.hasRight(reqPath, model.ACLRead) {
+		c.reply(550, "Permission denied")
+		return nil, false
+	}
+	obj, err := fs.Get(c.ctx, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		c.reply(550, "No such file or directory")
+		return nil, false
+	}
+	if file && obj.IsDir() {
+		c.reply(550, "Not a regular file")
+		return nil, false
+	}
+	return obj, true
+}
+
+func cmdSize(c *conn, arg string) bool {
+	if obj, ok := c.getFile(arg, true); ok {
+		c.reply(213, strconv.FormatInt(obj.GetSize(), 10))
+	}
+	return false
+}
+
+func cmdMdtm(c *conn, arg string) bool {
+	if obj, ok := c.getFile(arg, true); ok {
+		c.reply(213, obj.ModTime().UTC().Format("20060102150405"))
+	}
+	return false
+}
+
+func cmdRest(c *conn, arg string) bool {
+	offset, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || offset < 0 {
+		c.reply(501, "Invalid offset")
+		return false
+	}
+	c.restOffset = offset
+	c.reply(350, fmt.Sprintf("Restarting at %d", offset))
+	return false
+}
+
+func cmdRetr(c *conn, arg string) bool {
+	offset := c.restOffset
+	c.restOffset = 0
+	obj, ok := c.getFile(arg, true)
+	if !ok {
+		return false
+	}
+	reqPath, _ := c.realPath(arg)
+	if offset > obj.GetSize() {
+		c.reply(551, "Offset is beyond the end of the file")
+		return false
+	}
+	c.transfer(func(ctx context.Context, dc net.Conn) error {
+		rc, err := openFile(ctx, reqPath, obj.GetSize(), offset)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		_, err = utils.CopyWithBuffer(dc, rc)
+		return err
+	})
+	return false
+}
+
+func cmdStor(c *conn, arg string) bool {
+	offset := c.restOffset
+	c.restOffset = 0
+	if offset != 0 {
+		c.reply(554, "Resuming upload is not supported")
+		return false
+	}
+	reqPath, err := c.realPath(arg)
+	if err != nil || !c.hasRight(reqPath, model.ACLWrite) {
+		c.reply(550, "Permission denied")
+		return false
+	}
+	dir, name := stdpath.Split(reqPath)
+	c.transfer(func(ctx context.Context, dc net.Conn) error {
+		err := putFile(ctx, dir, name, dc)
+		c.audit("put", reqPath, "", err)
+		return err
+	})
+	return false
+}
+
+func cmdDele(c *conn, arg string) bool {
+	reqPath, err := c.realPath(arg)
+	if err != nil || !c.hasRight(reqPath, model.ACLDelete) {
+		c.reply(550, "Permission denied")
+		return false
+	}
+	obj, err := fs.Get(c.ctx, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil || obj.IsDir() {
+		c.reply(550, "No such file")
+		return false
+	}
+	err = fs.Remove(c.ctx, reqPath)
+	c.audit("delete", reqPath, "", err)
+	if err != nil {
+		c.reply(550, err.Error())
+		return false
+	}
+	c.reply(250, "File deleted")
+	return false
+}
+
+func cmdMkd(c *conn, arg string) bool {
+	reqPath, err := c.realPath(arg)
+	if err != nil || !c.hasRight(reqPath, model.ACLWrite) {
+		c.reply(550, "Permission denied")
+		return false
+	}
+	err = fs.MakeDir(c.ctx, reqPath)
+	c.audit("mkdir", reqPath, "", err)
+	if err != nil {
+		c.reply(550, err.Error())
+		return false
+	}
+	c.reply(257, fmt.Sprintf("%q created", c.path(arg)))
+	return false
+}
+
+func cmdRmd(c *conn, arg string) bool {
+	reqPath, err := c.realPath(arg)
+	if err != nil || !c.hasRight(reqPath, model.ACLDelete) {
+		c.reply(550, "Permission denied")
+		return false
+	}
+	obj, err := fs.Get(c.ctx, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil || !obj.IsDir() {
+		c.reply(550, "No such directory")
+		return false
+	}
+	objs, err := fs.List(c.ctx, reqPath, &fs.ListArgs{NoLog: true})
+	if err != nil {
+		c.reply(550, err.Error())
+		return false
+	}
+	if len(objs) > 0 {
+		c.reply(550, "Directory not empty")
+		return false
+	}
+	err = fs.Remove(c.ctx, reqPath)
+	c.audit("rmdir", reqPath, "", err)
+	if err != nil {
+		c.reply(550, err.Error())
+		return false
+	}
+	c.reply(250, "Directory removed")
+	return false
+}
+
+func cmdRnfr(c *conn, arg string) bool {
+	reqPath, err := c.realPath(arg)
+	if err != nil || !c.hasRight(reqPath, model.ACLDelete) {
+		c.reply(550, "Permission denied")
+		return false
+	}
+	if _, err = fs.Get(c.ctx, reqPath, &fs.GetArgs{NoLog: true}); err != nil {
+		c.reply(550, "No such file or directory")
+		return false
+	}
+	c.renameFrom = reqPath
+	c.reply(350, "Ready for RNTO")
+	return false
+}
+
+func cmdRnto(c *conn, arg string) bool {
+	src := c.renameFrom
+	c.renameFrom = ""
+	if src == "" {
+		c.reply(503, "Use RNFR first")
+		return false
+	}
+	dst, err := c.realPath(arg)
+	if err != nil || !c.hasRight(dst, model.ACLWrite) {
+		c.reply(550, "Permission denied")
+		return false
+	}
+	err = move(c.ctx, src, dst)
+	c.audit("move", src, dst, err)
+	if err != nil {
+		c.reply(550, err.Error())
+		return false
+	}
+	c.reply(250, "Renamed")
+	return false
+}
+
+func cmdAbor(c *conn, arg string) bool {
+	// the transfer replies 426 before it's done
+	if c.abortTransfer() {
+		c.reply(226, "Abort successful")
+	} else {
+		c.reply(226, "No transfer to abort")
+	}
+	return false
+}
+
+func writeLines(w io.Writer, objs []model.Obj, format func(model.Obj) string) error {
+	var sb strings.Builder
+	for _, obj := range objs {
+		sb.WriteString(format(obj))
+		sb.WriteString("\r\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// formatList formats the obj like ls -l
+func formatList(obj model.Obj) string {
+	mode := "-rw-r--r--"
+	if obj.IsDir() {
+		mode = "drwxr-xr-x"
+	}
+	t := obj.ModTime()
+	stamp := t.Format("Jan _2 15:04")
+	if time.Since(t) > 180*24*time.Hour || t.After(time.Now().Add(time.Hour)) {
+		stamp = t.Format("Jan _2  2006")
+	}
+	return fmt.Sprintf("%s 1 alist alist %12d %s %s", mode, obj.GetSize(), stamp, obj.GetName())
+}
+
+// formatFacts formats the facts of MLSD and MLST
+func formatFacts(obj model.Obj) string {
+	typ := "file"
+	if obj.IsDir() {
+		typ = "dir"
+	}
+	return fmt.Sprintf("type=%s;size=%d;modify=%s;", typ, obj.GetSize(), obj.ModTime().UTC().Format("20060102150405"))
+}
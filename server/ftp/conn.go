@@ -0,0 +1,348 @@
+package ftp
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	stdpath "path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const dataTimeout = 30 * time.Second
+
+// conn is a control connection of a client
+type conn struct {
+	server *Server
+	mu     sync.Mutex
+	nc     net.Conn
+	text   *textproto.Conn
+	tls    bool // the control connection is secured by AUTH TLS
+	prot   bool // the data connections are secured, set by PROT P
+	closed bool
+	wmu    sync.Mutex // the replies of the transfer are sent by another goroutine
+
+	// the transfer running in background, ABOR cancels it
+	cancelTransfer context.CancelFunc
+	transferDone   chan struct{}
+
+	username   string // set by USER and used by PASS
+	user       *model.User
+	ctx        context.Context
+	cwd        string // the current dir relative to the base path of the user
+	pasv       net.Listener
+	restOffset int64
+	renameFrom string
+}
+
+func newConn(s *Server, nc net.Conn) *conn {
+	return &conn{
+		server: s,
+		nc:     nc,
+		text:   textproto.NewConn(nc),
+		cwd:    "/",
+	}
+}
+
+func (c *conn) serve() {
+	defer c.close()
+	c.reply(220, "Welcome to alist ftp server")
+	for {
+		if c.server.conf.IdleTimeout > 0 {
+			_ = c.nc.SetReadDeadline(time.Now().Add(time.Duration(c.server.conf.IdleTimeout) * time.Second))
+		}
+		line, err := c.text.ReadLine()
+		if err != nil {
+			// the control connection is idle during a long transfer
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() && c.transferring() {
+				continue
+			}
+			if !errors.Is(err, io.EOF) && !c.isClosed() {
+				log.Debugf("[ftp] read command from %s: %v", c.nc.RemoteAddr(), err)
+			}
+			return
+		}
+		name, arg, _ := strings.Cut(line, " ")
+		name = strings.ToUpper(name)
+		cmd, ok := commands[name]
+		if !ok {
+			c.reply(502, "Command not implemented")
+			continue
+		}
+		if cmd.login && c.user == nil {
+			c.reply(530, "Please login with USER and PASS")
+			continue
+		}
+		if name != "ABOR" && name != "QUIT" {
+			// the commands sent during a transfer are handled after it
+			c.waitTransfer()
+		}
+		if cmd.fn(c, arg) {
+			return
+		}
+	}
+}
+
+func (c *conn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func (c *conn) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	if c.pasv != nil {
+		_ = c.pasv.Close()
+	}
+	if c.cancelTransfer != nil {
+		c.cancelTransfer()
+	}
+	_ = c.nc.Close()
+}
+
+func (c *conn) reply(code int, msg string) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if err := c.text.PrintfLine("%d %s", code, msg); err != nil {
+		log.Debugf("[ftp] reply to %s: %v", c.nc.RemoteAddr(), err)
+	}
+}
+
+// replyLines sends a multi-line reply, the lines are prefixed with a space
+func (c *conn) replyLines(code int, first string, lines []string, last string) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	w := c.text.Writer.W
+	_, _ = fmt.Fprintf(w, "%d-%s\r\n", code, first)
+	for _, l := range lines {
+		_, _ = fmt.Fprintf(w, " %s\r\n", l)
+	}
+	_, _ = fmt.Fprintf(w, "%d %s\r\n", code, last)
+	_ = w.Flush()
+}
+
+// upgradeTLS secures the control connection after the reply of AUTH TLS
+func (c *conn) upgradeTLS() error {
+	tc := tls.Server(c.nc, c.server.tlsConfig)
+	if err := tc.Handshake(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.nc = tc
+	c.mu.Unlock()
+	c.text = textproto.NewConn(tc)
+	c.tls = true
+	return nil
+}
+
+// login authorizes the user by the password or an api token of the user,
+// the user must be able to read by webdav
+func (c *conn) login(username, password string) error {
+	var user *model.User
+	if username == "anonymous" {
+		guest, err := op.GetGuest()
+		if err != nil {
+			return err
+		}
+		user = guest
+	} else {
+		u, err := op.GetUserByName(username)
+		if err != nil {
+			return err
+		}
+		if err = u.ValidateRawPassword(password); err != nil {
+			if !strings.HasPrefix(password, model.APITokenPrefix) {
+				return err
+			}
+			if u, err = op.GetUserByAPIToken(password); err != nil {
+				return err
+			}
+			if u.Username != username {
+				return errs.WrongPassword
+			}
+		}
+		user = u
+	}
+	if user.Disabled || !user.CanWebdavRead() {
+		return errs.PermissionDenied
+	}
+	c.user = user
+	c.ctx = context.WithValue(context.Background(), "user", user)
+	return nil
+}
+
+// path returns the path of the arg relative to the base path of the user,
+// it's the path seen by the client
+func (c *conn) path(arg string) string {
+	if strings.HasPrefix(arg, "/") {
+		return stdpath.Clean(arg)
+	}
+	return stdpath.Join(c.cwd, arg)
+}
+
+// realPath returns the path of the arg in the alist file system
+func (c *conn) realPath(arg string) (string, error) {
+	return c.user.JoinPath(c.path(arg))
+}
+
+// hasRight checks the permissions like webdav, the user must be able to
+// manage by webdav to write
+func (c *conn) hasRight(reqPath string, right int32) bool {
+	if right != model.ACLRead && right != model.ACLList && !c.user.CanWebdavManage() {
+		return false
+	}
+	return common.HasRight(c.user, reqPath, right, true)
+}
+
+// openData waits for the client to connect the passive listener, which is
+// closed after that since it's used once or when the transfer is aborted.
+// The connections from other addresses than the client's are dropped, so
+// that the port can't be stolen.
+func (c *conn) openData(ctx context.Context, l net.Listener) (net.Conn, error) {
+	defer l.Close()
+	stop := context.AfterFunc(ctx, func() { _ = l.Close() })
+	defer stop()
+	if tl, ok := l.(*net.TCPListener); ok {
+		_ = tl.SetDeadline(time.Now().Add(dataTimeout))
+	}
+	var dc net.Conn
+	for {
+		var err error
+		if dc, err = l.Accept(); err != nil {
+			return nil, err
+		}
+		if sameIP(dc.RemoteAddr(), c.nc.RemoteAddr()) {
+			break
+		}
+		log.Warnf("ftp: drop the data connection from %s, the client is %s", dc.RemoteAddr(), c.nc.RemoteAddr())
+		_ = dc.Close()
+	}
+	if c.prot {
+		tc := tls.Server(dc, c.server.tlsConfig)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			_ = dc.Close()
+			return nil, err
+		}
+		return tc, nil
+	}
+	return dc, nil
+}
+
+func sameIP(a, b net.Addr) bool {
+	ta, ok1 := a.(*net.TCPAddr)
+	tb, ok2 := b.(*net.TCPAddr)
+	return ok1 && ok2 && ta.IP.Equal(tb.IP)
+}
+
+// transfer runs f with the data connection in background, so that the
+// control connection can read ABOR, the data connection is closed if the
+// transfer is aborted
+func (c *conn) transfer(f func(ctx context.Context, dc net.Conn) error) {
+	if c.pasv == nil {
+		c.reply(425, "Use PASV or EPSV first")
+		return
+	}
+	l := c.pasv
+	c.mu.Lock()
+	c.pasv = nil
+	ctx, cancel := context.WithCancel(c.ctx)
+	done := make(chan struct{})
+	c.cancelTransfer, c.transferDone = cancel, done
+	c.mu.Unlock()
+	c.reply(150, "Opening data connection")
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.cancelTransfer, c.transferDone = nil, nil
+			c.mu.Unlock()
+			cancel()
+			close(done)
+		}()
+		dc, err := c.openData(ctx, l)
+		if err != nil {
+			if ctx.Err() != nil {
+				c.reply(426, "Transfer aborted")
+			} else {
+				c.reply(425, "Can't open data connection: "+err.Error())
+			}
+			return
+		}
+		stop := context.AfterFunc(ctx, func() { _ = dc.Close() })
+		err = f(ctx, dc)
+		stop()
+		if cerr := dc.Close(); err == nil {
+			err = cerr
+		}
+		switch {
+		case ctx.Err() != nil:
+			c.reply(426, "Transfer aborted")
+		case err != nil:
+			c.reply(451, "Transfer aborted: "+err.Error())
+		default:
+			c.reply(226, "Transfer complete")
+		}
+	}()
+}
+
+func (c *conn) transferring() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.transferDone != nil
+}
+
+func (c *conn) waitTransfer() {
+	c.mu.Lock()
+	done := c.transferDone
+	c.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// abortTransfer cancels the transfer and waits for it to reply, it returns
+// false if there is no transfer
+func (c *conn) abortTransfer() bool {
+	c.mu.Lock()
+	cancel, done := c.cancelTransfer, c.transferDone
+	c.mu.Unlock()
+	if done == nil {
+		return false
+	}
+	cancel()
+	<-done
+	return true
+}
+
+// audit records the write of ftp like webdav
+func (c *conn) audit(action, src, dst string, err error) {
+	host, _, _ := net.SplitHostPort(c.nc.RemoteAddr().String())
+	l := &model.AuditLog{
+		Username: c.user.Username,
+		IP:       host,
+		Action:   "ftp." + action,
+		SrcPath:  src,
+		DstPath:  dst,
+		Success:  err == nil,
+	}
+	if err != nil {
+		l.Message = err.Error()
+	}
+	op.RecordAudit(l)
+}
@@ -0,0 +1,89 @@
+package ftp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// openFile opens the file from the offset to the end using the link of the file
+func openFile(ctx context.Context, reqPath string, size, offset int64) (io.ReadCloser, error) {
+	link, _, err := fs.Link(ctx, reqPath, model.LinkArgs{
+		Header: http.Header{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	lr := stream.NewLinkReader(ctx, link, size, 0)
+	rc, err := lr.RangeRead(offset)
+	if err != nil {
+		_ = lr.Close()
+		return nil, err
+	}
+	return readCloser{
+		Reader: rc,
+		close: func() error {
+			err := rc.Close()
+			if e := lr.Close(); err == nil {
+				err = e
+			}
+			return err
+		},
+	}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r readCloser) Close() error {
+	return r.close()
+}
+
+// move moves the src to dst like the MOVE method of webdav
+func move(ctx context.Context, src, dst string) error {
+	srcDir, srcName := stdpath.Split(src)
+	dstDir, dstName := stdpath.Split(dst)
+	if utils.PathEqual(srcDir, dstDir) {
+		return fs.Rename(ctx, src, dstName)
+	}
+	// wait for the cross-storage move to finish, since the client expects it done
+	if _, err := fs.Move(context.WithValue(ctx, conf.NoTaskKey, struct{}{}), src, dstDir); err != nil {
+		return err
+	}
+	if srcName != dstName {
+		return fs.Rename(ctx, stdpath.Join(dstDir, srcName), dstName)
+	}
+	return nil
+}
+
+// putFile uploads the data, it's cached in a temp file first since the size
+// is unknown until the data connection is closed
+func putFile(ctx context.Context, dir, name string, r io.Reader) error {
+	obj := &model.Object{
+		Name:     name,
+		Modified: time.Now(),
+	}
+	s := &stream.FileStream{
+		Ctx:      ctx,
+		Obj:      obj,
+		Reader:   r,
+		Mimetype: utils.GetMimeType(name),
+	}
+	if _, err := s.CacheFullInTempFile(); err != nil {
+		_ = s.Close()
+		return errors.WithMessage(err, "failed receive the file")
+	}
+	obj.Size = s.GetSize()
+	return fs.PutDirectly(ctx, dir, s)
+}
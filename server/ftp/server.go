@@ -0,0 +1,154 @@
+// Package ftp implements a ftp server for alist, the users log in with the
+// alist users and have the same permissions as webdav
+package ftp
+
+import (
+	"crypto/tls"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var ErrServerClosed = errors.New("ftp: server closed")
+
+type Server struct {
+	conf      conf.FTP
+	tlsConfig *tls.Config
+	listener  net.Listener
+
+	mu     sync.Mutex
+	conns  map[*conn]struct{}
+	closed bool
+}
+
+func NewServer(c conf.FTP) (*Server, error) {
+	s := &Server{
+		conf:  c,
+		conns: make(map[*conn]struct{}),
+	}
+	if c.TLS {
+		cert, err := tls.LoadX509KeyPair(conf.Conf.Scheme.CertFile, conf.Conf.Scheme.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed load cert for ftps")
+		}
+		s.tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+	return s, nil
+}
+
+// Serve listens on the address of the config and serves the connections
+// until the server is closed
+func (s *Server) Serve() error {
+	l, err := net.Listen("tcp", s.conf.Listen)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		_ = l.Close()
+		return ErrServerClosed
+	}
+	s.listener = l
+	s.mu.Unlock()
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return errors.WithStack(err)
+		}
+		c := newConn(s, nc)
+		if !s.track(c) {
+			_ = nc.Close()
+			return ErrServerClosed
+		}
+		go func() {
+			defer s.untrack(c)
+			c.serve()
+		}()
+	}
+}
+
+// Addr returns the address the server listens on, it's nil before Serve
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Close stops listening and closes all the connections
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for c := range s.conns {
+		c.close()
+	}
+	return err
+}
+
+func (s *Server) track(c *conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[c] = struct{}{}
+	return true
+}
+
+func (s *Server) untrack(c *conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, c)
+}
+
+// listenPassive listens on a port of the passive port range for a data connection
+func (s *Server) listenPassive() (net.Listener, error) {
+	host, _, err := net.SplitHostPort(s.conf.Listen)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	start, end := s.conf.PassivePortStart, s.conf.PassivePortEnd
+	if start <= 0 || end < start {
+		l, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+		return l, errors.WithStack(err)
+	}
+	n := end - start + 1
+	offset := rand.Intn(n)
+	for i := 0; i < n; i++ {
+		port := start + (offset+i)%n
+		l, err := net.Listen("tcp", net.JoinHostPort(host, fmt.Sprint(port)))
+		if err == nil {
+			return l, nil
+		}
+		log.Debugf("[ftp] passive port %d is unavailable: %v", port, err)
+	}
+	return nil, errors.Errorf("no available passive port in %d-%d", start, end)
+}
@@ -0,0 +1,260 @@
+package ftp_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/ftp"
+	client "github.com/jlaffaye/ftp"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+var addr string
+
+func TestMain(m *testing.M) {
+	root, _ := os.MkdirTemp("", "alist-ftp-*")
+	conf.Conf.TempDir, _ = os.MkdirTemp("", "alist-ftp-temp-*")
+	s, err := startServer(root)
+	if err != nil {
+		panic(err)
+	}
+	addr = s.Addr().String()
+	code := m.Run()
+	_ = s.Close()
+	_ = os.RemoveAll(root)
+	_ = os.RemoveAll(conf.Conf.TempDir)
+	os.Exit(code)
+}
+
+func startServer(root string) (*ftp.Server, error) {
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  `{"root_folder_path":"` + root + `"}`,
+	})
+	if err != nil {
+		return nil, err
+	}
+	users := []*model.User{
+		{Username: "writer", Permission: 1<<8 | 1<<9},
+		{Username: "reader", Permission: 1 << 8},
+	}
+	for _, u := range users {
+		u.BasePath = "/"
+		u.SetPassword("password")
+		if err = op.CreateUser(u); err != nil {
+			return nil, err
+		}
+	}
+	s, err := ftp.NewServer(conf.FTP{Listen: "127.0.0.1:0"})
+	if err != nil {
+		return nil, err
+	}
+	go s.Serve()
+	for s.Addr() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return s, nil
+}
+
+func login(t *testing.T, username string) *client.ServerConn {
+	c, err := client.Dial(addr, client.DialWithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Quit() })
+	if err = c.Login(username, "password"); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestServer(t *testing.T) {
+	c := login(t, "writer")
+
+	if err := c.ChangeDir("/local"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MakeDir("dir"); err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("hello alist ftp")
+	if err := c.Stor("dir/a.txt", bytes.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := c.List("/local/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "a.txt" || entries[0].Size != uint64(len(content)) {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	r, err := c.RetrFrom("dir/a.txt", 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	_ = r.Close()
+	if err != nil || !bytes.Equal(data, content[6:]) {
+		t.Fatalf("unexpected data %q: %v", data, err)
+	}
+	if err = c.Rename("dir/a.txt", "dir/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if size, err := c.FileSize("/local/dir/b.txt"); err != nil || size != int64(len(content)) {
+		t.Fatalf("unexpected size %d: %v", size, err)
+	}
+	if err = c.RemoveDir("dir"); err == nil {
+		t.Fatal("non-empty dir is removed")
+	}
+	if err = c.Delete("dir/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.RemoveDir("dir"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerPermission(t *testing.T) {
+	c := login(t, "reader")
+	if _, err := c.List("/local"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Stor("/local/a.txt", bytes.NewReader([]byte("a"))); err == nil {
+		t.Fatal("reader can upload")
+	}
+	if err := c.MakeDir("/local/dir"); err == nil {
+		t.Fatal("reader can mkdir")
+	}
+
+	bad, err := client.Dial(addr, client.DialWithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bad.Quit()
+	if err = bad.Login("reader", "wrong"); err == nil {
+		t.Fatal("login with wrong password")
+	}
+}
+
+func TestPassivePeer(t *testing.T) {
+	tc, err := textproto.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tc.Close()
+	cmd := func(code int, format string, args ...any) string {
+		if err := tc.PrintfLine(format, args...); err != nil {
+			t.Fatal(err)
+		}
+		_, msg, err := tc.ReadResponse(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+	if _, _, err = tc.ReadResponse(220); err != nil {
+		t.Fatal(err)
+	}
+	cmd(331, "USER reader")
+	cmd(230, "PASS password")
+	msg := cmd(229, "EPSV")
+	port := strings.TrimSuffix(msg[strings.Index(msg, "(|||")+4:], "|)")
+	dataAddr := net.JoinHostPort("127.0.0.1", port)
+	// another host connects the passive port first
+	intruder, err := (&net.Dialer{LocalAddr: &net.TCPAddr{IP: net.ParseIP("127.0.0.2")}}).Dial("tcp", dataAddr)
+	if err != nil {
+		t.Skipf("can't dial from another loopback address: %v", err)
+	}
+	defer intruder.Close()
+	if err = tc.PrintfLine("LIST /"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = tc.ReadResponse(150); err != nil {
+		t.Fatal(err)
+	}
+	_ = intruder.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if n, err := intruder.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Fatalf("the data connection of another host is not dropped: %d, %v", n, err)
+	}
+	dc, err := net.Dial("tcp", dataAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(dc)
+	_ = dc.Close()
+	if err != nil || !strings.Contains(string(data), "local") {
+		t.Fatalf("unexpected list %q: %v", data, err)
+	}
+	if _, _, err = tc.ReadResponse(226); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	tc, err := textproto.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tc.Close()
+	cmd := func(code int, format string, args ...any) string {
+		if err := tc.PrintfLine(format, args...); err != nil {
+			t.Fatal(err)
+		}
+		_, msg, err := tc.ReadResponse(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+	if _, _, err = tc.ReadResponse(220); err != nil {
+		t.Fatal(err)
+	}
+	cmd(331, "USER writer")
+	cmd(230, "PASS password")
+	msg := cmd(229, "EPSV")
+	port := strings.TrimSuffix(msg[strings.Index(msg, "(|||")+4:], "|)")
+	cmd(150, "STOR /local/abort.txt")
+	dc, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dc.Close()
+	// the upload never ends, it can only be aborted
+	if _, err = dc.Write([]byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+	if err = tc.PrintfLine("ABOR"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = tc.ReadResponse(426); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = tc.ReadResponse(226); err != nil {
+		t.Fatal(err)
+	}
+	cmd(550, "SIZE /local/abort.txt")
+}
@@ -0,0 +1,102 @@
+package handles
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateAPITokenReq struct {
+	Name       string     `json:"name" binding:"required"`
+	Scope      string     `json:"scope" binding:"required"`
+	PathPrefix string     `json:"path_prefix"`
+	Expires    *time.Time `json:"expires"`
+}
+
+// CreateAPIToken creates a token of the current user, the token is only
+// returned here and can't be got again
+func CreateAPIToken(c *gin.Context) {
+	var req CreateAPITokenReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if user.IsGuest() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	t := &model.APIToken{
+		Name:       req.Name,
+		Scope:      req.Scope,
+		PathPrefix: req.PathPrefix,
+		Expires:    req.Expires,
+	}
+	if err := op.CreateAPIToken(user, t); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"id":         t.ID,
+		"token":      t.Token,
+		"access_key": t.AccessKey,
+	})
+}
+
+// ListAPITokens lists the tokens of the current user
+func ListAPITokens(c *gin.Context) {
+	listAPITokens(c, c.MustGet("user").(*model.User).ID)
+}
+
+// ListAllAPITokens lists the tokens of all users for admin
+func ListAllAPITokens(c *gin.Context) {
+	listAPITokens(c, 0)
+}
+
+func listAPITokens(c *gin.Context, userId uint) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	tokens, total, err := op.GetAPITokens(userId, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: tokens,
+		Total:   total,
+	})
+}
+
+// DeleteAPIToken revokes the token, only the owner and admin can do it
+func DeleteAPIToken(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	t, err := op.GetAPITokenById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if !user.IsAdmin() && t.UserID != user.ID {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if err = op.DeleteAPITokenById(t.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,91 @@
+package handles
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type ListAuditLogsReq struct {
+	model.PageReq
+	model.AuditLogFilter
+}
+
+func ListAuditLogs(c *gin.Context) {
+	var req ListAuditLogsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	logs, total, err := db.GetAuditLogs(req.AuditLogFilter, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: logs,
+		Total:   total,
+	})
+}
+
+// ExportAuditLogs writes the filtered logs as csv, they are read in batches
+// so that the export of a large range doesn't load all logs into memory
+func ExportAuditLogs(c *gin.Context) {
+	var filter model.AuditLogFilter
+	if err := c.ShouldBind(&filter); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	const batchSize = 1000
+	logs, err := db.GetAuditLogsBeforeId(filter, 0, batchSize)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	filename := fmt.Sprintf("audit_%s.csv", time.Now().Format("20060102150405"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"id", "time", "username", "ip", "action", "src_path", "dst_path", "storage", "target", "success", "message"})
+	for len(logs) > 0 {
+		for _, l := range logs {
+			_ = w.Write([]string{
+				strconv.FormatUint(uint64(l.ID), 10),
+				l.CreatedAt.Format(time.RFC3339),
+				l.Username,
+				l.IP,
+				l.Action,
+				l.SrcPath,
+				l.DstPath,
+				l.Storage,
+				l.Target,
+				strconv.FormatBool(l.Success),
+				l.Message,
+			})
+		}
+		w.Flush()
+		if err = w.Error(); err != nil {
+			log.Errorf("failed write audit logs: %+v", err)
+			return
+		}
+		if len(logs) < batchSize {
+			break
+		}
+		logs, err = db.GetAuditLogsBeforeId(filter, logs[len(logs)-1].ID, batchSize)
+		if err != nil {
+			// the response has been started, the client gets a truncated csv
+			log.Errorf("failed get audit logs: %+v", err)
+			return
+		}
+	}
+}
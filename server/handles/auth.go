@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
@@ -89,7 +90,8 @@ func loginHash(c *gin.Context, req *LoginReq) {
 
 type UserResp struct {
 	model.User
-	Otp bool `json:"otp"`
+	Otp  bool  `json:"otp"`
+	Used int64 `json:"used"` // the usage of the base path, only counted if the user has a quota
 }
 
 // CurrentUser get current user by token
@@ -103,6 +105,14 @@ func CurrentUser(c *gin.Context) {
 	if userResp.OtpSecret != "" {
 		userResp.Otp = true
 	}
+	if user.Quota > 0 {
+		used, err := db.GetUserUsage(user.ID)
+		if err != nil {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+		userResp.Used = used
+	}
 	common.SuccessResp(c, userResp)
 }
 
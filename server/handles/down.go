@@ -26,7 +26,8 @@ func Down(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
-	if common.ShouldProxy(storage, filename) {
+	// the files in archives can only be streamed by alist
+	if common.ShouldProxy(storage, filename) || fs.IsInArchive(c, rawPath) {
 		Proxy(c)
 		return
 	} else {
@@ -73,7 +74,7 @@ func Proxy(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
-	if canProxy(storage, filename) {
+	if canProxy(storage, filename) || fs.IsInArchive(c, rawPath) {
 		downProxyUrl := storage.GetStorage().DownProxyUrl
 		if downProxyUrl != "" {
 			_, ok := c.GetQuery("d")
@@ -0,0 +1,184 @@
+package handles
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/archive"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type FsArchiveReq struct {
+	SrcDir   string   `json:"src_dir" form:"src_dir"`
+	Names    []string `json:"names" form:"names"`
+	Format   string   `json:"format" form:"format"`
+	Password string   `json:"password" form:"password"`
+}
+
+// FsArchive streams the selected files and dirs in src_dir as a zip or tar
+func FsArchive(c *gin.Context) {
+	var req FsArchiveReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	srcDir, err := user.JoinPath(req.SrcDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(srcDir)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	if !common.CanAccess(user, meta, srcDir, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	packFiles(c, user, srcDir, req.Names, req.Format, req.Password)
+}
+
+// ArchiveDown is the signed download of a dir or file as an archive, the
+// children to pack can be selected by the names query
+func ArchiveDown(c *gin.Context) {
+	rawPath := c.MustGet("path").(string)
+	guest, err := op.GetGuest()
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	// the sign is verified for the path, so the password of it is known
+	password := ""
+	if meta, _ := c.MustGet("meta").(*model.Meta); meta != nil {
+		password = meta.Password
+	}
+	dir, names := rawPath, c.QueryArray("names")
+	obj, err := fs.Get(c, rawPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if !obj.IsDir() {
+		dir, names = stdpath.Dir(rawPath), []string{obj.GetName()}
+	}
+	c.Set("user", guest)
+	packFiles(c, guest, dir, names, c.DefaultQuery("format", archive.TypeZip), password)
+}
+
+func packFiles(c *gin.Context, user *model.User, dir string, names []string, format, password string) {
+	format = utils.GetNoneEmpty(strings.ToLower(format), archive.TypeZip)
+	if format != archive.TypeZip && format != archive.TypeTar {
+		common.ErrorStrResp(c, "unsupported format: "+format, 400)
+		return
+	}
+	if len(names) == 0 {
+		objs, err := fs.List(c, dir, &fs.ListArgs{})
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		for _, obj := range objs {
+			names = append(names, obj.GetName())
+		}
+	}
+	objs := make([]model.Obj, 0, len(names))
+	for _, name := range names {
+		if name == "" || name == ".." || strings.Contains(name, "/") {
+			common.ErrorStrResp(c, "invalid name: "+name, 400)
+			return
+		}
+		obj, err := fs.Get(c, stdpath.Join(dir, name), &fs.GetArgs{})
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		objs = append(objs, obj)
+	}
+	filename := stdpath.Base(dir)
+	if len(objs) == 1 {
+		filename = objs[0].GetName()
+	}
+	if filename == "/" {
+		filename = "archive"
+	}
+	filename += "." + format
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, filename, url.PathEscape(filename)))
+	c.Header("Content-Type", archive.ContentType(format))
+	c.Status(http.StatusOK)
+	w, _ := archive.NewWriter(c.Writer, format)
+	for _, obj := range objs {
+		objPath := stdpath.Join(dir, obj.GetName())
+		err := fs.WalkFS(c, -1, objPath, obj, func(reqPath string, info model.Obj) error {
+			meta, _ := op.GetNearestMeta(reqPath)
+			if !common.CanAccess(user, meta, reqPath, password) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			name := strings.TrimPrefix(reqPath, utils.PathAddSeparatorSuffix(dir))
+			modified := info.ModTime()
+			if modified.IsZero() {
+				modified = time.Now()
+			}
+			if info.IsDir() {
+				return w.AddDir(name, modified)
+			}
+			return packFile(c, w, reqPath, name, info, modified)
+		})
+		if err != nil {
+			// the response has been started, the client gets a broken archive
+			log.Errorf("failed pack %s: %+v", objPath, err)
+			_ = c.Error(err)
+			return
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Errorf("failed close archive of %s: %+v", dir, err)
+	}
+}
+
+func packFile(ctx context.Context, w *archive.Writer, path, name string, obj model.Obj, modified time.Time) error {
+	link, _, err := fs.Link(ctx, path, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		return err
+	}
+	if link.RangeReadCloser != nil {
+		defer link.RangeReadCloser.Close()
+	}
+	// any link provided is seekable
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s] stream", path)
+	}
+	defer ss.Close()
+	r, err := ss.RangeRead(http_range.Range{Length: -1})
+	if err != nil {
+		return err
+	}
+	if rc, ok := r.(io.Closer); ok {
+		defer rc.Close()
+	}
+	return w.AddFile(name, obj.GetSize(), modified, r)
+}
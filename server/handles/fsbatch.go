@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"regexp"
 
+	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/generic"
+	"github.com/alist-org/alist/v3/pkg/tache"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
@@ -29,16 +31,16 @@ func FsBatchRename(c *gin.Context) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
-	if !user.CanRename() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
-
 	reqPath, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	common.AuditPath(c, reqPath, "")
+	if !common.HasRight(user, reqPath, model.ACLWrite, user.CanRename()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(reqPath)
 	if err != nil {
@@ -62,8 +64,9 @@ func FsBatchRename(c *gin.Context) {
 }
 
 type RecursiveMoveReq struct {
-	SrcDir string `json:"src_dir"`
-	DstDir string `json:"dst_dir"`
+	SrcDir   string `json:"src_dir"`
+	DstDir   string `json:"dst_dir"`
+	Conflict string `json:"conflict"`
 }
 
 func FsRecursiveMove(c *gin.Context) {
@@ -72,12 +75,13 @@ func FsRecursiveMove(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-
-	user := c.MustGet("user").(*model.User)
-	if !user.CanMove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
+	conflict, ok := parseConflictPolicy(c, req.Conflict)
+	if !ok {
 		return
 	}
+	c.Set(conf.ConflictPolicyKey, conflict)
+
+	user := c.MustGet("user").(*model.User)
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
@@ -88,6 +92,12 @@ func FsRecursiveMove(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	common.AuditPath(c, srcDir, dstDir)
+	if !common.HasRight(user, srcDir, model.ACLDelete, user.CanMove()) ||
+		!common.HasRight(user, dstDir, model.ACLWrite, user.CanMove()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(srcDir)
 	if err != nil {
@@ -112,6 +122,7 @@ func FsRecursiveMove(c *gin.Context) {
 		filePathMap[file] = srcDir
 	}
 
+	var addedTasks []tache.TaskWithInfo
 	for !movingFiles.IsEmpty() {
 
 		movingFile := movingFiles.Pop()
@@ -137,7 +148,10 @@ func FsRecursiveMove(c *gin.Context) {
 			}
 
 			// move
-			err := fs.Move(c, movingFileName, dstDir, movingFiles.IsEmpty())
+			t, err := fs.Move(c, movingFileName, dstDir, movingFiles.IsEmpty())
+			if t != nil {
+				addedTasks = append(addedTasks, t)
+			}
 			if err != nil {
 				common.ErrorResp(c, err, 500)
 				return
@@ -146,7 +160,9 @@ func FsRecursiveMove(c *gin.Context) {
 
 	}
 
-	common.SuccessResp(c)
+	common.SuccessResp(c, gin.H{
+		"tasks": getTaskInfos(addedTasks),
+	})
 }
 
 type RegexRenameReq struct {
@@ -162,16 +178,16 @@ func FsRegexRename(c *gin.Context) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
-	if !user.CanRename() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
-
 	reqPath, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	common.AuditPath(c, reqPath, "")
+	if !common.HasRight(user, reqPath, model.ACLWrite, user.CanRename()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(reqPath)
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 
 	"github.com/alist-org/alist/v3/pkg/tache"
 
+	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -36,19 +37,18 @@ func FsMkdir(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
-	if !user.CanWrite() {
-		meta, err := op.GetNearestMeta(stdpath.Dir(reqPath))
-		if err != nil {
-			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
-				common.ErrorResp(c, err, 500, true)
-				return
-			}
-		}
-		if !common.CanWrite(meta, reqPath) {
-			common.ErrorResp(c, errs.PermissionDenied, 403)
+	common.AuditPath(c, reqPath, "")
+	meta, err := op.GetNearestMeta(stdpath.Dir(reqPath))
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
 			return
 		}
 	}
+	if !common.CanWritePath(user, meta, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	if err := fs.MakeDir(c, reqPath); err != nil {
 		common.ErrorResp(c, err, 500)
 		return
@@ -57,9 +57,10 @@ func FsMkdir(c *gin.Context) {
 }
 
 type MoveCopyReq struct {
-	SrcDir string   `json:"src_dir"`
-	DstDir string   `json:"dst_dir"`
-	Names  []string `json:"names"`
+	SrcDir   string   `json:"src_dir"`
+	DstDir   string   `json:"dst_dir"`
+	Names    []string `json:"names"`
+	Conflict string   `json:"conflict"` // the conflict policy of the copy or move between storages
 }
 
 func FsMove(c *gin.Context) {
@@ -72,11 +73,12 @@ func FsMove(c *gin.Context) {
 		common.ErrorStrResp(c, "Empty file names", 400)
 		return
 	}
-	user := c.MustGet("user").(*model.User)
-	if !user.CanMove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
+	conflict, ok := parseConflictPolicy(c, req.Conflict)
+	if !ok {
 		return
 	}
+	c.Set(conf.ConflictPolicyKey, conflict)
+	user := c.MustGet("user").(*model.User)
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
@@ -87,14 +89,26 @@ func FsMove(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	common.AuditPath(c, common.JoinNames(srcDir, req.Names), dstDir)
+	if !common.HasRight(user, dstDir, model.ACLWrite, user.CanMove()) ||
+		!hasRightOfNames(user, srcDir, req.Names, model.ACLDelete, user.CanMove()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	var addedTasks []tache.TaskWithInfo
 	for i, name := range req.Names {
-		err := fs.Move(c, stdpath.Join(srcDir, name), dstDir, len(req.Names) > i+1)
+		t, err := fs.Move(c, stdpath.Join(srcDir, name), dstDir, len(req.Names) > i+1)
+		if t != nil {
+			addedTasks = append(addedTasks, t)
+		}
 		if err != nil {
 			common.ErrorResp(c, err, 500)
 			return
 		}
 	}
-	common.SuccessResp(c)
+	common.SuccessResp(c, gin.H{
+		"tasks": getTaskInfos(addedTasks),
+	})
 }
 
 func FsCopy(c *gin.Context) {
@@ -107,11 +121,12 @@ func FsCopy(c *gin.Context) {
 		common.ErrorStrResp(c, "Empty file names", 400)
 		return
 	}
-	user := c.MustGet("user").(*model.User)
-	if !user.CanCopy() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
+	conflict, ok := parseConflictPolicy(c, req.Conflict)
+	if !ok {
 		return
 	}
+	c.Set(conf.ConflictPolicyKey, conflict)
+	user := c.MustGet("user").(*model.User)
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
@@ -122,6 +137,12 @@ func FsCopy(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	common.AuditPath(c, common.JoinNames(srcDir, req.Names), dstDir)
+	if !common.HasRight(user, dstDir, model.ACLWrite, user.CanCopy()) ||
+		!hasRightOfNames(user, srcDir, req.Names, model.ACLRead, user.CanCopy()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	var addedTasks []tache.TaskWithInfo
 	for i, name := range req.Names {
 		t, err := fs.Copy(c, stdpath.Join(srcDir, name), dstDir, len(req.Names) > i+1)
@@ -138,6 +159,81 @@ func FsCopy(c *gin.Context) {
 	})
 }
 
+type ExtractReq struct {
+	SrcPath   string `json:"src_path"`
+	DstDir    string `json:"dst_dir"`
+	Password  string `json:"password"`
+	Overwrite string `json:"overwrite"`
+}
+
+func FsExtract(c *gin.Context) {
+	var req ExtractReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	srcPath, err := user.JoinPath(req.SrcPath)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	dstDir, err := user.JoinPath(req.DstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	common.AuditPath(c, srcPath, dstDir)
+	meta, err := op.GetNearestMeta(dstDir)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	if !common.HasRight(user, srcPath, model.ACLRead, true) || !common.CanWritePath(user, meta, dstDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	t, err := fs.Extract(c, srcPath, dstDir, req.Password, req.Overwrite)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
+
+type ComputeHashReq struct {
+	Path string `json:"path" form:"path"`
+}
+
+// FsComputeHash adds a task computing the hashes that the driver doesn't
+// provide, it reads all the files so it's just allowed for admin
+func FsComputeHash(c *gin.Context) {
+	var req ComputeHashReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	common.AuditPath(c, reqPath, "")
+	t, err := fs.ComputeHash(c, reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
+
 type RenameReq struct {
 	Path string `json:"path"`
 	Name string `json:"name"`
@@ -150,15 +246,16 @@ func FsRename(c *gin.Context) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
-	if !user.CanRename() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	reqPath, err := user.JoinPath(req.Path)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	common.AuditPath(c, reqPath, stdpath.Join(stdpath.Dir(reqPath), req.Name))
+	if !common.HasRight(user, reqPath, model.ACLWrite, user.CanRename()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	if err := fs.Rename(c, reqPath, req.Name); err != nil {
 		common.ErrorResp(c, err, 500)
 		return
@@ -182,15 +279,16 @@ func FsRemove(c *gin.Context) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
-	if !user.CanRemove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	reqDir, err := user.JoinPath(req.Dir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	common.AuditPath(c, common.JoinNames(reqDir, req.Names), "")
+	if !hasRightOfNames(user, reqDir, req.Names, model.ACLDelete, user.CanRemove()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	for _, name := range req.Names {
 		err := fs.Remove(c, stdpath.Join(reqDir, name))
 		if err != nil {
@@ -214,15 +312,16 @@ func FsRemoveEmptyDirectory(c *gin.Context) {
 	}
 
 	user := c.MustGet("user").(*model.User)
-	if !user.CanRemove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	common.AuditPath(c, srcDir, "")
+	if !common.HasRight(user, srcDir, model.ACLDelete, user.CanRemove()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(srcDir)
 	if err != nil {
@@ -342,3 +441,13 @@ func Link(c *gin.Context) {
 	common.SuccessResp(c, link)
 	return
 }
+
+// hasRightOfNames checks the right of the user at all the names in the dir
+func hasRightOfNames(user *model.User, dir string, names []string, right int32, fallback bool) bool {
+	for _, name := range names {
+		if !common.HasRight(user, stdpath.Join(dir, name), right, fallback) {
+			return false
+		}
+	}
+	return true
+}
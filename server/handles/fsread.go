@@ -79,7 +79,11 @@ func FsList(c *gin.Context) {
 		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
 		return
 	}
-	if !user.CanWrite() && !common.CanWrite(meta, reqPath) && req.Refresh {
+	if !common.HasRight(user, reqPath, model.ACLList, true) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if !common.CanWritePath(user, meta, reqPath) && req.Refresh {
 		common.ErrorStrResp(c, "Refresh without permission", 403)
 		return
 	}
@@ -88,6 +92,7 @@ func FsList(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
+	objs = filterReadable(user, reqPath, objs)
 	total, objs := pagination(objs, &req.PageReq)
 	provider := "unknown"
 	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
@@ -99,7 +104,7 @@ func FsList(c *gin.Context) {
 		Total:    int64(total),
 		Readme:   getReadme(meta, reqPath),
 		Header:   getHeader(meta, reqPath),
-		Write:    user.CanWrite() || common.CanWrite(meta, reqPath),
+		Write:    common.CanWritePath(user, meta, reqPath),
 		Provider: provider,
 	})
 }
@@ -137,12 +142,16 @@ func FsDirs(c *gin.Context) {
 		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
 		return
 	}
+	if !common.HasRight(user, reqPath, model.ACLList, true) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	objs, err := fs.List(c, reqPath, &fs.ListArgs{})
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
-	dirs := filterDirs(objs)
+	dirs := filterDirs(filterReadable(user, reqPath, objs))
 	common.SuccessResp(c, dirs)
 }
 
@@ -151,6 +160,17 @@ type DirResp struct {
 	Modified time.Time `json:"modified"`
 }
 
+// filterReadable removes the objs which the read right is denied by the acl
+func filterReadable(user *model.User, parent string, objs []model.Obj) []model.Obj {
+	res := make([]model.Obj, 0, len(objs))
+	for _, obj := range objs {
+		if common.HasRight(user, stdpath.Join(parent, obj.GetName()), model.ACLRead, true) {
+			res = append(res, obj)
+		}
+	}
+	return res
+}
+
 func filterDirs(objs []model.Obj) []DirResp {
 	var dirs []DirResp
 	for _, obj := range objs {
@@ -280,7 +300,7 @@ func FsGet(c *gin.Context) {
 			common.ErrorResp(c, err, 500)
 			return
 		}
-		if storage.Config().MustProxy() || storage.GetStorage().WebProxy {
+		if storage.Config().MustProxy() || storage.GetStorage().WebProxy || fs.IsInArchive(c, reqPath) {
 			query := ""
 			if isEncrypt(meta, reqPath) || setting.GetBool(conf.SignAll) {
 				query = "?sign=" + sign.Sign(reqPath)
@@ -0,0 +1,259 @@
+package handles
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/tus"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// the tus protocol relies on the http status, so the handlers below
+// respond with the status directly instead of common.ErrorResp
+const (
+	tusVersion     = "1.0.0"
+	tusExtensions  = "creation,creation-with-upload,termination,expiration"
+	tusContentType = "application/offset+octet-stream"
+)
+
+func tusError(c *gin.Context, status int, err error) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.String(status, err.Error())
+}
+
+func tusResumable(c *gin.Context) bool {
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		tusError(c, http.StatusPreconditionFailed, errors.New("unsupported tus version"))
+		return false
+	}
+	c.Header("Tus-Resumable", tusVersion)
+	return true
+}
+
+// parseTusMetadata parses the Upload-Metadata header,
+// which is a comma separated list of `key base64(value)`
+func parseTusMetadata(s string) map[string]string {
+	meta := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		v, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		meta[key] = string(v)
+	}
+	return meta
+}
+
+func FsTusOptions(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Status(http.StatusNoContent)
+}
+
+func FsTusCreate(c *gin.Context) {
+	if !tusResumable(c) {
+		return
+	}
+	path, err := url.PathUnescape(c.GetHeader("File-Path"))
+	if err != nil {
+		tusError(c, http.StatusBadRequest, err)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	path, err = user.JoinPath(path)
+	if err != nil {
+		tusError(c, http.StatusForbidden, err)
+		return
+	}
+	conflict, err := model.ParseConflictPolicy(c.GetHeader("Conflict-Policy"))
+	if err != nil {
+		tusError(c, http.StatusBadRequest, err)
+		return
+	}
+	size, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || size < 0 {
+		tusError(c, http.StatusBadRequest, errors.New("invalid Upload-Length"))
+		return
+	}
+	storage, err := fs.GetStorage(path, &fs.GetStoragesArgs{})
+	if err != nil {
+		tusError(c, http.StatusBadRequest, err)
+		return
+	}
+	if storage.Config().NoUpload {
+		tusError(c, http.StatusMethodNotAllowed, errors.New("current storage doesn't support upload"))
+		return
+	}
+	// fail early rather than after all the data is uploaded
+	if conflict == model.ConflictFail {
+		if _, err = fs.Get(c, path, &fs.GetArgs{NoLog: true}); err == nil {
+			tusError(c, http.StatusConflict, errs.ObjectAlreadyExists)
+			return
+		}
+	}
+	metadata := c.GetHeader("Upload-Metadata")
+	mimetype := parseTusMetadata(metadata)["filetype"]
+	if mimetype == "" {
+		mimetype = utils.GetMimeType(stdpath.Base(path))
+	}
+	u := &tus.Upload{
+		UserID:   user.ID,
+		Path:     path,
+		Size:     size,
+		Mimetype: mimetype,
+		Modified: getLastModified(c),
+		Metadata: metadata,
+		Conflict: conflict,
+	}
+	if err = tus.Create(u); err != nil {
+		tusError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Header("Location", stdpath.Join(c.Request.URL.Path, u.ID))
+	c.Header("Upload-Expires", u.ExpiresAt.UTC().Format(http.TimeFormat))
+	if c.GetHeader("Content-Type") == tusContentType {
+		// creation-with-upload
+		if !tusWrite(c, u, 0) {
+			return
+		}
+	} else if size == 0 && !tusComplete(c, u) {
+		return
+	}
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Status(http.StatusCreated)
+}
+
+// getTusUpload returns the upload of the current user, other users'
+// uploads are treated as not found
+func getTusUpload(c *gin.Context) (*tus.Upload, bool) {
+	user := c.MustGet("user").(*model.User)
+	u, err := tus.Get(c.Param("id"))
+	if err == nil && u.UserID != user.ID {
+		err = tus.ErrNotFound
+	}
+	if err != nil {
+		if errors.Is(err, tus.ErrNotFound) {
+			tusError(c, http.StatusNotFound, err)
+		} else {
+			tusError(c, http.StatusInternalServerError, err)
+		}
+		return nil, false
+	}
+	return u, true
+}
+
+func FsTusHead(c *gin.Context) {
+	if !tusResumable(c) {
+		return
+	}
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(u.Size, 10))
+	c.Header("Upload-Expires", u.ExpiresAt.UTC().Format(http.TimeFormat))
+	if u.Metadata != "" {
+		c.Header("Upload-Metadata", u.Metadata)
+	}
+	c.Status(http.StatusOK)
+}
+
+func FsTusPatch(c *gin.Context) {
+	if !tusResumable(c) {
+		return
+	}
+	if c.GetHeader("Content-Type") != tusContentType {
+		tusError(c, http.StatusUnsupportedMediaType, errors.New("invalid Content-Type"))
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil {
+		tusError(c, http.StatusBadRequest, errors.New("invalid Upload-Offset"))
+		return
+	}
+	u, ok := getTusUpload(c)
+	if !ok || !tusWrite(c, u, offset) {
+		return
+	}
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Header("Upload-Expires", u.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusNoContent)
+}
+
+// tusWrite writes the request body to the upload and puts the file once
+// all the data is received, it responds with the error and returns false if failed
+func tusWrite(c *gin.Context, u *tus.Upload, offset int64) bool {
+	defer c.Request.Body.Close()
+	err := tus.Write(u, offset, c.Request.Body)
+	switch {
+	case err == nil:
+	case errors.Is(err, tus.ErrOffsetMismatch):
+		tusError(c, http.StatusConflict, err)
+		return false
+	case errors.Is(err, tus.ErrLocked):
+		tusError(c, http.StatusLocked, err)
+		return false
+	case errors.Is(err, tus.ErrNotFound):
+		tusError(c, http.StatusNotFound, err)
+		return false
+	default:
+		// the client resumes from the offset it gets by HEAD
+		log.Warnf("failed write tus upload %s: %+v", u.ID, err)
+		tusError(c, http.StatusInternalServerError, err)
+		return false
+	}
+	if u.Offset < u.Size {
+		return true
+	}
+	return tusComplete(c, u)
+}
+
+func tusComplete(c *gin.Context, u *tus.Upload) bool {
+	t, err := tus.Complete(u)
+	if err != nil {
+		switch {
+		case errors.Is(err, tus.ErrLocked):
+			tusError(c, http.StatusLocked, err)
+		case errors.Is(err, tus.ErrNotFound):
+			tusError(c, http.StatusNotFound, err)
+		default:
+			tusError(c, http.StatusInternalServerError, err)
+		}
+		return false
+	}
+	c.Header("Upload-Task-Id", t.GetID())
+	return true
+}
+
+func FsTusDelete(c *gin.Context) {
+	if !tusResumable(c) {
+		return
+	}
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	if err := tus.Remove(u.ID); err != nil {
+		tusError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -28,6 +28,16 @@ func getLastModified(c *gin.Context) time.Time {
 	return lastModified
 }
 
+// parseConflictPolicy responds with the error if the conflict policy is invalid
+func parseConflictPolicy(c *gin.Context, s string) (model.ConflictPolicy, bool) {
+	p, err := model.ParseConflictPolicy(s)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return "", false
+	}
+	return p, true
+}
+
 func FsStream(c *gin.Context) {
 	path := c.GetHeader("File-Path")
 	path, err := url.PathUnescape(path)
@@ -36,6 +46,10 @@ func FsStream(c *gin.Context) {
 		return
 	}
 	asTask := c.GetHeader("As-Task") == "true"
+	conflict, ok := parseConflictPolicy(c, c.GetHeader("Conflict-Policy"))
+	if !ok {
+		return
+	}
 	user := c.MustGet("user").(*model.User)
 	path, err = user.JoinPath(path)
 	if err != nil {
@@ -58,6 +72,7 @@ func FsStream(c *gin.Context) {
 		Reader:       c.Request.Body,
 		Mimetype:     c.GetHeader("Content-Type"),
 		WebPutAsTask: asTask,
+		Conflict:     conflict,
 	}
 	var t tache.TaskWithInfo
 	if asTask {
@@ -87,6 +102,10 @@ func FsForm(c *gin.Context) {
 		return
 	}
 	asTask := c.GetHeader("As-Task") == "true"
+	conflict, ok := parseConflictPolicy(c, c.GetHeader("Conflict-Policy"))
+	if !ok {
+		return
+	}
 	user := c.MustGet("user").(*model.User)
 	path, err = user.JoinPath(path)
 	if err != nil {
@@ -123,6 +142,7 @@ func FsForm(c *gin.Context) {
 		Reader:       f,
 		Mimetype:     file.Header.Get("Content-Type"),
 		WebPutAsTask: asTask,
+		Conflict:     conflict,
 	}
 	var t tache.TaskWithInfo
 	if asTask {
@@ -38,6 +38,7 @@ func CreateMeta(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	common.Audit(c).Target = req.Path
 	r, err := validHide(req.Hide)
 	if err != nil {
 		common.ErrorStrResp(c, fmt.Sprintf("%s is illegal: %s", r, err.Error()), 400)
@@ -56,6 +57,7 @@ func UpdateMeta(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	common.Audit(c).Target = req.Path
 	r, err := validHide(req.Hide)
 	if err != nil {
 		common.ErrorStrResp(c, fmt.Sprintf("%s is illegal: %s", r, err.Error()), 400)
@@ -1,6 +1,8 @@
 package handles
 
 import (
+	"strings"
+
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/offline_download/tool"
@@ -79,15 +81,11 @@ type AddOfflineDownloadReq struct {
 	Path         string   `json:"path"`
 	Tool         string   `json:"tool"`
 	DeletePolicy string   `json:"delete_policy"`
+	Conflict     string   `json:"conflict"`
 }
 
 func AddOfflineDownload(c *gin.Context) {
 	user := c.MustGet("user").(*model.User)
-	if !user.CanAddOfflineDownloadTasks() {
-		common.ErrorStrResp(c, "permission denied", 403)
-		return
-	}
-
 	var req AddOfflineDownloadReq
 	if err := c.ShouldBind(&req); err != nil {
 		common.ErrorResp(c, err, 400)
@@ -98,6 +96,16 @@ func AddOfflineDownload(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasRight(user, reqPath, model.ACLOffline, user.CanAddOfflineDownloadTasks()) {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+	conflict, ok := parseConflictPolicy(c, req.Conflict)
+	if !ok {
+		return
+	}
+	common.Audit(c).Target = strings.Join(req.Urls, "\n")
+	common.AuditPath(c, "", reqPath)
 	var tasks []tache.TaskWithInfo
 	for _, url := range req.Urls {
 		t, err := tool.AddURL(c, &tool.AddURLArgs{
@@ -105,6 +113,7 @@ func AddOfflineDownload(c *gin.Context) {
 			DstDirPath:   reqPath,
 			Tool:         req.Tool,
 			DeletePolicy: tool.DeletePolicy(req.DeletePolicy),
+			Conflict:     conflict,
 		})
 		if err != nil {
 			common.ErrorResp(c, err, 500)
@@ -0,0 +1,103 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateS3KeyReq struct {
+	Name string `json:"name"`
+	// the user the key is issued to, only used by admin
+	UserID uint `json:"user_id"`
+}
+
+// CreateS3Key issues a s3 key to the current user, or the given user for
+// admin, the secret key is only returned here and can't be got again
+func CreateS3Key(c *gin.Context) {
+	var req CreateS3KeyReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if req.UserID != 0 && req.UserID != user.ID {
+		if !user.IsAdmin() {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+		u, err := op.GetUserById(req.UserID)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		user = u
+	}
+	k, err := op.CreateS3Key(user, req.Name)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"id":         k.ID,
+		"access_key": k.AccessKey,
+		"secret_key": k.SecretKey,
+	})
+}
+
+// ListS3Keys lists the s3 keys of the current user
+func ListS3Keys(c *gin.Context) {
+	listS3Keys(c, c.MustGet("user").(*model.User).ID)
+}
+
+// ListAllS3Keys lists the s3 keys of all users for admin
+func ListAllS3Keys(c *gin.Context) {
+	listS3Keys(c, 0)
+}
+
+func listS3Keys(c *gin.Context, userId uint) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	keys, total, err := op.GetS3Keys(userId, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: keys,
+		Total:   total,
+	})
+}
+
+// DeleteS3Key revokes the s3 key, only the owner and admin can do it
+func DeleteS3Key(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	k, err := op.GetS3KeyById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if !user.IsAdmin() && k.UserID != user.ID {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if err = op.DeleteS3KeyById(k.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
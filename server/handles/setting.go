@@ -51,6 +51,11 @@ func SaveSettings(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	keys := make([]string, 0, len(req))
+	for _, item := range req {
+		keys = append(keys, item.Key)
+	}
+	common.Audit(c).Target = strings.Join(keys, ",")
 	if err := op.SaveSettingItems(req); err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
@@ -0,0 +1,351 @@
+package handles
+
+import (
+	"context"
+	"crypto/subtle"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type CreateShareReq struct {
+	Paths        []string   `json:"paths"`
+	Password     string     `json:"password"`
+	Expires      *time.Time `json:"expires"`
+	MaxDownloads int        `json:"max_downloads"`
+	AllowPreview bool       `json:"allow_preview"`
+	AllowUpload  bool       `json:"allow_upload"`
+}
+
+func CreateShare(c *gin.Context) {
+	var req CreateShareReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Paths) == 0 {
+		common.ErrorStrResp(c, "Empty paths", 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if user.IsGuest() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	paths := make([]string, 0, len(req.Paths))
+	names := map[string]bool{}
+	for _, p := range req.Paths {
+		reqPath, err := user.JoinPath(p)
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		// the paths are the children of the root of the share
+		name := stdpath.Base(reqPath)
+		if names[name] {
+			common.ErrorStrResp(c, "duplicate name in paths: "+name, 400)
+			return
+		}
+		names[name] = true
+		meta, err := op.GetNearestMeta(reqPath)
+		if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+		if !common.CanAccess(user, meta, reqPath, "") || !common.HasRight(user, reqPath, model.ACLShare, true) {
+			common.ErrorStrResp(c, "you have no permission to share "+p, 403)
+			return
+		}
+		if req.AllowUpload && !common.CanWritePath(user, meta, reqPath) {
+			common.ErrorStrResp(c, "you have no permission to upload to "+p, 403)
+			return
+		}
+		if _, err = fs.Get(c, reqPath, &fs.GetArgs{}); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		paths = append(paths, reqPath)
+	}
+	common.AuditPath(c, strings.Join(paths, "\n"), "")
+	share := &model.Share{
+		ID:           random.String(8),
+		CreatorID:    user.ID,
+		Creator:      user.Username,
+		Paths:        strings.Join(paths, "\n"),
+		Password:     req.Password,
+		Expires:      req.Expires,
+		MaxDownloads: req.MaxDownloads,
+		AllowPreview: req.AllowPreview,
+		AllowUpload:  req.AllowUpload,
+		CreatedAt:    time.Now(),
+	}
+	if err := db.CreateShare(share); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.Audit(c).Target = share.ID
+	common.SuccessResp(c, gin.H{
+		"id": share.ID,
+	})
+}
+
+// ListShares lists the shares of the current user
+func ListShares(c *gin.Context) {
+	listShares(c, c.MustGet("user").(*model.User).ID)
+}
+
+// ListAllShares lists the shares of all users for admin
+func ListAllShares(c *gin.Context) {
+	listShares(c, 0)
+}
+
+func listShares(c *gin.Context, creatorId uint) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	shares, total, err := db.GetShares(creatorId, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: shares,
+		Total:   total,
+	})
+}
+
+// DeleteShare revokes the share, only the creator and admin can do it
+func DeleteShare(c *gin.Context) {
+	share, err := db.GetShareById(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if !user.IsAdmin() && share.CreatorID != user.ID {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if err = db.DeleteShareById(share.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+type ShareObjResp struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	IsDir    bool      `json:"is_dir"`
+	Modified time.Time `json:"modified"`
+}
+
+// getShare checks the share and returns the actual path of the path in
+// the share, it's empty for the root of the share
+func getShare(c *gin.Context) (*model.Share, string, bool) {
+	share, err := db.GetShareById(c.Param("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "share not found", 404)
+		return nil, "", false
+	}
+	if share.IsExpired() {
+		common.ErrorStrResp(c, "share expired", 410)
+		return nil, "", false
+	}
+	if share.Password != "" && subtle.ConstantTimeCompare([]byte(sharePassword(c)), []byte(share.Password)) != 1 {
+		common.ErrorStrResp(c, "password is incorrect", 401)
+		return nil, "", false
+	}
+	guest, err := op.GetGuest()
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return nil, "", false
+	}
+	// the content of the share is seen as guest, so that the hidden objs are not shown
+	c.Set("user", guest)
+	reqPath := utils.FixAndCleanPath(c.Param("path"))
+	if reqPath == "/" {
+		return share, "", true
+	}
+	parts := strings.SplitN(strings.TrimPrefix(reqPath, "/"), "/", 2)
+	for _, p := range share.GetPaths() {
+		if stdpath.Base(p) != parts[0] {
+			continue
+		}
+		if len(parts) == 1 {
+			return share, p, true
+		}
+		actualPath := stdpath.Join(p, parts[1])
+		// the password has been granted by the creator, only the hidden objs can't be accessed
+		meta, _ := op.GetNearestMeta(actualPath)
+		password := ""
+		if meta != nil {
+			password = meta.Password
+		}
+		if !common.CanAccess(guest, meta, actualPath, password) {
+			common.ErrorResp(c, errs.ObjectNotFound, 404)
+			return nil, "", false
+		}
+		return share, actualPath, true
+	}
+	common.ErrorResp(c, errs.ObjectNotFound, 404)
+	return nil, "", false
+}
+
+// sharePassword returns the password of the share from the header like FsUp,
+// or from the cookie for the downloads by the browser
+func sharePassword(c *gin.Context) string {
+	if password := c.GetHeader("Password"); password != "" {
+		return password
+	}
+	password, _ := c.Cookie("share_password")
+	return password
+}
+
+// ShareGet lists the dir or downloads the file in the share
+func ShareGet(c *gin.Context) {
+	share, actualPath, ok := getShare(c)
+	if !ok {
+		return
+	}
+	var objs []model.Obj
+	if actualPath == "" {
+		for _, p := range share.GetPaths() {
+			obj, err := fs.Get(c, p, &fs.GetArgs{})
+			if err != nil {
+				continue
+			}
+			objs = append(objs, obj)
+		}
+	} else {
+		obj, err := fs.Get(c, actualPath, &fs.GetArgs{})
+		if err != nil {
+			common.ErrorResp(c, err, 404)
+			return
+		}
+		if !obj.IsDir() {
+			shareDown(c, share, actualPath)
+			return
+		}
+		meta, _ := op.GetNearestMeta(actualPath)
+		c.Set("meta", meta)
+		objs, err = fs.List(c, actualPath, &fs.ListArgs{})
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	content := make([]ShareObjResp, 0, len(objs))
+	for _, obj := range objs {
+		content = append(content, ShareObjResp{
+			Name:     obj.GetName(),
+			Size:     obj.GetSize(),
+			IsDir:    obj.IsDir(),
+			Modified: obj.ModTime(),
+		})
+	}
+	common.SuccessResp(c, gin.H{
+		"content":      content,
+		"allow_upload": share.AllowUpload,
+	})
+}
+
+// shareDown downloads the file, the preview is not counted as a download,
+// neither are the range requests except the first one of a download
+func shareDown(c *gin.Context, share *model.Share, actualPath string) {
+	if c.Query("preview") == "true" {
+		if !share.AllowPreview {
+			common.ErrorStrResp(c, "preview is not allowed", 403)
+			return
+		}
+	} else if c.Request.Method != "HEAD" && isFirstRange(c.GetHeader("Range")) {
+		ok, err := db.IncrShareDownloads(share.ID)
+		if err != nil {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+		if !ok {
+			common.ErrorStrResp(c, "the share has reached the max downloads", 403)
+			return
+		}
+	}
+	meta, _ := op.GetNearestMeta(actualPath)
+	c.Set("path", actualPath)
+	c.Set("meta", meta)
+	Down(c)
+}
+
+// isFirstRange returns whether the request reads the file from the start
+func isFirstRange(rangeHeader string) bool {
+	if rangeHeader == "" {
+		return true
+	}
+	return strings.HasPrefix(strings.ReplaceAll(rangeHeader, " ", ""), "bytes=0-")
+}
+
+// SharePut uploads the body as the file of the path in the share
+func SharePut(c *gin.Context) {
+	share, actualPath, ok := getShare(c)
+	if !ok {
+		return
+	}
+	defer c.Request.Body.Close()
+	if !share.AllowUpload {
+		common.ErrorStrResp(c, "upload is not allowed", 403)
+		return
+	}
+	dir, name := stdpath.Split(actualPath)
+	if actualPath == "" || !isInSharedDir(share, dir) {
+		common.ErrorStrResp(c, "can only upload into the shared dirs", 403)
+		return
+	}
+	size, err := strconv.ParseInt(c.GetHeader("Content-Length"), 10, 64)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: getLastModified(c),
+		},
+		Reader:   c.Request.Body,
+		Mimetype: c.GetHeader("Content-Type"),
+		// the files in the share can't be replaced by anyone having the link
+		Conflict: model.ConflictRename,
+	}
+	if err = fs.PutDirectly(c, dir, s, true); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"name": s.GetName(),
+	})
+}
+
+func isInSharedDir(share *model.Share, dir string) bool {
+	for _, p := range share.GetPaths() {
+		if utils.IsSubPath(p, dir) {
+			obj, err := fs.Get(context.Background(), p, &fs.GetArgs{})
+			return err == nil && obj.IsDir()
+		}
+	}
+	return false
+}
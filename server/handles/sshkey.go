@@ -0,0 +1,89 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type AddSSHPublicKeyReq struct {
+	Title string `json:"title"`
+	Key   string `json:"key" binding:"required"`
+}
+
+// AddSSHPublicKey adds a public key to the current user to log in the sftp server
+func AddSSHPublicKey(c *gin.Context) {
+	var req AddSSHPublicKeyReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if user.IsGuest() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	k, err := op.CreateSSHPublicKey(user, req.Title, req.Key)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, k)
+}
+
+// ListSSHPublicKeys lists the public keys of the current user
+func ListSSHPublicKeys(c *gin.Context) {
+	listSSHPublicKeys(c, c.MustGet("user").(*model.User).ID)
+}
+
+// ListAllSSHPublicKeys lists the public keys of all users for admin
+func ListAllSSHPublicKeys(c *gin.Context) {
+	listSSHPublicKeys(c, 0)
+}
+
+func listSSHPublicKeys(c *gin.Context, userId uint) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	keys, total, err := op.GetSSHPublicKeys(userId, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: keys,
+		Total:   total,
+	})
+}
+
+// DeleteSSHPublicKey deletes the public key, only the owner and admin can do it
+func DeleteSSHPublicKey(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	k, err := op.GetSSHPublicKeyById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if !user.IsAdmin() && k.UserID != user.ID {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if err = op.DeleteSSHPublicKeyById(k.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
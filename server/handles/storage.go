@@ -38,6 +38,7 @@ func CreateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	common.Audit(c).Storage = req.MountPath
 	if id, err := op.CreateStorage(c, req); err != nil {
 		common.ErrorWithDataResp(c, err, 500, gin.H{
 			"id": id,
@@ -55,6 +56,7 @@ func UpdateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	common.Audit(c).Storage = req.MountPath
 	if err := op.UpdateStorage(c, req); err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
@@ -150,3 +152,41 @@ func LoadAllStorages(c *gin.Context) {
 	}(storages)
 	common.SuccessResp(c)
 }
+
+func ListStorageCache(c *gin.Context) {
+	mountPath := c.Query("mount_path")
+	if mountPath == "" {
+		common.ErrorStrResp(c, "mount_path is required", 400)
+		return
+	}
+	entries, err := op.ListPersistCache(mountPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, entries)
+}
+
+type PurgeStorageCacheReq struct {
+	MountPath string `json:"mount_path" form:"mount_path"`
+	Path      string `json:"path" form:"path"`
+}
+
+func PurgeStorageCache(c *gin.Context) {
+	var req PurgeStorageCacheReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.MountPath == "" {
+		common.ErrorStrResp(c, "mount_path is required", 400)
+		return
+	}
+	common.Audit(c).Storage = req.MountPath
+	common.AuditPath(c, req.Path, "")
+	if err := op.PurgePersistCache(req.MountPath, req.Path); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
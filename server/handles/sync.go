@@ -0,0 +1,148 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/sync_job"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListSyncJobs(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	jobs, total, err := db.GetSyncJobs(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: jobs,
+		Total:   total,
+	})
+}
+
+func GetSyncJob(c *gin.Context) {
+	job, ok := getSyncJob(c)
+	if !ok {
+		return
+	}
+	common.SuccessResp(c, job)
+}
+
+func CreateSyncJob(c *gin.Context) {
+	var req model.SyncJob
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.Audit(c).Target = req.Name
+	req.ID = 0
+	if err := sync_job.CreateJob(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"id": req.ID,
+	})
+}
+
+func UpdateSyncJob(c *gin.Context) {
+	var req model.SyncJob
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.Audit(c).Target = req.Name
+	if err := sync_job.UpdateJob(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteSyncJob(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := sync_job.DeleteJob(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// RunSyncJob runs the job as a task, nothing is changed if dry_run is true
+func RunSyncJob(c *gin.Context) {
+	job, ok := getSyncJob(c)
+	if !ok {
+		return
+	}
+	common.Audit(c).Target = job.Name
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	t := sync_job.Run(job, dryRun)
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
+
+// DiffSyncJob returns the actions that a run of the job would do
+func DiffSyncJob(c *gin.Context) {
+	job, ok := getSyncJob(c)
+	if !ok {
+		return
+	}
+	actions, err := sync_job.Diff(c, job)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, actions)
+}
+
+type ListSyncRunsReq struct {
+	model.PageReq
+	ID uint `json:"id" form:"id"`
+}
+
+func ListSyncRuns(c *gin.Context) {
+	var req ListSyncRunsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	runs, total, err := db.GetSyncRuns(req.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: runs,
+		Total:   total,
+	})
+}
+
+func getSyncJob(c *gin.Context) (*model.SyncJob, bool) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, false
+	}
+	job, err := db.GetSyncJobById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return nil, false
+	}
+	return job, true
+}
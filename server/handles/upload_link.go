@@ -0,0 +1,267 @@
+package handles
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type CreateUploadLinkReq struct {
+	Path        string     `json:"path"`
+	Password    string     `json:"password"`
+	Expires     *time.Time `json:"expires"`
+	MaxFileSize int64      `json:"max_file_size"`
+	AllowedExts string     `json:"allowed_exts"`
+	Quota       int64      `json:"quota"`
+}
+
+func CreateUploadLink(c *gin.Context) {
+	var req CreateUploadLinkReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if user.IsGuest() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !common.CanWritePath(user, meta, reqPath) || !common.HasRight(user, reqPath, model.ACLShare, true) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	common.AuditPath(c, reqPath, "")
+	obj, err := fs.Get(c, reqPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if !obj.IsDir() {
+		common.ErrorResp(c, errs.NotFolder, 400)
+		return
+	}
+	link := &model.UploadLink{
+		ID:          random.String(8),
+		CreatorID:   user.ID,
+		Creator:     user.Username,
+		Path:        reqPath,
+		Password:    req.Password,
+		Expires:     req.Expires,
+		MaxFileSize: req.MaxFileSize,
+		AllowedExts: req.AllowedExts,
+		Quota:       req.Quota,
+		CreatedAt:   time.Now(),
+	}
+	if err = db.CreateUploadLink(link); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.Audit(c).Target = link.ID
+	common.SuccessResp(c, gin.H{
+		"id": link.ID,
+	})
+}
+
+// ListUploadLinks lists the upload links of the current user
+func ListUploadLinks(c *gin.Context) {
+	listUploadLinks(c, c.MustGet("user").(*model.User).ID)
+}
+
+// ListAllUploadLinks lists the upload links of all users for admin
+func ListAllUploadLinks(c *gin.Context) {
+	listUploadLinks(c, 0)
+}
+
+func listUploadLinks(c *gin.Context, creatorId uint) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	links, total, err := db.GetUploadLinks(creatorId, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: links,
+		Total:   total,
+	})
+}
+
+// DeleteUploadLink revokes the link, only the creator and admin can do it
+func DeleteUploadLink(c *gin.Context) {
+	link, err := db.GetUploadLinkById(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if !user.IsAdmin() && link.CreatorID != user.ID {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if err = db.DeleteUploadLinkById(link.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func getUploadLink(c *gin.Context) (*model.UploadLink, bool) {
+	link, err := db.GetUploadLinkById(c.Param("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "upload link not found", 404)
+		return nil, false
+	}
+	if link.IsExpired() {
+		common.ErrorStrResp(c, "upload link expired", 410)
+		return nil, false
+	}
+	password := c.GetHeader("Password")
+	if password == "" {
+		password = c.Query("password")
+	}
+	if link.Password != "" && subtle.ConstantTimeCompare([]byte(password), []byte(link.Password)) != 1 {
+		common.ErrorStrResp(c, "password is incorrect", 401)
+		return nil, false
+	}
+	return link, true
+}
+
+// UploadLinkInfo shows the limits of the link for the upload page
+func UploadLinkInfo(c *gin.Context) {
+	link, ok := getUploadLink(c)
+	if !ok {
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"expires":       link.Expires,
+		"max_file_size": link.MaxFileSize,
+		"allowed_exts":  link.AllowedExts,
+		"quota":         link.Quota,
+		"used":          link.Used,
+	})
+}
+
+// UploadLinkStream uploads the body as the file named by the File-Name header
+func UploadLinkStream(c *gin.Context) {
+	defer c.Request.Body.Close()
+	link, ok := getUploadLink(c)
+	if !ok {
+		return
+	}
+	name, err := url.PathUnescape(c.GetHeader("File-Name"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	size, err := strconv.ParseInt(c.GetHeader("Content-Length"), 10, 64)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	uploadByLink(c, link, name, size, c.GetHeader("Content-Type"), c.Request.Body)
+}
+
+// UploadLinkForm uploads the file of the multipart form
+func UploadLinkForm(c *gin.Context) {
+	link, ok := getUploadLink(c)
+	if !ok {
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	defer f.Close()
+	uploadByLink(c, link, file.Filename, file.Size, file.Header.Get("Content-Type"), f)
+}
+
+func uploadByLink(c *gin.Context, link *model.UploadLink, name string, size int64, mimetype string, r io.Reader) {
+	name = stdpath.Base("/" + strings.ReplaceAll(name, "\\", "/"))
+	if name == "/" || name == "." || name == ".." {
+		common.ErrorStrResp(c, "invalid file name", 400)
+		return
+	}
+	if !link.IsExtAllowed(utils.Ext(name)) {
+		common.ErrorStrResp(c, "file type is not allowed", 403)
+		return
+	}
+	if link.MaxFileSize > 0 && size > link.MaxFileSize {
+		common.ErrorStrResp(c, "file is too large", 413)
+		return
+	}
+	// reserve the quota before uploading, it's released if the upload fails
+	ok, err := db.AddUploadLinkUsed(link.ID, size)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !ok {
+		common.ErrorStrResp(c, "quota of the upload link exceeded", 413)
+		return
+	}
+	// the file is renamed like "name (1).ext" if it exists in the dir
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: time.Now(),
+		},
+		Reader:   io.LimitReader(r, size),
+		Mimetype: mimetype,
+		Conflict: model.ConflictRename,
+	}
+	if err = fs.PutDirectly(c, link.Path, s, true); err != nil {
+		if _, e := db.AddUploadLinkUsed(link.ID, -size); e != nil {
+			log.Errorf("failed release quota of upload link %s: %+v", link.ID, e)
+		}
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	name = s.GetName()
+	// there is no notification channel of a user, the message is sent to the
+	// channel of the site set by admin, so only admin is notified of the
+	// uploads of all links, the creator is named in the message
+	go op.Notify("文件上传通知", fmt.Sprintf("%s的上传链接%s收到文件%s(%d字节)", link.Creator, link.ID,
+		stdpath.Join(link.Path, name), size))
+	common.SuccessResp(c, gin.H{
+		"name": name,
+	})
+}
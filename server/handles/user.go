@@ -1,8 +1,10 @@
 package handles
 
 import (
+	"context"
 	"strconv"
 
+	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
@@ -35,6 +37,7 @@ func CreateUser(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	common.Audit(c).Target = req.Username
 	if req.IsAdmin() || req.IsGuest() {
 		common.ErrorStrResp(c, "admin or guest user can not be created", 400, true)
 		return
@@ -60,6 +63,7 @@ func UpdateUser(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
+	common.Audit(c).Target = user.Username
 	if user.Role != req.Role {
 		common.ErrorStrResp(c, "role can not be changed", 400)
 		return
@@ -137,3 +141,9 @@ func DelUserCache(c *gin.Context) {
 	}
 	common.SuccessResp(c)
 }
+
+// ReconcileUsage recomputes the usage of the paths with a quota in background
+func ReconcileUsage(c *gin.Context) {
+	go fs.ReconcileUsage(context.Background())
+	common.SuccessResp(c)
+}
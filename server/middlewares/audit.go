@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// Audit records the operation of the route after it's handled, the paths
+// are filled by the handler, and the query is the target by default
+func Audit(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := &model.AuditLog{
+			IP:        c.ClientIP(),
+			Action:    action,
+			Target:    c.Request.URL.RawQuery,
+			Success:   true,
+			CreatedAt: time.Now(),
+		}
+		common.SetAudit(c, l)
+		c.Next()
+		if user, ok := c.Value("user").(*model.User); ok {
+			l.Username = user.Username
+		}
+		if status := c.Writer.Status(); l.Success && status >= 400 {
+			l.Success = false
+			l.Message = http.StatusText(status)
+		}
+		op.RecordAudit(l)
+	}
+}
@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"crypto/subtle"
+	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -45,6 +46,19 @@ func Auth(c *gin.Context) {
 		c.Next()
 		return
 	}
+	if strings.HasPrefix(token, model.APITokenPrefix) {
+		user, err := op.GetUserByAPIToken(token)
+		if err != nil {
+			common.ErrorResp(c, err, 401)
+			c.Abort()
+			return
+		}
+		c.Set("user", user)
+		c.Set("api_token", true)
+		log.Debugf("use api token: %+v", user)
+		c.Next()
+		return
+	}
 	userClaims, err := common.ParseToken(token)
 	if err != nil {
 		common.ErrorResp(c, err, 401)
@@ -73,6 +87,17 @@ func Auth(c *gin.Context) {
 	c.Next()
 }
 
+// NoAPIToken rejects the requests authorized by an api token, the user of
+// the api token is a restricted copy which can't manage the account
+func NoAPIToken(c *gin.Context) {
+	if c.GetBool("api_token") {
+		common.ErrorStrResp(c, "Not allowed with an api token, login please", 403)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
 func Authn(c *gin.Context) {
 	token := c.GetHeader("Authorization")
 	if subtle.ConstantTimeCompare([]byte(token), []byte(setting.GetStr(conf.Token))) == 1 {
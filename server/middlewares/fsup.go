@@ -27,6 +27,7 @@ func FsUp(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	common.AuditPath(c, path, "")
 	meta, err := op.GetNearestMeta(stdpath.Dir(path))
 	if err != nil {
 		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
@@ -35,7 +36,7 @@ func FsUp(c *gin.Context) {
 			return
 		}
 	}
-	if !(common.CanAccess(user, meta, path, password) && (user.CanWrite() || common.CanWrite(meta, stdpath.Dir(path)))) {
+	if !(common.CanAccess(user, meta, path, password) && common.CanWritePath(user, meta, stdpath.Dir(path))) {
 		common.ErrorResp(c, errs.PermissionDenied, 403)
 		c.Abort()
 		return
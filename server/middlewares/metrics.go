@@ -0,0 +1,24 @@
+package middlewares
+
+import (
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records the count and latency of the requests by the route, and
+// the bytes of the files served by the download and proxy routes
+func Metrics(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	metrics.ObserveRequest(route, c.Request.Method, c.Writer.Status(), time.Since(start))
+	if strings.HasSuffix(route, "/d/*path") || strings.HasSuffix(route, "/p/*path") {
+		metrics.AddBytesServed(route, int64(c.Writer.Size()))
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/alist-org/alist/v3/server/static"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // 生成指定长度的随机字符串
@@ -40,6 +41,7 @@ func Init(e *gin.Engine) {
 	}
 	Cors(e)
 	g := e.Group(conf.URL.Path)
+	g.Use(middlewares.Metrics)
 	if conf.Conf.Scheme.HttpPort != -1 && conf.Conf.Scheme.HttpsPort != -1 && conf.Conf.Scheme.ForceHttps {
 		e.Use(middlewares.ForceHttps)
 	}
@@ -49,6 +51,7 @@ func Init(e *gin.Engine) {
 	g.GET("/favicon.ico", handles.Favicon)
 	g.GET("/robots.txt", handles.Robots)
 	g.GET("/i/:link_name", handles.Plist)
+	g.GET("/metrics", middlewares.Auth, middlewares.AuthAdmin, gin.WrapH(promhttp.Handler()))
 	common.SecretKey = []byte(conf.Conf.JwtSecret)
 	g.Use(middlewares.StoragesLoaded)
 	if conf.Conf.MaxConnections > 0 {
@@ -61,6 +64,13 @@ func Init(e *gin.Engine) {
 	g.GET("/p/*path", middlewares.Down, handles.Proxy)
 	g.HEAD("/d/*path", middlewares.Down, handles.Down)
 	g.HEAD("/p/*path", middlewares.Down, handles.Proxy)
+	g.GET("/z/*path", middlewares.Down, handles.ArchiveDown)
+	g.GET("/s/:id/*path", handles.ShareGet)
+	g.HEAD("/s/:id/*path", handles.ShareGet)
+	g.PUT("/s/:id/*path", handles.SharePut)
+	g.GET("/u/:id", handles.UploadLinkInfo)
+	g.PUT("/u/:id", handles.UploadLinkStream)
+	g.POST("/u/:id", handles.UploadLinkForm)
 
 	api := g.Group("/api")
 	auth := api.Group("", middlewares.Auth)
@@ -70,9 +80,9 @@ func Init(e *gin.Engine) {
 	api.POST("/auth/login/hash", handles.LoginHash)
 	api.POST("/auth/login/ldap", handles.LoginLdap)
 	auth.GET("/me", handles.CurrentUser)
-	auth.POST("/me/update", handles.UpdateCurrent)
-	auth.POST("/auth/2fa/generate", handles.Generate2FA)
-	auth.POST("/auth/2fa/verify", handles.Verify2FA)
+	auth.POST("/me/update", middlewares.NoAPIToken, handles.UpdateCurrent)
+	auth.POST("/auth/2fa/generate", middlewares.NoAPIToken, handles.Generate2FA)
+	auth.POST("/auth/2fa/verify", middlewares.NoAPIToken, handles.Verify2FA)
 
 	// auth
 	api.GET("/auth/sso", handles.SSOLoginRedirect)
@@ -99,6 +109,26 @@ func Init(e *gin.Engine) {
 	public.Any("/offline_download_tools", handles.OfflineDownloadTools)
 
 	_fs(auth.Group("/fs"))
+	share := auth.Group("/share")
+	share.GET("/list", handles.ListShares)
+	share.POST("/create", middlewares.Audit("share.create"), handles.CreateShare)
+	share.POST("/delete", middlewares.Audit("share.delete"), handles.DeleteShare)
+	uploadLink := auth.Group("/upload_link")
+	uploadLink.GET("/list", handles.ListUploadLinks)
+	uploadLink.POST("/create", middlewares.Audit("upload_link.create"), handles.CreateUploadLink)
+	uploadLink.POST("/delete", middlewares.Audit("upload_link.delete"), handles.DeleteUploadLink)
+	apiToken := auth.Group("/api_token", middlewares.NoAPIToken)
+	apiToken.GET("/list", handles.ListAPITokens)
+	apiToken.POST("/create", handles.CreateAPIToken)
+	apiToken.POST("/delete", handles.DeleteAPIToken)
+	sshKey := auth.Group("/ssh_key", middlewares.NoAPIToken)
+	sshKey.GET("/list", handles.ListSSHPublicKeys)
+	sshKey.POST("/add", handles.AddSSHPublicKey)
+	sshKey.POST("/delete", handles.DeleteSSHPublicKey)
+	s3Key := auth.Group("/s3_key", middlewares.NoAPIToken)
+	s3Key.GET("/list", handles.ListS3Keys)
+	s3Key.POST("/create", handles.CreateS3Key)
+	s3Key.POST("/delete", handles.DeleteS3Key)
 	admin(auth.Group("/admin", middlewares.AuthAdmin))
 	if flags.Debug || flags.Dev {
 		debug(g.Group("/debug"))
@@ -112,28 +142,45 @@ func admin(g *gin.RouterGroup) {
 	meta := g.Group("/meta")
 	meta.GET("/list", handles.ListMetas)
 	meta.GET("/get", handles.GetMeta)
-	meta.POST("/create", handles.CreateMeta)
-	meta.POST("/update", handles.UpdateMeta)
-	meta.POST("/delete", handles.DeleteMeta)
+	meta.POST("/create", middlewares.Audit("meta.create"), handles.CreateMeta)
+	meta.POST("/update", middlewares.Audit("meta.update"), handles.UpdateMeta)
+	meta.POST("/delete", middlewares.Audit("meta.delete"), handles.DeleteMeta)
 
 	user := g.Group("/user")
 	user.GET("/list", handles.ListUsers)
 	user.GET("/get", handles.GetUser)
-	user.POST("/create", handles.CreateUser)
-	user.POST("/update", handles.UpdateUser)
-	user.POST("/cancel_2fa", handles.Cancel2FAById)
-	user.POST("/delete", handles.DeleteUser)
-	user.POST("/del_cache", handles.DelUserCache)
+	user.POST("/create", middlewares.Audit("user.create"), handles.CreateUser)
+	user.POST("/update", middlewares.Audit("user.update"), handles.UpdateUser)
+	user.POST("/cancel_2fa", middlewares.Audit("user.cancel_2fa"), handles.Cancel2FAById)
+	user.POST("/delete", middlewares.Audit("user.delete"), handles.DeleteUser)
+	user.POST("/del_cache", middlewares.Audit("user.del_cache"), handles.DelUserCache)
+	user.POST("/reconcile_usage", middlewares.Audit("user.reconcile_usage"), handles.ReconcileUsage)
+
+	group := g.Group("/group")
+	group.GET("/list", handles.ListGroups)
+	group.GET("/get", handles.GetGroup)
+	group.POST("/create", middlewares.Audit("group.create"), handles.CreateGroup)
+	group.POST("/update", middlewares.Audit("group.update"), handles.UpdateGroup)
+	group.POST("/delete", middlewares.Audit("group.delete"), handles.DeleteGroup)
+	group.POST("/set_members", middlewares.Audit("group.set_members"), handles.SetGroupMembers)
+
+	acl := g.Group("/acl")
+	acl.GET("/list", handles.ListACLs)
+	acl.POST("/create", middlewares.Audit("acl.create"), handles.CreateACL)
+	acl.POST("/update", middlewares.Audit("acl.update"), handles.UpdateACL)
+	acl.POST("/delete", middlewares.Audit("acl.delete"), handles.DeleteACL)
 
 	storage := g.Group("/storage")
 	storage.GET("/list", handles.ListStorages)
 	storage.GET("/get", handles.GetStorage)
-	storage.POST("/create", handles.CreateStorage)
-	storage.POST("/update", handles.UpdateStorage)
-	storage.POST("/delete", handles.DeleteStorage)
-	storage.POST("/enable", handles.EnableStorage)
-	storage.POST("/disable", handles.DisableStorage)
-	storage.POST("/load_all", handles.LoadAllStorages)
+	storage.POST("/create", middlewares.Audit("storage.create"), handles.CreateStorage)
+	storage.POST("/update", middlewares.Audit("storage.update"), handles.UpdateStorage)
+	storage.POST("/delete", middlewares.Audit("storage.delete"), handles.DeleteStorage)
+	storage.POST("/enable", middlewares.Audit("storage.enable"), handles.EnableStorage)
+	storage.POST("/disable", middlewares.Audit("storage.disable"), handles.DisableStorage)
+	storage.POST("/load_all", middlewares.Audit("storage.load_all"), handles.LoadAllStorages)
+	storage.GET("/cache/list", handles.ListStorageCache)
+	storage.POST("/cache/purge", middlewares.Audit("storage.cache_purge"), handles.PurgeStorageCache)
 
 	driver := g.Group("/driver")
 	driver.GET("/list", handles.ListDriverInfo)
@@ -143,11 +190,51 @@ func admin(g *gin.RouterGroup) {
 	setting := g.Group("/setting")
 	setting.GET("/get", handles.GetSetting)
 	setting.GET("/list", handles.ListSettings)
-	setting.POST("/save", handles.SaveSettings)
-	setting.POST("/delete", handles.DeleteSetting)
-	setting.POST("/reset_token", handles.ResetToken)
-	setting.POST("/set_aria2", handles.SetAria2)
-	setting.POST("/set_qbit", handles.SetQbittorrent)
+	setting.POST("/save", middlewares.Audit("setting.save"), handles.SaveSettings)
+	setting.POST("/delete", middlewares.Audit("setting.delete"), handles.DeleteSetting)
+	setting.POST("/reset_token", middlewares.Audit("setting.reset_token"), handles.ResetToken)
+	setting.POST("/set_aria2", middlewares.Audit("setting.set_aria2"), handles.SetAria2)
+	setting.POST("/set_qbit", middlewares.Audit("setting.set_qbit"), handles.SetQbittorrent)
+
+	sync := g.Group("/sync")
+	sync.GET("/list", handles.ListSyncJobs)
+	sync.GET("/get", handles.GetSyncJob)
+	sync.POST("/create", middlewares.Audit("sync.create"), handles.CreateSyncJob)
+	sync.POST("/update", middlewares.Audit("sync.update"), handles.UpdateSyncJob)
+	sync.POST("/delete", middlewares.Audit("sync.delete"), handles.DeleteSyncJob)
+	sync.POST("/run", middlewares.Audit("sync.run"), handles.RunSyncJob)
+	sync.GET("/diff", handles.DiffSyncJob)
+	sync.GET("/runs", handles.ListSyncRuns)
+
+	share := g.Group("/share")
+	share.GET("/list", handles.ListAllShares)
+	share.POST("/delete", middlewares.Audit("share.delete"), handles.DeleteShare)
+
+	uploadLink := g.Group("/upload_link")
+	uploadLink.GET("/list", handles.ListAllUploadLinks)
+	uploadLink.POST("/delete", middlewares.Audit("upload_link.delete"), handles.DeleteUploadLink)
+
+	apiToken := g.Group("/api_token")
+	apiToken.GET("/list", handles.ListAllAPITokens)
+	apiToken.POST("/delete", middlewares.Audit("api_token.delete"), handles.DeleteAPIToken)
+
+	sshKey := g.Group("/ssh_key")
+	sshKey.GET("/list", handles.ListAllSSHPublicKeys)
+	sshKey.POST("/delete", middlewares.Audit("ssh_key.delete"), handles.DeleteSSHPublicKey)
+
+	s3Key := g.Group("/s3_key")
+	s3Key.GET("/list", handles.ListAllS3Keys)
+	s3Key.POST("/create", middlewares.Audit("s3_key.create"), handles.CreateS3Key)
+	s3Key.POST("/delete", middlewares.Audit("s3_key.delete"), handles.DeleteS3Key)
+
+	audit := g.Group("/audit")
+	audit.GET("/list", handles.ListAuditLogs)
+	audit.GET("/export", handles.ExportAuditLogs)
+
+	trash := g.Group("/trash")
+	trash.GET("/list", handles.ListTrash)
+	trash.POST("/restore", middlewares.Audit("trash.restore"), handles.RestoreTrash)
+	trash.POST("/purge", middlewares.Audit("trash.purge"), handles.PurgeTrash)
 
 	task := g.Group("/task")
 	handles.SetupTaskRoute(task)
@@ -170,21 +257,30 @@ func _fs(g *gin.RouterGroup) {
 	g.Any("/get", handles.FsGet)
 	g.Any("/other", handles.FsOther)
 	g.Any("/dirs", handles.FsDirs)
-	g.POST("/mkdir", handles.FsMkdir)
-	g.POST("/rename", handles.FsRename)
-	g.POST("/batch_rename", handles.FsBatchRename)
-	g.POST("/regex_rename", handles.FsRegexRename)
-	g.POST("/move", handles.FsMove)
-	g.POST("/recursive_move", handles.FsRecursiveMove)
-	g.POST("/copy", handles.FsCopy)
-	g.POST("/remove", handles.FsRemove)
-	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
-	g.PUT("/put", middlewares.FsUp, handles.FsStream)
-	g.PUT("/form", middlewares.FsUp, handles.FsForm)
+	g.POST("/mkdir", middlewares.Audit("fs.mkdir"), handles.FsMkdir)
+	g.POST("/rename", middlewares.Audit("fs.rename"), handles.FsRename)
+	g.POST("/batch_rename", middlewares.Audit("fs.batch_rename"), handles.FsBatchRename)
+	g.POST("/regex_rename", middlewares.Audit("fs.regex_rename"), handles.FsRegexRename)
+	g.POST("/move", middlewares.Audit("fs.move"), handles.FsMove)
+	g.POST("/recursive_move", middlewares.Audit("fs.recursive_move"), handles.FsRecursiveMove)
+	g.POST("/copy", middlewares.Audit("fs.copy"), handles.FsCopy)
+	g.POST("/extract", middlewares.Audit("fs.extract"), handles.FsExtract)
+	g.POST("/compute_hash", middlewares.AuthAdmin, middlewares.Audit("fs.compute_hash"), handles.FsComputeHash)
+	g.POST("/archive", handles.FsArchive)
+	g.POST("/remove", middlewares.Audit("fs.remove"), handles.FsRemove)
+	g.POST("/remove_empty_directory", middlewares.Audit("fs.remove_empty_directory"), handles.FsRemoveEmptyDirectory)
+	g.PUT("/put", middlewares.Audit("fs.put"), middlewares.FsUp, handles.FsStream)
+	g.PUT("/form", middlewares.Audit("fs.put"), middlewares.FsUp, handles.FsForm)
+	tus := g.Group("/tus")
+	tus.OPTIONS("", handles.FsTusOptions)
+	tus.POST("", middlewares.Audit("fs.put"), middlewares.FsUp, handles.FsTusCreate)
+	tus.HEAD("/:id", handles.FsTusHead)
+	tus.PATCH("/:id", handles.FsTusPatch)
+	tus.DELETE("/:id", handles.FsTusDelete)
 	g.POST("/link", middlewares.AuthAdmin, handles.Link)
 	//g.POST("/add_aria2", handles.AddOfflineDownload)
 	//g.POST("/add_qbit", handles.AddQbittorrent)
-	g.POST("/add_offline_download", handles.AddOfflineDownload)
+	g.POST("/add_offline_download", middlewares.Audit("fs.add_offline_download"), handles.AddOfflineDownload)
 }
 
 func Cors(r *gin.Engine) {
@@ -32,18 +32,21 @@ var (
 // backend for gofakes3
 type s3Backend struct {
 	meta *sync.Map
+	// the access key of the user the backend serves, empty for the global access key
+	accessKey string
 }
 
 // newBackend creates a new SimpleBucketBackend.
-func newBackend() gofakes3.Backend {
+func newBackend(accessKey string) *s3Backend {
 	return &s3Backend{
-		meta: new(sync.Map),
+		meta:      new(sync.Map),
+		accessKey: accessKey,
 	}
 }
 
 // ListBuckets always returns the default bucket.
 func (b *s3Backend) ListBuckets() ([]gofakes3.BucketInfo, error) {
-	buckets, err := getAndParseBuckets()
+	buckets, err := b.getBuckets()
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +65,7 @@ func (b *s3Backend) ListBuckets() ([]gofakes3.BucketInfo, error) {
 
 // ListBucket lists the objects in the given bucket.
 func (b *s3Backend) ListBucket(bucketName string, prefix *gofakes3.Prefix, page gofakes3.ListBucketPage) (*gofakes3.ObjectList, error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := b.getBucketByName(bucketName)
 	if err != nil {
 		return nil, err
 	}
@@ -82,6 +85,9 @@ func (b *s3Backend) ListBucket(bucketName string, prefix *gofakes3.Prefix, page
 
 	response := gofakes3.NewObjectList()
 	path, remaining := prefixParser(prefix)
+	if !b.hasRight(bucketPath, model.ACLList) {
+		return nil, errAccessDenied
+	}
 
 	err = b.entryListR(bucketPath, path, remaining, prefix.HasDelimiter, response)
 	if err == gofakes3.ErrNoSuchKey {
@@ -99,13 +105,16 @@ func (b *s3Backend) ListBucket(bucketName string, prefix *gofakes3.Prefix, page
 // Note that the metadata is not supported yet.
 func (b *s3Backend) HeadObject(bucketName, objectName string) (*gofakes3.Object, error) {
 	ctx := context.Background()
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := b.getBucketByName(bucketName)
 	if err != nil {
 		return nil, err
 	}
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if !b.hasRight(fp, model.ACLRead) {
+		return nil, gofakes3.KeyNotFound(objectName)
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	node, err := fs.Get(context.WithValue(ctx, "meta", fmeta), fp, &fs.GetArgs{})
 	if err != nil {
@@ -143,13 +152,16 @@ func (b *s3Backend) HeadObject(bucketName, objectName string) (*gofakes3.Object,
 // GetObject fetchs the object from the filesystem.
 func (b *s3Backend) GetObject(bucketName, objectName string, rangeRequest *gofakes3.ObjectRangeRequest) (obj *gofakes3.Object, err error) {
 	ctx := context.Background()
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := b.getBucketByName(bucketName)
 	if err != nil {
 		return nil, err
 	}
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if !b.hasRight(fp, model.ACLRead) {
+		return nil, gofakes3.KeyNotFound(objectName)
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	node, err := fs.Get(context.WithValue(ctx, "meta", fmeta), fp, &fs.GetArgs{})
 	if err != nil {
@@ -261,9 +273,19 @@ func (b *s3Backend) PutObject(
 	bucketName, objectName string,
 	meta map[string]string,
 	input io.Reader, size int64,
+) (result gofakes3.PutObjectResult, err error) {
+	result, err = b.putObject(bucketName, objectName, meta, input, size)
+	b.audit("put", objectPath(bucketName, objectName), "", err)
+	return result, err
+}
+
+func (b *s3Backend) putObject(
+	bucketName, objectName string,
+	meta map[string]string,
+	input io.Reader, size int64,
 ) (result gofakes3.PutObjectResult, err error) {
 	ctx := context.Background()
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := b.getBucketByName(bucketName)
 	if err != nil {
 		return result, err
 	}
@@ -271,6 +293,9 @@ func (b *s3Backend) PutObject(
 
 	fp := path.Join(bucketPath, objectName)
 	reqPath := path.Dir(fp)
+	if !b.hasRight(fp, model.ACLWrite) {
+		return result, errAccessDenied
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	_, err = fs.Get(context.WithValue(ctx, "meta", fmeta), reqPath, &fs.GetArgs{})
 	if err != nil {
@@ -319,7 +344,7 @@ func (b *s3Backend) PutObject(
 func (b *s3Backend) DeleteMulti(bucketName string, objects ...string) (result gofakes3.MultiDeleteResult, rerr error) {
 	for _, object := range objects {
 		if err := b.deleteObject(bucketName, object); err != nil {
-			utils.Log.Errorf("serve s3", "delete object failed: %v", err)
+			utils.Log.Errorf("serve s3: delete object failed: %v", err)
 			result.Error = append(result.Error, gofakes3.ErrorResult{
 				Code:    gofakes3.ErrInternal,
 				Message: gofakes3.ErrInternal.Message(),
@@ -341,15 +366,21 @@ func (b *s3Backend) DeleteObject(bucketName, objectName string) (result gofakes3
 }
 
 // deleteObject deletes the object from the filesystem.
-func (b *s3Backend) deleteObject(bucketName, objectName string) error {
+func (b *s3Backend) deleteObject(bucketName, objectName string) (err error) {
+	defer func() {
+		b.audit("delete", objectPath(bucketName, objectName), "", err)
+	}()
 	ctx := context.Background()
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := b.getBucketByName(bucketName)
 	if err != nil {
 		return err
 	}
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if !b.hasRight(fp, model.ACLDelete) {
+		return errAccessDenied
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	// S3 does not report an error when attemping to delete a key that does not exist, so
 	// we need to skip IsNotExist errors.
@@ -373,7 +404,7 @@ func (b *s3Backend) DeleteBucket(name string) error {
 
 // BucketExists checks if the bucket exists.
 func (b *s3Backend) BucketExists(name string) (exists bool, err error) {
-	buckets, err := getAndParseBuckets()
+	buckets, err := b.getBuckets()
 	if err != nil {
 		return false, err
 	}
@@ -391,9 +422,12 @@ func (b *s3Backend) CopyObject(srcBucket, srcKey, dstBucket, dstKey string, meta
 		//TODO: update meta
 		return result, nil
 	}
+	defer func() {
+		b.audit("copy", objectPath(srcBucket, srcKey), objectPath(dstBucket, dstKey), err)
+	}()
 
 	ctx := context.Background()
-	srcB, err := getBucketByName(srcBucket)
+	srcB, err := b.getBucketByName(srcBucket)
 	if err != nil {
 		return result, err
 	}
@@ -420,7 +454,7 @@ func (b *s3Backend) CopyObject(srcBucket, srcKey, dstBucket, dstKey string, meta
 		meta["mtime"] = swift.TimeToFloatString(srcNode.ModTime())
 	}
 
-	_, err = b.PutObject(dstBucket, dstKey, meta, c.Contents, c.Size)
+	_, err = b.putObject(dstBucket, dstKey, meta, c.Contents, c.Size)
 	if err != nil {
 		return
 	}
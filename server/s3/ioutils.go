@@ -2,7 +2,14 @@
 // Package s3 implements a fake s3 server for alist
 package s3
 
-import "io"
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
 
 type noOpReadCloser struct{}
 
@@ -34,3 +41,69 @@ func (rwc *readerWithCloser) Close() error {
 	}
 	return nil
 }
+
+// chunkedReader decodes the body in the format of aws-chunked, which is sent
+// with the STREAMING-AWS4-HMAC-SHA256-PAYLOAD content sha256, the signatures
+// of the chunks are not verified
+type chunkedReader struct {
+	r      *bufio.Reader
+	remain int64
+	eof    bool
+}
+
+func newChunkedReader(r io.Reader) *chunkedReader {
+	return &chunkedReader{r: bufio.NewReader(r)}
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for c.remain == 0 {
+		if c.eof {
+			return 0, io.EOF
+		}
+		if err := c.nextChunk(); err != nil {
+			return 0, err
+		}
+	}
+	if int64(len(p)) > c.remain {
+		p = p[:c.remain]
+	}
+	n, err := c.r.Read(p)
+	c.remain -= int64(n)
+	if c.remain == 0 && err == nil {
+		err = c.readCRLF()
+	}
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// nextChunk reads the header of the chunk: <hex size>;chunk-signature=<signature>\r\n
+func (c *chunkedReader) nextChunk() error {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return io.ErrUnexpectedEOF
+	}
+	sizeStr, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+	size, err := strconv.ParseInt(sizeStr, 16, 64)
+	if err != nil || size < 0 {
+		return errors.Errorf("invalid chunk size %q", sizeStr)
+	}
+	if size == 0 {
+		c.eof = true
+		return nil
+	}
+	c.remain = size
+	return nil
+}
+
+func (c *chunkedReader) readCRLF() error {
+	var crlf [2]byte
+	if _, err := io.ReadFull(c.r, crlf[:]); err != nil {
+		return err
+	}
+	if crlf != [2]byte{'\r', '\n'} {
+		return errors.New("malformed chunk")
+	}
+	return nil
+}
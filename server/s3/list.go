@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/Mikubill/gofakes3"
+	"github.com/alist-org/alist/v3/internal/model"
 )
 
 func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, response *gofakes3.ObjectList) error {
@@ -26,6 +27,9 @@ func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, resp
 		if !strings.HasPrefix(object, name) {
 			continue
 		}
+		if !b.hasRight(path.Join(fp, object), model.ACLRead) {
+			continue
+		}
 
 		if entry.IsDir() {
 			if addPrefix {
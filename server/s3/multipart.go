@@ -0,0 +1,505 @@
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Mikubill/gofakes3"
+	"github.com/Mikubill/gofakes3/xml"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// multipartUpload is an upload in progress, the parts are staged on the
+// disk and assembled when the upload is completed
+type multipartUpload struct {
+	id        string
+	accessKey string
+	bucket    string
+	object    string
+	meta      map[string]string
+	initiated time.Time
+	dir       string
+
+	mu    sync.Mutex
+	parts map[int]uploadPart
+}
+
+type uploadPart struct {
+	etag     string // the hex md5 of the part
+	size     int64
+	modified time.Time
+}
+
+func (u *multipartUpload) partPath(number int) string {
+	return filepath.Join(u.dir, strconv.Itoa(number))
+}
+
+// lastActive returns the time the last part is uploaded
+func (u *multipartUpload) lastActive() time.Time {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	t := u.initiated
+	for _, part := range u.parts {
+		if part.modified.After(t) {
+			t = part.modified
+		}
+	}
+	return t
+}
+
+// uploadExpiration is how long an upload is kept after the last part is
+// uploaded, the clients may never complete or abort it
+const uploadExpiration = 24 * time.Hour
+
+// uploader keeps the uploads in memory, so that the staged parts of the last
+// run are removed since they can't be completed anymore
+type uploader struct {
+	dir       string
+	mu        sync.Mutex
+	uploads   map[string]*multipartUpload
+	lastSweep time.Time
+}
+
+func newUploader() *uploader {
+	dir := filepath.Join(conf.Conf.TempDir, "s3_multipart")
+	if err := os.RemoveAll(dir); err != nil {
+		log.Warnf("failed remove the staged parts of s3 multipart uploads: %+v", err)
+	}
+	return &uploader{
+		dir:     dir,
+		uploads: make(map[string]*multipartUpload),
+	}
+}
+
+func (u *uploader) begin(accessKey, bucket, object string, meta map[string]string) (*multipartUpload, error) {
+	u.sweep()
+	id := random.String(32)
+	upload := &multipartUpload{
+		id:        id,
+		accessKey: accessKey,
+		bucket:    bucket,
+		object:    object,
+		meta:      meta,
+		initiated: time.Now(),
+		dir:       filepath.Join(u.dir, id),
+		parts:     make(map[int]uploadPart),
+	}
+	if err := os.MkdirAll(upload.dir, 0o700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	u.mu.Lock()
+	u.uploads[id] = upload
+	u.mu.Unlock()
+	return upload, nil
+}
+
+// get returns the upload of the access key, the uploads of the other
+// access keys are invisible
+func (u *uploader) get(accessKey, bucket, object, id string) (*multipartUpload, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	upload, ok := u.uploads[id]
+	if !ok || upload.accessKey != accessKey || upload.bucket != bucket || upload.object != object {
+		return nil, gofakes3.ErrNoSuchUpload
+	}
+	return upload, nil
+}
+
+// take removes the upload from the uploads, so that it can't be completed
+// or aborted twice
+func (u *uploader) take(accessKey, bucket, object, id string) (*multipartUpload, error) {
+	upload, err := u.get(accessKey, bucket, object, id)
+	if err != nil {
+		return nil, err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.uploads[id] != upload {
+		return nil, gofakes3.ErrNoSuchUpload
+	}
+	delete(u.uploads, id)
+	return upload, nil
+}
+
+// sweep removes the expired uploads at most once per hour
+func (u *uploader) sweep() {
+	u.mu.Lock()
+	if time.Since(u.lastSweep) < time.Hour {
+		u.mu.Unlock()
+		return
+	}
+	u.lastSweep = time.Now()
+	var expired []*multipartUpload
+	for id, upload := range u.uploads {
+		if time.Since(upload.lastActive()) > uploadExpiration {
+			delete(u.uploads, id)
+			expired = append(expired, upload)
+		}
+	}
+	u.mu.Unlock()
+	for _, upload := range expired {
+		if err := os.RemoveAll(upload.dir); err != nil {
+			log.Warnf("failed remove the parts of expired s3 multipart upload: %+v", err)
+		}
+	}
+}
+
+func (u *uploader) put(upload *multipartUpload) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.uploads[upload.id] = upload
+}
+
+func (u *uploader) list(accessKey, bucket, prefix string) []*multipartUpload {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var res []*multipartUpload
+	for _, upload := range u.uploads {
+		if upload.accessKey == accessKey && upload.bucket == bucket && strings.HasPrefix(upload.object, prefix) {
+			res = append(res, upload)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].object != res[j].object {
+			return res[i].object < res[j].object
+		}
+		return res[i].initiated.Before(res[j].initiated)
+	})
+	return res
+}
+
+func isMultipartRequest(r *http.Request) bool {
+	query := r.URL.Query()
+	return query.Has("uploads") || query.Has("uploadId")
+}
+
+// serveMultipart serves the requests of the multipart uploads in path style,
+// see https://docs.aws.amazon.com/AmazonS3/latest/userguide/mpuoverview.html
+func (s *server) serveMultipart(w http.ResponseWriter, r *http.Request, b *s3Backend) {
+	bucket, object, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+	var err error
+	switch {
+	case query.Has("uploads") && r.Method == http.MethodPost && object != "":
+		err = s.initiateMultipartUpload(w, r, b, bucket, object)
+	case query.Has("uploads") && r.Method == http.MethodGet && object == "":
+		err = s.listMultipartUploads(w, r, b, bucket)
+	case query.Has("uploadId") && object != "":
+		id := query.Get("uploadId")
+		switch r.Method {
+		case http.MethodPut:
+			err = s.uploadPart(w, r, b, bucket, object, id)
+		case http.MethodGet:
+			err = s.listParts(w, r, b, bucket, object, id)
+		case http.MethodPost:
+			err = s.completeMultipartUpload(w, r, b, bucket, object, id)
+		case http.MethodDelete:
+			err = s.abortMultipartUpload(w, b, bucket, object, id)
+		default:
+			err = gofakes3.ErrMethodNotAllowed
+		}
+	default:
+		err = gofakes3.ErrMethodNotAllowed
+	}
+	if err != nil {
+		writeError(w, r, err)
+	}
+}
+
+func (s *server) initiateMultipartUpload(w http.ResponseWriter, r *http.Request, b *s3Backend, bucketName, object string) error {
+	bucket, err := b.getBucketByName(bucketName)
+	if err != nil {
+		return err
+	}
+	if !b.hasRight(path.Join(bucket.Path, object), model.ACLWrite) {
+		return errAccessDenied
+	}
+	// the metadata is used when the object is put
+	meta := make(map[string]string)
+	for k, v := range r.Header {
+		if k == "Content-Type" || strings.HasPrefix(k, "X-Amz-Meta-") {
+			meta[k] = v[0]
+		}
+	}
+	upload, err := s.uploads.begin(b.accessKey, bucketName, object, meta)
+	if err != nil {
+		return err
+	}
+	return writeXML(w, struct {
+		XMLName xml.Name `xml:"InitiateMultipartUploadResult"`
+		gofakes3.InitiateMultipartUpload
+	}{
+		InitiateMultipartUpload: gofakes3.InitiateMultipartUpload{
+			Bucket:   bucketName,
+			Key:      object,
+			UploadID: gofakes3.UploadID(upload.id),
+		},
+	})
+}
+
+func (s *server) uploadPart(w http.ResponseWriter, r *http.Request, b *s3Backend, bucket, object, id string) error {
+	defer r.Body.Close()
+	if r.Header.Get("X-Amz-Copy-Source") != "" {
+		return gofakes3.ErrNotImplemented
+	}
+	number, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || number <= 0 || number > gofakes3.MaxUploadPartNumber {
+		return gofakes3.ErrInvalidPart
+	}
+	upload, err := s.uploads.get(b.accessKey, bucket, object, id)
+	if err != nil {
+		return err
+	}
+	var body io.Reader = r.Body
+	size := r.ContentLength
+	if r.Header.Get("X-Amz-Content-Sha256") == "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+		body = newChunkedReader(r.Body)
+		if size, err = strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64); err != nil {
+			return gofakes3.ErrMissingContentLength
+		}
+	}
+	if size < 0 {
+		return gofakes3.ErrMissingContentLength
+	}
+
+	// write to a temp file first, so that the part uploaded again replaces
+	// the old one only if it's received completely
+	tmp, err := os.CreateTemp(upload.dir, "part-*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(tmp.Name())
+	h := md5.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if n != size {
+		return gofakes3.ErrIncompleteBody
+	}
+	sum := h.Sum(nil)
+	if contentMD5 := r.Header.Get("Content-MD5"); contentMD5 != "" && contentMD5 != base64.StdEncoding.EncodeToString(sum) {
+		return gofakes3.ErrBadDigest
+	}
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+	if err = os.Rename(tmp.Name(), upload.partPath(number)); err != nil {
+		return errors.WithStack(err)
+	}
+	part := uploadPart{
+		etag:     hex.EncodeToString(sum),
+		size:     n,
+		modified: time.Now(),
+	}
+	upload.parts[number] = part
+	w.Header().Set("ETag", `"`+part.etag+`"`)
+	return nil
+}
+
+func (s *server) listParts(w http.ResponseWriter, r *http.Request, b *s3Backend, bucket, object, id string) error {
+	upload, err := s.uploads.get(b.accessKey, bucket, object, id)
+	if err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	marker, _ := strconv.Atoi(query.Get("part-number-marker"))
+	maxParts, err := strconv.Atoi(query.Get("max-parts"))
+	if err != nil || maxParts <= 0 || maxParts > 1000 {
+		maxParts = 1000
+	}
+	res := gofakes3.ListMultipartUploadPartsResult{
+		Bucket:           bucket,
+		Key:              object,
+		UploadID:         gofakes3.UploadID(id),
+		PartNumberMarker: marker,
+		MaxParts:         int64(maxParts),
+	}
+	upload.mu.Lock()
+	numbers := make([]int, 0, len(upload.parts))
+	for number := range upload.parts {
+		if number > marker {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+	if len(numbers) > maxParts {
+		numbers = numbers[:maxParts]
+		res.IsTruncated = true
+	}
+	for _, number := range numbers {
+		part := upload.parts[number]
+		res.Parts = append(res.Parts, gofakes3.ListMultipartUploadPartItem{
+			PartNumber:   number,
+			LastModified: gofakes3.NewContentTime(part.modified),
+			ETag:         `"` + part.etag + `"`,
+			Size:         part.size,
+		})
+		res.NextPartNumberMarker = number
+	}
+	upload.mu.Unlock()
+	return writeXML(w, res)
+}
+
+func (s *server) listMultipartUploads(w http.ResponseWriter, r *http.Request, b *s3Backend, bucket string) error {
+	if _, err := b.getBucketByName(bucket); err != nil {
+		return err
+	}
+	prefix := r.URL.Query().Get("prefix")
+	res := gofakes3.ListMultipartUploadsResult{
+		Bucket:     bucket,
+		Prefix:     prefix,
+		MaxUploads: 1000,
+	}
+	for _, upload := range s.uploads.list(b.accessKey, bucket, prefix) {
+		res.Uploads = append(res.Uploads, gofakes3.ListMultipartUploadItem{
+			Key:       upload.object,
+			UploadID:  gofakes3.UploadID(upload.id),
+			Initiated: gofakes3.NewContentTime(upload.initiated),
+		})
+	}
+	return writeXML(w, res)
+}
+
+func (s *server) abortMultipartUpload(w http.ResponseWriter, b *s3Backend, bucket, object, id string) error {
+	upload, err := s.uploads.take(b.accessKey, bucket, object, id)
+	if err != nil {
+		return err
+	}
+	if err = os.RemoveAll(upload.dir); err != nil {
+		log.Warnf("failed remove the parts of s3 multipart upload: %+v", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// completeMultipartUpload streams the parts into the storage, the upload is
+// kept if it fails so that the client can retry it
+func (s *server) completeMultipartUpload(w http.ResponseWriter, r *http.Request, b *s3Backend, bucket, object, id string) error {
+	var req gofakes3.CompleteMultipartUploadRequest
+	body, err := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = xml.Unmarshal(body, &req); err != nil || len(req.Parts) == 0 {
+		return gofakes3.ErrMalformedXML
+	}
+	upload, err := s.uploads.take(b.accessKey, bucket, object, id)
+	if err != nil {
+		return err
+	}
+	etag, err := s.assemble(b, upload, req.Parts)
+	if err != nil {
+		s.uploads.put(upload)
+		return err
+	}
+	if err = os.RemoveAll(upload.dir); err != nil {
+		log.Warnf("failed remove the parts of s3 multipart upload: %+v", err)
+	}
+	return writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		gofakes3.CompleteMultipartUploadResult
+	}{
+		CompleteMultipartUploadResult: gofakes3.CompleteMultipartUploadResult{
+			Location: r.URL.Path,
+			Bucket:   bucket,
+			Key:      object,
+			ETag:     etag,
+		},
+	})
+}
+
+// assemble checks the parts and puts them as the object, the etag of the
+// object is the md5 of the md5s of the parts like s3
+func (s *server) assemble(b *s3Backend, upload *multipartUpload, parts []gofakes3.CompletedPart) (string, error) {
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+	var (
+		size  int64
+		paths = make([]string, 0, len(parts))
+		h     = md5.New()
+	)
+	for i, p := range parts {
+		if i > 0 && p.PartNumber <= parts[i-1].PartNumber {
+			return "", gofakes3.ErrInvalidPartOrder
+		}
+		part, ok := upload.parts[p.PartNumber]
+		if !ok || strings.Trim(p.ETag, `"`) != part.etag {
+			return "", gofakes3.ErrInvalidPart
+		}
+		sum, _ := hex.DecodeString(part.etag)
+		h.Write(sum)
+		size += part.size
+		paths = append(paths, upload.partPath(p.PartNumber))
+	}
+	rc := &partsReader{paths: paths}
+	defer rc.Close()
+	if _, err := b.PutObject(upload.bucket, upload.object, upload.meta, rc, size); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(h.Sum(nil)), len(parts)), nil
+}
+
+// partsReader reads the part files one by one, the files are opened lazily
+// since there may be thousands of parts
+type partsReader struct {
+	paths []string
+	cur   *os.File
+}
+
+func (p *partsReader) Read(b []byte) (int, error) {
+	for {
+		if p.cur == nil {
+			if len(p.paths) == 0 {
+				return 0, io.EOF
+			}
+			f, err := os.Open(p.paths[0])
+			if err != nil {
+				return 0, err
+			}
+			p.cur, p.paths = f, p.paths[1:]
+		}
+		n, err := p.cur.Read(b)
+		if errors.Is(err, io.EOF) {
+			_ = p.cur.Close()
+			p.cur = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (p *partsReader) Close() error {
+	if p.cur == nil {
+		return nil
+	}
+	return p.cur.Close()
+}
+
+func writeXML(w http.ResponseWriter, v any) error {
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write([]byte(xml.Header))
+	return xml.NewEncoder(w).Encode(v)
+}
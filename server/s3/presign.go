@@ -0,0 +1,162 @@
+package s3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the query of the presigned url in signature version 4, see
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html
+const (
+	amzAlgorithm     = "X-Amz-Algorithm"
+	amzCredential    = "X-Amz-Credential"
+	amzDate          = "X-Amz-Date"
+	amzExpires       = "X-Amz-Expires"
+	amzSignedHeaders = "X-Amz-SignedHeaders"
+	amzSignature     = "X-Amz-Signature"
+	amzSecurityToken = "X-Amz-Security-Token"
+
+	signV4Algorithm  = "AWS4-HMAC-SHA256"
+	iso8601Format    = "20060102T150405Z"
+	yyyymmdd         = "20060102"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	maxPresignExpiry = 7 * 24 * time.Hour
+	// the max clock skew between the client and the server
+	maxClockSkew = 15 * time.Minute
+)
+
+var presignedQueries = []string{amzAlgorithm, amzCredential, amzDate, amzExpires, amzSignedHeaders, amzSignature, amzSecurityToken}
+
+func isPresigned(r *http.Request) bool {
+	return r.URL.Query().Has(amzSignature)
+}
+
+// stripPresigned removes the query of the presigned url, so that the
+// request is served like a request signed in the header
+func stripPresigned(r *http.Request) {
+	query := r.URL.Query()
+	for _, k := range presignedQueries {
+		query.Del(k)
+	}
+	r.URL.RawQuery = query.Encode()
+}
+
+func presignError(code, description string) error {
+	status := http.StatusForbidden
+	if code == "AuthorizationQueryParametersError" {
+		status = http.StatusBadRequest
+	}
+	return s3Error{
+		Code:           code,
+		Description:    description,
+		HTTPStatusCode: status,
+	}
+}
+
+// verifyPresigned verifies the signature and the expiry of the presigned url
+func verifyPresigned(r *http.Request, secret string, now time.Time) error {
+	query := r.URL.Query()
+	if query.Get(amzAlgorithm) != signV4Algorithm {
+		return presignError("AuthorizationQueryParametersError", "X-Amz-Algorithm only supports \"AWS4-HMAC-SHA256\"")
+	}
+	// <access key>/<date>/<region>/s3/aws4_request
+	cred := strings.Split(query.Get(amzCredential), "/")
+	if len(cred) != 5 || cred[3] != "s3" || cred[4] != "aws4_request" {
+		return presignError("AuthorizationQueryParametersError", "Error parsing the X-Amz-Credential parameter")
+	}
+	t, err := time.Parse(iso8601Format, query.Get(amzDate))
+	if err != nil || t.Format(yyyymmdd) != cred[1] {
+		return presignError("AuthorizationQueryParametersError", "X-Amz-Date must be in the ISO8601 Long Format \"yyyyMMdd'T'HHmmss'Z'\"")
+	}
+	expires, err := strconv.ParseInt(query.Get(amzExpires), 10, 64)
+	if err != nil || expires <= 0 || time.Duration(expires)*time.Second > maxPresignExpiry {
+		return presignError("AuthorizationQueryParametersError", "X-Amz-Expires must be between 1 and 604800 seconds")
+	}
+	if now.Before(t.Add(-maxClockSkew)) {
+		return presignError("AccessDenied", "Request is not valid yet")
+	}
+	if now.After(t.Add(time.Duration(expires) * time.Second)) {
+		return presignError("AccessDenied", "Request has expired")
+	}
+	signedHeaders := strings.Split(query.Get(amzSignedHeaders), ";")
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		uriEncode(r.URL.Path, false),
+		canonicalQuery(query),
+		canonicalHeaders(r, signedHeaders),
+		strings.Join(signedHeaders, ";"),
+		unsignedPayload,
+	}, "\n")
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	scope := strings.Join(cred[1:], "/")
+	stringToSign := signV4Algorithm + "\n" + query.Get(amzDate) + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+	key := hmacSHA256([]byte("AWS4"+secret), cred[1])
+	for _, s := range cred[2:] {
+		key = hmacSHA256(key, s)
+	}
+	sig := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	if !hmac.Equal([]byte(sig), []byte(query.Get(amzSignature))) {
+		return presignError("SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided. Check your key and signing method.")
+	}
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery sorts the query by the encoded keys, the signature is excluded
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for k, vs := range query {
+		if k == amzSignature {
+			continue
+		}
+		for _, v := range vs {
+			pairs = append(pairs, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+func canonicalHeaders(r *http.Request, signedHeaders []string) string {
+	var sb strings.Builder
+	for _, h := range signedHeaders {
+		var v string
+		if h == "host" {
+			v = r.Host
+		} else {
+			values := r.Header.Values(h)
+			for i := range values {
+				values[i] = strings.Join(strings.Fields(values[i]), " ")
+			}
+			v = strings.Join(values, ",")
+		}
+		sb.WriteString(h + ":" + v + "\n")
+	}
+	return sb.String()
+}
+
+// uriEncode encodes the string like the aws sdk, the slash is kept in the path
+func uriEncode(s string, encodeSlash bool) string {
+	var sb strings.Builder
+	for _, b := range []byte(s) {
+		if b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b >= '0' && b <= '9' ||
+			b == '-' || b == '_' || b == '.' || b == '~' || (b == '/' && !encodeSlash) {
+			sb.WriteByte(b)
+			continue
+		}
+		sb.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{b})))
+	}
+	return sb.String()
+}
@@ -6,22 +6,184 @@ import (
 	"context"
 	"math/rand"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Mikubill/gofakes3"
+	"github.com/Mikubill/gofakes3/signature"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 // Make a new S3 Server to serve the remote
 func NewServer(ctx context.Context) (h http.Handler, err error) {
+	s := &server{
+		uploads: newUploader(),
+	}
+	global := authlistResolver()
+	for accessKey, secretKey := range global {
+		s.globalKey, s.globalSecret = accessKey, secretKey
+	}
+	signature.StoreKeys(global)
+	s.global = newPrincipal(newBackend(""), s.globalSecret)
+	return s, nil
+}
+
+func newFaker(backend gofakes3.Backend) *gofakes3.GoFakeS3 {
 	var newLogger logger
-	faker := gofakes3.New(
-		newBackend(),
+	return gofakes3.New(
+		backend,
 		// gofakes3.WithHostBucket(!opt.pathBucketMode),
 		gofakes3.WithLogger(newLogger),
 		gofakes3.WithRequestID(rand.Uint64()),
 		gofakes3.WithoutVersioning(),
-		gofakes3.WithV4Auth(authlistResolver()),
 		gofakes3.WithIntegrityCheck(true), // Check Content-MD5 if supplied
 	)
+}
+
+// principal is the backend of an access key, the requests are authorized
+// by the server before served by the faker
+type principal struct {
+	backend *s3Backend
+	secret  string // empty if the access key doesn't require auth
+	faker   http.Handler
+}
+
+func newPrincipal(backend *s3Backend, secret string) *principal {
+	return &principal{
+		backend: backend,
+		secret:  secret,
+		faker:   newFaker(backend).Server(),
+	}
+}
+
+// server dispatches the requests signed by the s3 key of a user or the access
+// key of an api token to a faker whose backend is bound to the user, the others
+// are served by the global access key. The multipart uploads are served by the
+// server itself since the faker keeps the parts in memory.
+type server struct {
+	global       *principal
+	globalKey    string
+	globalSecret string
+	users        sync.Map // access key -> *principal
+	uploads      *uploader
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p, err := s.getPrincipal(getAccessKey(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err = p.authorize(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if isMultipartRequest(r) {
+		s.serveMultipart(w, r, p.backend)
+		return
+	}
+	p.faker.ServeHTTP(w, r)
+}
+
+func (s *server) getPrincipal(accessKey string) (*principal, error) {
+	if accessKey == "" || accessKey == s.globalKey {
+		return s.global, nil
+	}
+	_, secret, err := op.GetS3Credential(accessKey)
+	if err != nil {
+		// the revoked keys are still known by the signature verifier, so
+		// that they must be rejected here
+		return nil, s3Error{
+			Code:           "InvalidAccessKeyId",
+			Description:    "The access key ID you provided does not exist in our records.",
+			HTTPStatusCode: http.StatusForbidden,
+		}
+	}
+	p, ok := s.users.Load(accessKey)
+	if !ok {
+		signature.StoreKeys(map[string]string{accessKey: secret})
+		p, _ = s.users.LoadOrStore(accessKey, newPrincipal(newBackend(accessKey), secret))
+	}
+	return p.(*principal), nil
+}
+
+// authorize verifies the signature in the authorization header or the query
+// of a presigned url, the query of presigned url is removed after that
+func (p *principal) authorize(r *http.Request) error {
+	if isPresigned(r) {
+		if p.secret != "" {
+			if err := verifyPresigned(r, p.secret, time.Now()); err != nil {
+				return err
+			}
+		}
+		stripPresigned(r)
+		return nil
+	}
+	if p.secret == "" {
+		return nil
+	}
+	if code := signature.V4SignVerify(r); code != signature.ErrNone {
+		return s3Error(signature.GetAPIError(code))
+	}
+	return nil
+}
+
+// getAccessKey returns the access key of the credential in the authorization
+// header or the query of presigned url
+func getAccessKey(r *http.Request) string {
+	cred := r.URL.Query().Get("X-Amz-Credential")
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		i := strings.Index(auth, "Credential=")
+		if i < 0 {
+			return ""
+		}
+		cred = auth[i+len("Credential="):]
+	}
+	accessKey, _, _ := strings.Cut(cred, "/")
+	return accessKey
+}
+
+// s3Error is an error with the status code, it's written as the s3 error response
+type s3Error signature.APIError
+
+func (e s3Error) Error() string {
+	return e.Code + ": " + e.Description
+}
 
-	return faker.Server(), nil
+// writeError writes the error in the format of s3, the errors of gofakes3
+// keep their codes and the others are internal errors
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var resp s3Error
+	if !errors.As(err, &resp) {
+		var e gofakes3.Error
+		if errors.As(err, &e) {
+			code := e.ErrorCode()
+			resp = s3Error{
+				Code:           string(code),
+				Description:    code.Message(),
+				HTTPStatusCode: code.Status(),
+			}
+			if er, ok := e.(*gofakes3.ErrorResponse); ok {
+				resp.Description = er.Message
+			}
+			if code == "AccessDenied" {
+				resp.HTTPStatusCode = http.StatusForbidden
+			}
+		} else {
+			log.Errorf("[s3] %s %s: %+v", r.Method, r.URL.Path, err)
+			resp = s3Error{
+				Code:           string(gofakes3.ErrInternal),
+				Description:    "We encountered an internal error. Please try again.",
+				HTTPStatusCode: http.StatusInternalServerError,
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(resp.HTTPStatusCode)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(signature.EncodeAPIErrorToResponse(signature.APIError(resp)))
+	}
 }
@@ -0,0 +1,249 @@
+package s3_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+var (
+	client   *awss3.S3
+	endpoint string
+)
+
+func TestMain(m *testing.M) {
+	root, _ := os.MkdirTemp("", "alist-s3-*")
+	conf.Conf.TempDir, _ = os.MkdirTemp("", "alist-s3-temp-*")
+	ts, err := startServer(root)
+	if err != nil {
+		panic(err)
+	}
+	endpoint = ts.URL
+	client = newClient("access", "secret")
+	code := m.Run()
+	ts.Close()
+	_ = os.RemoveAll(root)
+	_ = os.RemoveAll(conf.Conf.TempDir)
+	os.Exit(code)
+}
+
+func newClient(accessKey, secretKey string) *awss3.S3 {
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:         aws.String(endpoint),
+		Region:           aws.String("alist"),
+		S3ForcePathStyle: aws.Bool(true),
+		Credentials:      credentials.NewStaticCredentials(accessKey, secretKey, ""),
+	}))
+	return awss3.New(sess)
+}
+
+func startServer(root string) (*httptest.Server, error) {
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0o755); err != nil {
+		return nil, err
+	}
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  `{"root_folder_path":"` + root + `"}`,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = op.SaveSettingItems([]model.SettingItem{
+		{Key: conf.S3AccessKeyId, Value: "access", Type: conf.TypeString, Group: model.S3},
+		{Key: conf.S3SecretAccessKey, Value: "secret", Type: conf.TypeString, Group: model.S3},
+		{Key: conf.S3Buckets, Value: `[{"name":"bucket","path":"/local"},{"name":"sub","path":"/local/sub"}]`, Type: conf.TypeString, Group: model.S3},
+	})
+	if err != nil {
+		return nil, err
+	}
+	h, err := s3.NewServer(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return httptest.NewServer(h), nil
+}
+
+func TestMultipartUpload(t *testing.T) {
+	content := make([]byte, 11<<20)
+	rand.New(rand.NewSource(1)).Read(content)
+	uploader := s3manager.NewUploaderWithClient(client, func(u *s3manager.Uploader) {
+		u.PartSize = 5 << 20
+		u.Concurrency = 2
+	})
+	if _, err := uploader.Upload(&s3manager.UploadInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("big.bin"),
+		Body:   bytes.NewReader(content),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	out, err := client.GetObject(&awss3.GetObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("big.bin"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(out.Body)
+	_ = out.Body.Close()
+	if err != nil || !bytes.Equal(data, content) {
+		t.Fatalf("unexpected object of %d bytes: %v", len(data), err)
+	}
+
+	created, err := client.CreateMultipartUpload(&awss3.CreateMultipartUploadInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("aborted.bin"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.UploadPart(&awss3.UploadPartInput{
+		Bucket:     aws.String("bucket"),
+		Key:        aws.String("aborted.bin"),
+		UploadId:   created.UploadId,
+		PartNumber: aws.Int64(1),
+		Body:       bytes.NewReader([]byte("part")),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	parts, err := client.ListParts(&awss3.ListPartsInput{
+		Bucket:   aws.String("bucket"),
+		Key:      aws.String("aborted.bin"),
+		UploadId: created.UploadId,
+	})
+	if err != nil || len(parts.Parts) != 1 || *parts.Parts[0].Size != 4 {
+		t.Fatalf("unexpected parts %v: %v", parts, err)
+	}
+	if _, err = client.AbortMultipartUpload(&awss3.AbortMultipartUploadInput{
+		Bucket:   aws.String("bucket"),
+		Key:      aws.String("aborted.bin"),
+		UploadId: created.UploadId,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.CompleteMultipartUpload(&awss3.CompleteMultipartUploadInput{
+		Bucket:   aws.String("bucket"),
+		Key:      aws.String("aborted.bin"),
+		UploadId: created.UploadId,
+	}); err == nil {
+		t.Fatal("aborted upload is completed")
+	}
+}
+
+func TestPresignedURL(t *testing.T) {
+	if _, err := client.PutObject(&awss3.PutObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("shared.txt"),
+		Body:   strings.NewReader("hello alist s3"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	req, _ := client.GetObjectRequest(&awss3.GetObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("shared.txt"),
+	})
+	u, err := req.Presign(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(data) != "hello alist s3" {
+		t.Fatalf("unexpected response %d: %s", resp.StatusCode, data)
+	}
+
+	resp, err = http.Get(strings.Replace(u, "X-Amz-Signature=", "X-Amz-Signature=0", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("unexpected status of tampered url: %d", resp.StatusCode)
+	}
+}
+
+func TestUserKey(t *testing.T) {
+	users := []*model.User{
+		{Username: "s3reader", BasePath: "/local/sub"},
+		{Username: "s3writer", BasePath: "/local/sub", Permission: 1 << 3},
+	}
+	clients := make([]*awss3.S3, len(users))
+	for i, u := range users {
+		u.SetPassword("password")
+		if err := op.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+		k, err := op.CreateS3Key(u, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		clients[i] = newClient(k.AccessKey, k.SecretKey)
+	}
+	reader, writer := clients[0], clients[1]
+
+	buckets, err := reader.ListBuckets(&awss3.ListBucketsInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets.Buckets) != 1 || *buckets.Buckets[0].Name != "sub" {
+		t.Fatalf("unexpected buckets: %v", buckets.Buckets)
+	}
+	put := &awss3.PutObjectInput{
+		Bucket: aws.String("sub"),
+		Key:    aws.String("a.txt"),
+		Body:   strings.NewReader("a"),
+	}
+	if _, err = reader.PutObject(put); err == nil {
+		t.Fatal("user without write permission can put")
+	}
+	put.Body = strings.NewReader("a")
+	if _, err = writer.PutObject(put); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = writer.HeadObject(&awss3.HeadObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("sub/a.txt"),
+	}); err == nil {
+		t.Fatal("user can access the bucket outside of the base path")
+	}
+	if _, err = newClient(users[0].Username, "wrong").ListBuckets(&awss3.ListBucketsInput{}); err == nil {
+		t.Fatal("unknown access key is accepted")
+	}
+}
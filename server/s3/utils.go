@@ -5,6 +5,7 @@ package s3
 import (
 	"context"
 	"encoding/json"
+	"path"
 	"strings"
 
 	"github.com/Mikubill/gofakes3"
@@ -14,6 +15,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
 )
 
 type Bucket struct {
@@ -40,6 +42,106 @@ func getBucketByName(name string) (Bucket, error) {
 	return Bucket{}, gofakes3.BucketNotFound(name)
 }
 
+// objectPath returns the path of the object, it's empty if the bucket is not found
+func objectPath(bucketName, objectName string) string {
+	bucket, err := getBucketByName(bucketName)
+	if err != nil {
+		return ""
+	}
+	return path.Join(bucket.Path, objectName)
+}
+
+var errAccessDenied = gofakes3.ErrorMessage("AccessDenied", "Access Denied")
+
+// getUser returns the user of the access key the backend serves, it's nil
+// for the global access key which is not bound to a user
+func (b *s3Backend) getUser() (*model.User, error) {
+	if b.accessKey == "" {
+		return nil, nil
+	}
+	user, _, err := op.GetS3Credential(b.accessKey)
+	return user, err
+}
+
+// getBuckets returns the buckets visible to the backend, the user of an access
+// key only sees the buckets under its base path
+func (b *s3Backend) getBuckets() ([]Bucket, error) {
+	buckets, err := getAndParseBuckets()
+	if err != nil {
+		return nil, err
+	}
+	user, err := b.getUser()
+	if err != nil || user == nil {
+		return buckets, err
+	}
+	var res []Bucket
+	for _, bucket := range buckets {
+		if utils.IsSubPath(user.BasePath, bucket.Path) {
+			res = append(res, bucket)
+		}
+	}
+	return res, nil
+}
+
+func (b *s3Backend) getBucketByName(name string) (Bucket, error) {
+	buckets, err := b.getBuckets()
+	if err != nil {
+		return Bucket{}, err
+	}
+	for _, bucket := range buckets {
+		if bucket.Name == name {
+			return bucket, nil
+		}
+	}
+	return Bucket{}, gofakes3.BucketNotFound(name)
+}
+
+// hasRight checks the acl right at the path, only the entries for all users
+// apply to the global access key, the user of an access key is also limited
+// by its base path and permissions
+func (b *s3Backend) hasRight(path string, right int32) bool {
+	user, err := b.getUser()
+	if err != nil {
+		return false
+	}
+	if user != nil && !utils.IsSubPath(user.BasePath, path) {
+		return false
+	}
+	if allowed, matched := op.CheckACL(user, path, right); matched {
+		return allowed
+	}
+	if user == nil {
+		return true
+	}
+	switch right {
+	case model.ACLWrite:
+		return user.CanWrite()
+	case model.ACLDelete:
+		return user.CanRemove()
+	}
+	return true
+}
+
+// audit records the write of s3, the operator is the user of the access key,
+// or the global access key which is not bound to a user
+func (b *s3Backend) audit(action, src, dst string, err error) {
+	username := setting.GetStr(conf.S3AccessKeyId)
+	if user, _ := b.getUser(); user != nil {
+		username = user.Username
+	}
+	l := &model.AuditLog{
+		Username: username,
+		Action:   "s3." + action,
+		SrcPath:  src,
+		DstPath:  dst,
+		Success:  err == nil,
+	}
+	if err != nil {
+		l.Message = err.Error()
+	}
+	op.RecordAudit(l)
+}
+
 func getDirEntries(path string) ([]model.Obj, error) {
 	ctx := context.Background()
 	meta, _ := op.GetNearestMeta(path)
@@ -0,0 +1,115 @@
+package sftp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// fileReader reads the file from its link, which is got on the first read
+type fileReader struct {
+	ctx  context.Context
+	path string
+	size int64
+
+	mu sync.Mutex
+	lr *stream.LinkReader
+}
+
+func newFileReader(ctx context.Context, path string, size int64) *fileReader {
+	return &fileReader{ctx: ctx, path: path, size: size}
+}
+
+func (r *fileReader) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	if r.lr == nil {
+		link, _, err := fs.Link(r.ctx, r.path, model.LinkArgs{
+			Header: http.Header{},
+		})
+		if err != nil {
+			return 0, err
+		}
+		r.lr = stream.NewLinkReader(r.ctx, link, r.size, 0)
+	}
+	return r.lr.ReadAt(p, off)
+}
+
+// Close is called by the sftp server when the client closes the handle
+func (r *fileReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lr == nil {
+		return nil
+	}
+	return r.lr.Close()
+}
+
+// fileWriter buffers the content in a temp file since the client may write
+// out of order, it's uploaded when the client closes the handle
+type fileWriter struct {
+	ctx  context.Context
+	path string
+	tmp  *os.File
+	done func(err error)
+}
+
+func newFileWriter(ctx context.Context, path string) (*fileWriter, error) {
+	tmp, err := os.CreateTemp(conf.Conf.TempDir, "sftp-*")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &fileWriter{ctx: ctx, path: path, tmp: tmp}, nil
+}
+
+func (w *fileWriter) WriteAt(p []byte, off int64) (int, error) {
+	return w.tmp.WriteAt(p, off)
+}
+
+// Close uploads the temp file, the temp file is removed even if the upload fails
+func (w *fileWriter) Close() error {
+	err := w.upload()
+	if w.done != nil {
+		w.done(err)
+	}
+	_ = w.tmp.Close()
+	_ = os.Remove(w.tmp.Name())
+	return toStatus(err)
+}
+
+func (w *fileWriter) upload() error {
+	info, err := w.tmp.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err = w.tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	name := stdpath.Base(w.path)
+	s := &stream.FileStream{
+		Ctx: w.ctx,
+		Obj: &model.Object{
+			Name:     name,
+			Size:     info.Size(),
+			Modified: time.Now(),
+		},
+		Mimetype: utils.GetMimeType(name),
+	}
+	// the drivers can use the temp file directly, it's removed when the stream is closed
+	s.SetTmpFile(w.tmp)
+	return fs.PutDirectly(w.ctx, stdpath.Dir(w.path), s)
+}
@@ -0,0 +1,297 @@
+package sftp
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+)
+
+// handler serves the sftp requests of a session of the user
+type handler struct {
+	user   *model.User
+	ctx    context.Context
+	remote net.Addr
+}
+
+func newHandler(user *model.User, remote net.Addr) *handler {
+	return &handler{
+		user:   user,
+		ctx:    context.WithValue(context.Background(), "user", user),
+		remote: remote,
+	}
+}
+
+// realPath returns the path of the request in the alist file system
+func (h *handler) realPath(reqPath string) (string, error) {
+	p, err := h.user.JoinPath(reqPath)
+	if err != nil {
+		return "", sftp.ErrSSHFxPermissionDenied
+	}
+	return p, nil
+}
+
+// checkRight checks the permissions like webdav, the user must be able to
+// manage by webdav to write
+func (h *handler) checkRight(reqPath string, right int32) error {
+	if right != model.ACLRead && right != model.ACLList && !h.user.CanWebdavManage() {
+		return sftp.ErrSSHFxPermissionDenied
+	}
+	if !common.HasRight(h.user, reqPath, right, true) {
+		return sftp.ErrSSHFxPermissionDenied
+	}
+	return nil
+}
+
+func (h *handler) get(reqPath string) (model.Obj, error) {
+	obj, err := fs.Get(h.ctx, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return obj, nil
+}
+
+func (h *handler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	reqPath, err := h.realPath(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+	if err = h.checkRight(reqPath, model.ACLRead); err != nil {
+		return nil, err
+	}
+	obj, err := h.get(reqPath)
+	if err != nil {
+		return nil, err
+	}
+	if obj.IsDir() {
+		return nil, sftp.ErrSSHFxFailure
+	}
+	return newFileReader(h.ctx, reqPath, obj.GetSize()), nil
+}
+
+func (h *handler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	reqPath, err := h.realPath(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+	if err = h.checkRight(reqPath, model.ACLWrite); err != nil {
+		return nil, err
+	}
+	if obj, err := h.get(reqPath); err == nil && obj.IsDir() {
+		return nil, sftp.ErrSSHFxFailure
+	}
+	w, err := newFileWriter(h.ctx, reqPath)
+	if err != nil {
+		return nil, err
+	}
+	w.done = func(err error) {
+		h.audit("put", reqPath, "", err)
+	}
+	return w, nil
+}
+
+func (h *handler) Filecmd(r *sftp.Request) error {
+	reqPath, err := h.realPath(r.Filepath)
+	if err != nil {
+		return err
+	}
+	switch r.Method {
+	case "Setstat":
+		// the attributes like the modified time can't be set to the storages
+		return nil
+	case "Mkdir":
+		if err = h.checkRight(reqPath, model.ACLWrite); err != nil {
+			return err
+		}
+		err = fs.MakeDir(h.ctx, reqPath)
+		h.audit("mkdir", reqPath, "", err)
+		return toStatus(err)
+	case "Rmdir", "Remove":
+		if err = h.checkRight(reqPath, model.ACLDelete); err != nil {
+			return err
+		}
+		obj, err := h.get(reqPath)
+		if err != nil {
+			return err
+		}
+		if obj.IsDir() != (r.Method == "Rmdir") {
+			return sftp.ErrSSHFxFailure
+		}
+		if obj.IsDir() {
+			objs, err := fs.List(h.ctx, reqPath, &fs.ListArgs{NoLog: true})
+			if err != nil {
+				return toStatus(err)
+			}
+			if len(objs) > 0 {
+				return errors.New("directory not empty")
+			}
+		}
+		err = fs.Remove(h.ctx, reqPath)
+		h.audit("delete", reqPath, "", err)
+		return toStatus(err)
+	case "Rename", "PosixRename":
+		dst, err := h.realPath(r.Target)
+		if err != nil {
+			return err
+		}
+		if err = h.checkRight(reqPath, model.ACLDelete); err != nil {
+			return err
+		}
+		if err = h.checkRight(dst, model.ACLWrite); err != nil {
+			return err
+		}
+		if _, err = h.get(reqPath); err != nil {
+			return err
+		}
+		err = move(h.ctx, reqPath, dst)
+		h.audit("move", reqPath, dst, err)
+		return toStatus(err)
+	}
+	return sftp.ErrSSHFxOpUnsupported
+}
+
+func (h *handler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	reqPath, err := h.realPath(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+	switch r.Method {
+	case "Stat":
+		if err = h.checkRight(reqPath, model.ACLRead); err != nil {
+			return nil, err
+		}
+		obj, err := h.get(reqPath)
+		if err != nil {
+			return nil, err
+		}
+		if r.Filepath == "/" {
+			// the name of the root is the name of the base path
+			obj = &model.Object{Name: "/", IsFolder: true, Modified: obj.ModTime()}
+		}
+		return listerAt{fileInfo{obj}}, nil
+	case "List":
+		if err = h.checkRight(reqPath, model.ACLList); err != nil {
+			return nil, err
+		}
+		meta, _ := op.GetNearestMeta(reqPath)
+		objs, err := fs.List(context.WithValue(h.ctx, "meta", meta), reqPath, &fs.ListArgs{})
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		res := make(listerAt, 0, len(objs))
+		for _, o := range objs {
+			if common.HasRight(h.user, stdpath.Join(reqPath, o.GetName()), model.ACLRead, true) {
+				res = append(res, fileInfo{o})
+			}
+		}
+		return res, nil
+	}
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+// audit records the write of sftp like webdav
+func (h *handler) audit(action, src, dst string, err error) {
+	host, _, _ := net.SplitHostPort(h.remote.String())
+	l := &model.AuditLog{
+		Username: h.user.Username,
+		IP:       host,
+		Action:   "sftp." + action,
+		SrcPath:  src,
+		DstPath:  dst,
+		Success:  err == nil,
+	}
+	if err != nil {
+		l.Message = err.Error()
+	}
+	op.RecordAudit(l)
+}
+
+// move moves the src to dst like the MOVE method of webdav
+func move(ctx context.Context, src, dst string) error {
+	srcDir, srcName := stdpath.Split(src)
+	dstDir, dstName := stdpath.Split(dst)
+	if utils.PathEqual(srcDir, dstDir) {
+		return fs.Rename(ctx, src, dstName)
+	}
+	// wait for the cross-storage move to finish, since the client expects it done
+	if _, err := fs.Move(context.WithValue(ctx, conf.NoTaskKey, struct{}{}), src, dstDir); err != nil {
+		return err
+	}
+	if srcName != dstName {
+		return fs.Rename(ctx, stdpath.Join(dstDir, srcName), dstName)
+	}
+	return nil
+}
+
+// toStatus converts the errors of alist to the errors known by the sftp clients
+func toStatus(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errs.IsObjectNotFound(err):
+		return os.ErrNotExist
+	case errors.Is(err, errs.PermissionDenied):
+		return sftp.ErrSSHFxPermissionDenied
+	}
+	return err
+}
+
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(ls []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(ls, l[offset:])
+	if n < len(ls) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// fileInfo presents the obj as os.FileInfo
+type fileInfo struct {
+	obj model.Obj
+}
+
+func (f fileInfo) Name() string {
+	return f.obj.GetName()
+}
+
+func (f fileInfo) Size() int64 {
+	if f.obj.IsDir() {
+		return 0
+	}
+	return f.obj.GetSize()
+}
+
+func (f fileInfo) Mode() os.FileMode {
+	if f.obj.IsDir() {
+		return os.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+func (f fileInfo) ModTime() time.Time {
+	return f.obj.ModTime()
+}
+
+func (f fileInfo) IsDir() bool {
+	return f.obj.IsDir()
+}
+
+func (f fileInfo) Sys() any {
+	return nil
+}
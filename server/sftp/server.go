@@ -0,0 +1,312 @@
+// Package sftp implements a sftp server for alist, the users log in with the
+// password, an api token or a public key of the alist users and have the
+// same permissions as webdav
+package sftp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+var ErrServerClosed = errors.New("sftp: server closed")
+
+const handshakeTimeout = 30 * time.Second
+
+type Server struct {
+	conf     conf.SFTP
+	hostKey  ssh.Signer
+	listener net.Listener
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+func NewServer(c conf.SFTP) (*Server, error) {
+	hostKey, err := loadHostKey(c.HostKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		conf:    c,
+		hostKey: hostKey,
+		conns:   make(map[net.Conn]struct{}),
+	}, nil
+}
+
+// loadHostKey reads the private key of the host, an ed25519 key is generated
+// and saved if the file doesn't exist, so that the fingerprint is stable
+func loadHostKey(path string) (ssh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		signer, err := ssh.ParsePrivateKey(data)
+		return signer, errors.Wrapf(err, "failed parse host key %s", path)
+	}
+	if !os.IsNotExist(err) {
+		return nil, errors.WithStack(err)
+	}
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "alist")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		return nil, errors.Wrap(err, "failed save host key")
+	}
+	log.Infof("generated sftp host key at %s", path)
+	return ssh.NewSignerFromKey(priv)
+}
+
+// Serve listens on the address of the config and serves the connections
+// until the server is closed
+func (s *Server) Serve() error {
+	l, err := net.Listen("tcp", s.conf.Listen)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		_ = l.Close()
+		return ErrServerClosed
+	}
+	s.listener = l
+	s.mu.Unlock()
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return errors.WithStack(err)
+		}
+		if !s.track(nc) {
+			_ = nc.Close()
+			return ErrServerClosed
+		}
+		go func() {
+			defer s.untrack(nc)
+			s.serveConn(nc)
+		}()
+	}
+}
+
+// Addr returns the address the server listens on, it's nil before Serve
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Close stops listening and closes all the connections
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for nc := range s.conns {
+		_ = nc.Close()
+	}
+	return err
+}
+
+func (s *Server) track(nc net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[nc] = struct{}{}
+	return true
+}
+
+func (s *Server) untrack(nc net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, nc)
+}
+
+// serveConn authorizes the client and serves the sftp subsystem of the sessions,
+// the user is resolved from the permissions of the authenticated method only,
+// since the callbacks are also called for the methods that are not used
+func (s *Server) serveConn(nc net.Conn) {
+	defer nc.Close()
+	config := &ssh.ServerConfig{
+		ServerVersion: "SSH-2.0-alist",
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			u, err := passwordUser(meta.User(), string(password))
+			if err != nil {
+				return nil, err
+			}
+			p := userPermissions(u)
+			if u.TokenScope != "" {
+				p.Extensions[apiTokenExt] = string(password)
+			}
+			return p, nil
+		},
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			u, err := op.GetUserBySSHPublicKey(meta.User(), key)
+			if err != nil {
+				return nil, err
+			}
+			if err = checkUser(u); err != nil {
+				return nil, err
+			}
+			return userPermissions(u), nil
+		},
+	}
+	config.AddHostKey(s.hostKey)
+	_ = nc.SetDeadline(time.Now().Add(handshakeTimeout))
+	sc, chans, reqs, err := ssh.NewServerConn(nc, config)
+	if err != nil {
+		log.Debugf("[sftp] handshake with %s: %v", nc.RemoteAddr(), err)
+		return
+	}
+	defer sc.Close()
+	_ = nc.SetDeadline(time.Time{})
+	user, err := sessionUser(sc.Permissions)
+	if err != nil {
+		log.Warnf("[sftp] failed get user of %s: %v", nc.RemoteAddr(), err)
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for nch := range chans {
+		if nch.ChannelType() != "session" {
+			_ = nch.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		ch, chReqs, err := nch.Accept()
+		if err != nil {
+			log.Debugf("[sftp] accept channel from %s: %v", nc.RemoteAddr(), err)
+			continue
+		}
+		go s.serveSession(newHandler(user, nc.RemoteAddr()), ch, chReqs)
+	}
+}
+
+// serveSession only accepts the sftp subsystem, the shell and exec are denied
+func (s *Server) serveSession(h *handler, ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer ch.Close()
+	for req := range reqs {
+		ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+		_ = req.Reply(ok, nil)
+		if !ok {
+			continue
+		}
+		go ssh.DiscardRequests(reqs)
+		rs := sftp.NewRequestServer(ch, sftp.Handlers{
+			FileGet:  h,
+			FilePut:  h,
+			FileCmd:  h,
+			FileList: h,
+		})
+		if err := rs.Serve(); err != nil && !errors.Is(err, io.EOF) {
+			log.Debugf("[sftp] serve %s: %v", h.remote, err)
+		}
+		_ = rs.Close()
+		return
+	}
+}
+
+const (
+	userIDExt   = "user-id"
+	apiTokenExt = "api-token"
+)
+
+// userPermissions keeps the authenticated user in the permissions of the connection
+func userPermissions(user *model.User) *ssh.Permissions {
+	return &ssh.Permissions{Extensions: map[string]string{
+		userIDExt: strconv.FormatUint(uint64(user.ID), 10),
+	}}
+}
+
+// sessionUser returns the user kept in the permissions, the user of an api
+// token is restricted by the token again
+func sessionUser(p *ssh.Permissions) (*model.User, error) {
+	if p == nil {
+		return nil, errs.PermissionDenied
+	}
+	var user *model.User
+	if token, ok := p.Extensions[apiTokenExt]; ok {
+		u, err := op.GetUserByAPIToken(token)
+		if err != nil {
+			return nil, err
+		}
+		user = u
+	} else {
+		id, err := strconv.ParseUint(p.Extensions[userIDExt], 10, 64)
+		if err != nil {
+			return nil, errs.PermissionDenied
+		}
+		if user, err = op.GetUserById(uint(id)); err != nil {
+			return nil, err
+		}
+	}
+	return user, checkUser(user)
+}
+
+// passwordUser authorizes the user by the password or an api token of the user
+func passwordUser(username, password string) (*model.User, error) {
+	user, err := op.GetUserByName(username)
+	if err != nil {
+		return nil, err
+	}
+	if err = user.ValidateRawPassword(password); err != nil {
+		if !strings.HasPrefix(password, model.APITokenPrefix) {
+			return nil, err
+		}
+		if user, err = op.GetUserByAPIToken(password); err != nil {
+			return nil, err
+		}
+		if user.Username != username {
+			return nil, errs.WrongPassword
+		}
+	}
+	return user, checkUser(user)
+}
+
+// checkUser checks the user can log in, the user must be able to read by webdav
+func checkUser(user *model.User) error {
+	if user.IsGuest() || user.Disabled || !user.CanWebdavRead() {
+		return errs.PermissionDenied
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package sftp_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/sftp"
+	client "github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+var (
+	addr   string
+	signer ssh.Signer
+)
+
+func TestMain(m *testing.M) {
+	root, _ := os.MkdirTemp("", "alist-sftp-*")
+	conf.Conf.TempDir, _ = os.MkdirTemp("", "alist-sftp-temp-*")
+	s, err := startServer(root)
+	if err != nil {
+		panic(err)
+	}
+	addr = s.Addr().String()
+	code := m.Run()
+	_ = s.Close()
+	_ = os.RemoveAll(root)
+	_ = os.RemoveAll(conf.Conf.TempDir)
+	os.Exit(code)
+}
+
+func startServer(root string) (*sftp.Server, error) {
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  `{"root_folder_path":"` + root + `"}`,
+	})
+	if err != nil {
+		return nil, err
+	}
+	users := []*model.User{
+		{Username: "writer", Permission: 1<<8 | 1<<9},
+		{Username: "reader", Permission: 1 << 8},
+	}
+	for _, u := range users {
+		u.BasePath = "/"
+		u.SetPassword("password")
+		if err = op.CreateUser(u); err != nil {
+			return nil, err
+		}
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if signer, err = ssh.NewSignerFromKey(priv); err != nil {
+		return nil, err
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = op.CreateSSHPublicKey(users[1], "test", string(ssh.MarshalAuthorizedKey(sshPub))); err != nil {
+		return nil, err
+	}
+	s, err := sftp.NewServer(conf.SFTP{
+		Listen:  "127.0.0.1:0",
+		HostKey: filepath.Join(conf.Conf.TempDir, "host_key"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	go s.Serve()
+	for s.Addr() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return s, nil
+}
+
+func dial(username string, auth ssh.AuthMethod) (*client.Client, error) {
+	sc, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            username,
+		Auth:            []ssh.AuthMethod{auth},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.NewClient(sc)
+	if err != nil {
+		_ = sc.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func login(t *testing.T, username string, auth ssh.AuthMethod) *client.Client {
+	c, err := dial(username, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestServer(t *testing.T) {
+	c := login(t, "writer", ssh.Password("password"))
+
+	if err := c.Mkdir("/local/dir"); err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("hello alist sftp")
+	f, err := c.Create("/local/dir/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	infos, err := c.ReadDir("/local/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Name() != "a.txt" || infos[0].Size() != int64(len(content)) {
+		t.Fatalf("unexpected infos: %+v", infos)
+	}
+	f, err = c.Open("/local/dir/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.Seek(6, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	_ = f.Close()
+	if err != nil || !bytes.Equal(data, content[6:]) {
+		t.Fatalf("unexpected data %q: %v", data, err)
+	}
+	if err = c.Rename("/local/dir/a.txt", "/local/dir/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := c.Stat("/local/dir/b.txt"); err != nil || info.Size() != int64(len(content)) {
+		t.Fatalf("unexpected stat %+v: %v", info, err)
+	}
+	if err = c.RemoveDirectory("/local/dir"); err == nil {
+		t.Fatal("non-empty dir is removed")
+	}
+	if err = c.Remove("/local/dir/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.RemoveDirectory("/local/dir"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Stat("/local/dir"); !os.IsNotExist(err) {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
+
+func TestServerPermission(t *testing.T) {
+	c := login(t, "reader", ssh.PublicKeys(signer))
+	if _, err := c.ReadDir("/local"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Create("/local/a.txt"); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("reader can upload: %v", err)
+	}
+	if err := c.Mkdir("/local/dir"); err == nil {
+		t.Fatal("reader can mkdir")
+	}
+
+	if _, err := dial("reader", ssh.Password("wrong")); err == nil {
+		t.Fatal("login with wrong password")
+	}
+	if _, err := dial("writer", ssh.PublicKeys(signer)); err == nil {
+		t.Fatal("login with the key of another user")
+	}
+}
@@ -4,14 +4,18 @@ import (
 	"context"
 	"crypto/subtle"
 	"net/http"
+	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
 	"github.com/alist-org/alist/v3/server/webdav"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
@@ -43,7 +47,65 @@ func WebDav(dav *gin.RouterGroup) {
 func ServeWebDAV(c *gin.Context) {
 	user := c.MustGet("user").(*model.User)
 	ctx := context.WithValue(c.Request.Context(), "user", user)
+	if !checkDavRights(c, user) {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	start := time.Now()
 	handler.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+	if utils.SliceContains([]string{"PUT", "DELETE", "MKCOL", "COPY", "MOVE"}, c.Request.Method) {
+		auditWebDAV(c, user, start)
+	}
+}
+
+// auditWebDAV records the write of webdav, the result is known by the status
+func auditWebDAV(c *gin.Context, user *model.User, start time.Time) {
+	status := c.Writer.Status()
+	l := &model.AuditLog{
+		Username:  user.Username,
+		IP:        c.ClientIP(),
+		Action:    "webdav." + strings.ToLower(c.Request.Method),
+		SrcPath:   davPath(user, c.Request.URL.Path),
+		Success:   status < 400,
+		CreatedAt: start,
+	}
+	if dst := c.GetHeader("Destination"); dst != "" {
+		if u, err := url.Parse(dst); err == nil {
+			l.DstPath = davPath(user, u.Path)
+		}
+	}
+	if !l.Success {
+		l.Message = http.StatusText(status)
+	}
+	op.RecordAudit(l)
+}
+
+// checkDavRights checks the acl rights required by the method, the legacy
+// permissions of webdav have been checked in WebDAVAuth
+func checkDavRights(c *gin.Context, user *model.User) bool {
+	src := davPath(user, c.Request.URL.Path)
+	dst := ""
+	if u, err := url.Parse(c.GetHeader("Destination")); err == nil && u.Path != "" {
+		dst = davPath(user, u.Path)
+	}
+	switch c.Request.Method {
+	case "GET", "HEAD", "POST", "PROPFIND":
+		return common.HasRight(user, src, model.ACLRead, true)
+	case "PUT", "MKCOL", "PROPPATCH", "LOCK", "UNLOCK":
+		return common.HasRight(user, src, model.ACLWrite, true)
+	case "DELETE":
+		return common.HasRight(user, src, model.ACLDelete, true)
+	case "MOVE":
+		return common.HasRight(user, src, model.ACLDelete, true) && common.HasRight(user, dst, model.ACLWrite, true)
+	case "COPY":
+		return common.HasRight(user, src, model.ACLRead, true) && common.HasRight(user, dst, model.ACLWrite, true)
+	}
+	return true
+}
+
+func davPath(user *model.User, p string) string {
+	p, _ = user.JoinPath(strings.TrimPrefix(p, handler.Prefix))
+	return p
 }
 
 func WebDAVAuth(c *gin.Context) {
@@ -67,6 +129,12 @@ func WebDAVAuth(c *gin.Context) {
 				c.Next()
 				return
 			}
+			if strings.HasPrefix(bt, model.APITokenPrefix) {
+				if user, err := op.GetUserByAPIToken(bt); err == nil {
+					webdavUser(c, guest, user)
+					return
+				}
+			}
 		}
 		if c.Request.Method == "OPTIONS" {
 			c.Set("user", guest)
@@ -79,7 +147,11 @@ func WebDAVAuth(c *gin.Context) {
 		return
 	}
 	user, err := op.GetUserByName(username)
-	if err != nil || user.ValidateRawPassword(password) != nil {
+	if err == nil && user.ValidateRawPassword(password) != nil {
+		// the api token of the user can be used as the password
+		user, err = apiTokenUser(username, password)
+	}
+	if err != nil {
 		if c.Request.Method == "OPTIONS" {
 			c.Set("user", guest)
 			c.Next()
@@ -89,6 +161,11 @@ func WebDAVAuth(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	webdavUser(c, guest, user)
+}
+
+// webdavUser checks the webdav permissions of the authorized user
+func webdavUser(c *gin.Context, guest, user *model.User) {
 	if user.Disabled || !user.CanWebdavRead() {
 		if c.Request.Method == "OPTIONS" {
 			c.Set("user", guest)
@@ -112,3 +189,18 @@ func WebDAVAuth(c *gin.Context) {
 	c.Set("user", user)
 	c.Next()
 }
+
+// apiTokenUser authorizes the user by the api token used as the password
+func apiTokenUser(username, token string) (*model.User, error) {
+	if !strings.HasPrefix(token, model.APITokenPrefix) {
+		return nil, errs.WrongPassword
+	}
+	user, err := op.GetUserByAPIToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if user.Username != username {
+		return nil, errs.WrongPassword
+	}
+	return user, nil
+}
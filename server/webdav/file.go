@@ -14,6 +14,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
 )
 
 // slashClean is equivalent to but slightly more efficient than
@@ -36,7 +37,8 @@ func moveFiles(ctx context.Context, src, dst string, overwrite bool) (status int
 	if srcDir == dstDir {
 		err = fs.Rename(ctx, src, dstName)
 	} else {
-		err = fs.Move(ctx, src, dstDir)
+		// wait for the cross-storage move to finish, since the client expects it done
+		_, err = fs.Move(context.WithValue(ctx, conf.NoTaskKey, struct{}{}), src, dstDir)
 		if err != nil {
 			return http.StatusInternalServerError, err
 		}
@@ -84,6 +86,10 @@ func walkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn
 	if depth == 1 {
 		depth = 0
 	}
+	user, _ := ctx.Value("user").(*model.User)
+	if !common.HasRight(user, name, model.ACLList, true) {
+		return nil
+	}
 	meta, _ := op.GetNearestMeta(name)
 	// Read directory names.
 	objs, err := fs.List(context.WithValue(ctx, "meta", meta), name, &fs.ListArgs{})
@@ -99,6 +105,9 @@ func walkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn
 
 	for _, fileInfo := range objs {
 		filename := path.Join(name, fileInfo.GetName())
+		if !common.HasRight(user, filename, model.ACLRead, true) {
+			continue
+		}
 		if err != nil {
 			if err := walkFn(filename, fileInfo, err); err != nil && err != filepath.SkipDir {
 				return err
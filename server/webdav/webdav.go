@@ -325,6 +325,14 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if err != nil {
 		return http.StatusForbidden, err
 	}
+	conflict, err := model.ParseConflictPolicy(r.Header.Get("Conflict-Policy"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	// "If-None-Match: *" asks to create the file only if it doesn't exist
+	if r.Header.Get("If-None-Match") == "*" {
+		conflict = model.ConflictFail
+	}
 	obj := model.Object{
 		Name:     path.Base(reqPath),
 		Size:     r.ContentLength,
@@ -335,7 +343,12 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 		Obj:      &obj,
 		Reader:   r.Body,
 		Mimetype: r.Header.Get("Content-Type"),
+		Conflict: conflict,
 	}
+	defer func() {
+		_ = r.Body.Close()
+		_ = stream.Close()
+	}()
 	if stream.Mimetype == "" {
 		stream.Mimetype = utils.GetMimeType(reqPath)
 	}
@@ -343,13 +356,15 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if errs.IsNotFoundError(err) {
 		return http.StatusNotFound, err
 	}
-
-	_ = r.Body.Close()
-	_ = stream.Close()
+	if errors.Is(err, errs.ObjectAlreadyExists) {
+		return http.StatusPreconditionFailed, err
+	}
 	// TODO(rost): Returning 405 Method Not Allowed might not be appropriate.
 	if err != nil {
 		return http.StatusMethodNotAllowed, err
 	}
+	// the file may be renamed by the conflict policy
+	reqPath = path.Join(path.Dir(reqPath), stream.GetName())
 	fi, err := fs.Get(ctx, reqPath, &fs.GetArgs{})
 	if err != nil {
 		fi = &obj